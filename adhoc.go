@@ -0,0 +1,72 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// Mapping 为MapAdhoc建立的独立映射。它不进入全局映射，因而不影响、也不受
+// MapStruct等的影响，建立后可并发使用。零值不含任何映射。
+type Mapping struct {
+	id   string            // 唯一标识，区分接收计划缓存
+	stru string            // 结构名
+	m    map[string]entryT // 同全局的mapping
+}
+
+// adhocs 为已建立的独立映射数，用于生成其唯一标识。
+var adhocs uint64
+
+// MapAdhoc 为结构stru建立一个独立映射，用于一次性的报表结构等不宜进入全局映
+// 射的场合。与MapStruct不同，MapAdhoc可以在任何时候调用。stru 以变量值的形式
+// 作参数，可以取零值。
+func MapAdhoc(stru interface{}) (Mapping, error) {
+	t, err := structarg(stru)
+	if err != nil {
+		return Mapping{}, fmt.Errorf("MapAdhoc: %v", err)
+	}
+	s, v := t.Name(), reflect.New(t).Elem()
+	if s == "" {
+		return Mapping{}, fmt.Errorf("MapAdhoc: invalid struct %q", t)
+	}
+	m := make(map[string]entryT)
+	if err := register(m, s, v); err != nil {
+		return Mapping{}, fmt.Errorf("MapAdhoc: %v", err)
+	}
+	id := fmt.Sprintf("@%d", atomic.AddUint64(&adhocs, 1))
+	return Mapping{id, s, m}, nil
+}
+
+// Scan 与包函数Scan相同，但使用独立映射m，dest 的结构须为m所映射的结构。
+func (m Mapping) Scan(rows *sql.Rows, dest ...interface{}) error {
+	if err := scan(rows, &scanOpts{mp: m.m, id: m.id}, dest); err != nil {
+		return fmt.Errorf("Mapping.Scan: %w", err)
+	}
+	return nil
+}
+
+// Buildstr 与包函数Buildstr相同，但使用独立映射m。
+func (m Mapping) Buildstr(data interface{}, field ...string) (string, error) {
+	var sql strings.Builder
+	if err := build(&sql, nil, conf(), m.m, data, field...); err != nil {
+		return "", fmt.Errorf("Mapping.Buildstr: %w", err)
+	}
+	return sql.String(), nil
+}
+
+// BuildstrTo 与包函数BuildstrTo相同，但使用独立映射m。
+func (m Mapping) BuildstrTo(w io.Writer, data interface{},
+	field ...string) error {
+	ew := &errWriter{w: w}
+	err := build(ew, nil, conf(), m.m, data, field...)
+	if err == nil {
+		err = ew.err
+	}
+	if err != nil {
+		return fmt.Errorf("Mapping.BuildstrTo: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type AdhocReport struct {
+	Dept  string `db:"col=department"`
+	Total int
+}
+
+func TestMapAdhoc(t *testing.T) {
+	m, err := MapAdhoc(&AdhocReport{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadmap()["AdhocReport"]; ok {
+		t.Error("MapAdhoc changed the global mapping")
+	}
+	rows := testRows(t, []string{"department", "total"},
+		[]driver.Value{"a", int64(3)})
+	defer rows.Close()
+	var d []*AdhocReport
+	if err = m.Scan(rows, &d); err != nil || *d[0] != (AdhocReport{"a", 3}) {
+		t.Errorf("Scan: %v %v", d, err)
+	}
+	s, err := m.Buildstr(d[0], "Dept")
+	if err != nil || s != `SET department="a"` {
+		t.Errorf("Buildstr: %s %v", s, err)
+	}
+	if _, err = (Mapping{}).Buildstr(d[0]); err == nil {
+		t.Error("zero Mapping accepted")
+	}
+	for _, stru := range []interface{}{nil, 1, struct{ A int }{}} {
+		if _, err = MapAdhoc(stru); err == nil {
+			t.Errorf("MapAdhoc(%#v) accepted", stru)
+		}
+	}
+}
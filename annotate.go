@@ -0,0 +1,82 @@
+package sqlaux
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Annotate 返回一个中间件，按sqlcommenter格式在语句末尾附加注释，如
+// "/*application='app',file='main.go%3A12',traceparent='00-...'*/"，使数据
+// 库端的慢查询日志等可以追溯到应用。app 为应用名，为""时省略；file 为调用
+// sqlaux的源文件名和行号；trace 不为nil时，用其从ctx中取得W3C traceparent，
+// 返回""时省略。已带有注释（以"*/"结尾）的语句不再附加。
+func Annotate(app string, trace func(ctx context.Context) string) Middleware {
+	return func(ctx context.Context, query string,
+		args []interface{}) (string, []interface{}, error) {
+		q := strings.TrimSpace(query)
+		if strings.HasSuffix(q, "*/") {
+			return query, args, nil
+		}
+		kv := map[string]string{"file": caller()}
+		if app != "" {
+			kv["application"] = app
+		}
+		if trace != nil {
+			if t := trace(ctx); t != "" {
+				kv["traceparent"] = t
+			}
+		}
+		return strings.TrimSuffix(q, ";") + " " + sqlcomment(kv), args, nil
+	}
+}
+
+// sqlcomment 按sqlcommenter规范生成注释：键排序，键值URL编码（空格编码为
+// %20），值加单引号。
+func sqlcomment(kv map[string]string) string {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("/*")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%s='%s'", urlencode(k), urlencode(kv[k]))
+	}
+	b.WriteString("*/")
+	return b.String()
+}
+
+// urlencode 按RFC 3986对s作URL编码，"'"、"*"、"/"等均被编码，故结果可直接
+// 置于注释的单引号中。
+func urlencode(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// pkgPath 为sqlaux的包路径，用于在调用栈中跳过sqlaux自身的栈帧。
+var pkgPath = reflect.TypeOf(entryT{}).PkgPath()
+
+// caller 返回调用栈中第一个sqlaux之外的调用者的"文件名:行号"。
+func caller() string {
+	var pc [20]uintptr
+	n := runtime.Callers(2, pc[:])
+	fs := runtime.CallersFrames(pc[:n])
+	for {
+		f, more := fs.Next()
+		if !strings.HasPrefix(f.Function, pkgPath+".") {
+			return fmt.Sprintf("%s:%d", f.File[strings.LastIndex(f.File,
+				"/")+1:], f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package sqlaux
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	old := loadhooks()
+	defer hooks.Store(old)
+	err := Use(Annotate("my app", func(context.Context) string {
+		return "00-abc-01"
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	takeExecs()
+	ExecContext(ctx, tdb, "DELETE FROM t;")
+	ExecContext(ctx, tdb, "DELETE FROM t /* done */")
+	got := takeExecs()
+	re := regexp.MustCompile(`^DELETE FROM t /\*application='my%20app',` +
+		`file='[\w.]+\.go%3A\d+',traceparent='00-abc-01'\*/$`)
+	if len(got) != 2 || !re.MatchString(got[0]) ||
+		got[1] != "DELETE FROM t /* done */" {
+		t.Errorf("got %q", got)
+	}
+
+	want := `/*a='it%27s%20%2A%2F',b%3D='x%2Fy'*/`
+	if s := sqlcomment(map[string]string{"b=": "x/y", "a": "it's */"}); s !=
+		want {
+		t.Errorf("sqlcomment: got %s, want %s", s, want)
+	}
+}
@@ -0,0 +1,222 @@
+package sqlaux
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Buildargs 与Buildstr相同，但值不拼接到语句中，而是生成按d规范的占位符（
+// 即d.Placeholder，如PostgreSQL为$1、$2...，MySQL为?），值作为参数返回，交
+// 由驱动转义和传递，从根本上避免注入风险。返回的SQL形如：
+// data为切片时："(列名1,列名2,...) VALUES ($1,$2,...),..."
+// data为单值时："SET 列名1=$1,列名2=$2,..."
+// 参数的处理与ToMap相同（driver.Valuer、json、零值时间策略等）；geo 字段生成
+// "ST_GeomFromText($n[,srid])"，PostgreSQL的time.Duration字段生成
+// "$n::interval"。$n 占位符从$1开始，与其他片段组合时用Join或Rebase重新编号。
+// d 为nil时使用?占位符。
+//
+// 约定：data、field同Buildstr。
+func Buildargs(d Dialect, data interface{}, field ...string) (Fragment,
+	error) {
+	return buildargs(conf(), d, data, field...)
+}
+
+// buildargs 为Buildargs的实现，c 为配置。
+func buildargs(c *Config, d Dialect, data interface{},
+	field ...string) (f Fragment, err error) {
+	v, err := toslice(data)
+	if err != nil {
+		return f, fmt.Errorf("Buildargs: %v", err)
+	}
+	if len(loadhooks().observers) > 0 {
+		n := v.Len()
+		defer observe("buildargs", []reflect.Type{v.Type().Elem().Elem()},
+			time.Now(), &n, &err)
+	}
+	single := reflect.ValueOf(data).Kind() == reflect.Ptr
+	stru, col := v.Type().Elem().Elem().Name(), ""
+	defer recovered(&err, "Buildargs", &stru, &col)
+	mapping, err := automap(loadmap(), v.Type().Elem().Elem())
+	if err != nil {
+		return f, fmt.Errorf("Buildargs: %v", err)
+	}
+	e, ok := mapping[stru]
+	if !ok {
+		return f, fmt.Errorf("Buildargs: %q has no mapping", stru)
+	}
+	if len(field) == 0 { // default all mapped fields
+		field = e.name.([]string)
+		if w, ok := mapping["w."+stru]; ok { // except computed ones
+			field = w.name.([]string)
+		}
+	}
+	es := make([]entryT, len(field))
+	for i, n := range field {
+		if es[i], ok = mapping["0."+stru+"."+n]; !ok {
+			return f, fmt.Errorf("Buildargs: %v", nofield(mapping, stru, n))
+		}
+	}
+
+	var sql strings.Builder
+	if single {
+		sql.WriteString("SET ")
+	} else {
+		sql.WriteString("(")
+		for i, m := range es {
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString(m.name.(string))
+		}
+		sql.WriteString(") VALUES ")
+	}
+	for i := 0; i < v.Len(); i++ {
+		if v.Index(i).IsNil() {
+			return f, fmt.Errorf("Buildargs: data[%d] is nil", i)
+		}
+		p := v.Index(i).UnsafePointer() // base address
+		if i > 0 {
+			sql.WriteString(",")
+		}
+		if !single {
+			sql.WriteString("(")
+		}
+		j := 0 // number of values
+		for _, m := range es {
+			col = m.name.(string)
+			ptr := reflect.NewAt(m.typ, m.addr(p))
+			if single && m.omit(c, ptr) {
+				continue
+			}
+			skip := c.ZeroTime == ZeroTimeSkip && m.zerotime(ptr)
+			if j > 0 {
+				sql.WriteString(",")
+			}
+			j++
+			if single {
+				sql.WriteString(m.name.(string) + "=")
+			}
+			if skip {
+				sql.WriteString("DEFAULT")
+				continue
+			}
+			s, arg, err := m.placeholder(d, c, len(f.Args)+1, ptr)
+			if err != nil {
+				return f, fmt.Errorf("Buildargs: data[%d] %v", i, err)
+			}
+			sql.WriteString(s)
+			f.Args = append(f.Args, arg)
+		}
+		if single && j == 0 {
+			return f, fmt.Errorf("Buildargs: no field to set")
+		}
+		if !single {
+			sql.WriteString(")")
+		}
+	}
+	f.SQL = sql.String()
+	return f, nil
+}
+
+// BuildWhere 为单表查询生成data（形如*struct）field字段的等值条件：
+// "列名1=$1 AND 列名2=$2 AND ..."，值作为参数返回，用于按字段值查找，如：
+//
+//	f, _ := BuildWhere(d, &User{Name: "x", Dept: 3}, "Name", "Dept")
+//	rows, _ := db.Query("SELECT ... FROM users WHERE "+f.SQL, f.Args...)
+//
+// field缺省时为主键字段。值为NULL的字段（如nil指针）生成"列名 IS NULL"，不
+// 占用占位符。占位符和参数的处理与Buildargs相同。
+func BuildWhere(d Dialect, data interface{}, field ...string) (Fragment,
+	error) {
+	return buildWhere(conf(), d, data, field...)
+}
+
+// buildWhere 为BuildWhere的实现，c 为配置。
+func buildWhere(c *Config, d Dialect, data interface{},
+	field ...string) (f Fragment, err error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		return f, fmt.Errorf("BuildWhere: argument 'data' bad type %T", data)
+	}
+	stru := v.Elem().Type()
+	mapping, err := automap(loadmap(), stru)
+	if err != nil {
+		return f, fmt.Errorf("BuildWhere: %v", err)
+	}
+	if _, ok := mapping[stru.Name()]; !ok {
+		return f, fmt.Errorf("BuildWhere: %q has no mapping", stru)
+	}
+	var es []entryT
+	if len(field) == 0 { // default primary key
+		if es = pkeys(mapping, stru.Name()); len(es) == 0 {
+			return f, fmt.Errorf("BuildWhere: %q has no primary key", stru)
+		}
+	}
+	for _, n := range field {
+		m, ok := mapping["0."+stru.Name()+"."+n]
+		if !ok {
+			return f, fmt.Errorf("BuildWhere: %v",
+				nofield(mapping, stru.Name(), n))
+		}
+		es = append(es, m)
+	}
+
+	name, col := stru.Name(), ""
+	defer recovered(&err, "BuildWhere", &name, &col)
+	var sql strings.Builder
+	for i, m := range es {
+		if i > 0 {
+			sql.WriteString(" AND ")
+		}
+		col = m.name.(string)
+		ptr := reflect.NewAt(m.typ, m.addr(v.UnsafePointer()))
+		s, arg, err := m.placeholder(d, c, len(f.Args)+1, ptr)
+		if err != nil {
+			return f, fmt.Errorf("BuildWhere: %v", err)
+		}
+		if arg == nil { // col=NULL never matches
+			sql.WriteString(m.name.(string) + " IS NULL")
+			continue
+		}
+		sql.WriteString(m.name.(string) + "=" + s)
+		f.Args = append(f.Args, arg)
+	}
+	f.SQL = sql.String()
+	return f, nil
+}
+
+// placeholder 返回映射项e所对应字段（ptr为指向字段的指针）按配置c的第n个占
+// 位符表达式及其参数，见Buildargs。
+func (e entryT) placeholder(d Dialect, c *Config, n int,
+	ptr reflect.Value) (string, interface{}, error) {
+	ph := "?"
+	if d != nil {
+		ph = d.Placeholder(n)
+	}
+	arg, err := e.arg(c, ptr)
+	if err != nil || e.info == nil {
+		return ph, arg, err
+	}
+	switch {
+	case e.info.geo && arg != nil && arg != "":
+		fn := "ST_GeomFromText("
+		if d != nil && d.Name() == "sqlite" {
+			fn = "GeomFromText("
+		}
+		if e.info.srid != 0 {
+			ph += "," + strconv.Itoa(e.info.srid)
+		}
+		return fn + ph + ")", arg, nil
+	case e.info.geo:
+		return ph, nil, nil // NULL
+	case e.info.unit != "" && d != nil && d.Name() == "postgres":
+		t := time.Duration(ptr.Elem().Int())
+		return ph + "::interval", strconv.FormatFloat(t.Seconds(), 'f', -1,
+			64) + " seconds", nil
+	}
+	return ph, arg, nil
+}
@@ -0,0 +1,120 @@
+package sqlaux
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type ArgsCfg struct {
+	ID      int `db:"pk"`
+	Name    string
+	Age     int `db:"check='age >= 0'"`
+	Created time.Time
+}
+
+func init() {
+	if err := MapStruct(ArgsCfg{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestConfigBuildargs(t *testing.T) {
+	sentinel := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := With(WithNullPolicy(ZeroTimeSentinel, sentinel))
+	f, err := c.Buildargs(PostgreSQL, &ArgsCfg{ID: 1}, "Name", "Created")
+	if err != nil || f.SQL != "SET name=$1,created=$2" ||
+		f.Args[1] != sentinel {
+		t.Fatalf("%+v %v", f, err)
+	}
+	f, err = Buildargs(PostgreSQL, &ArgsCfg{ID: 1}, "Name", "Created")
+	if err != nil || f.Args[1] == sentinel {
+		t.Fatalf("global config: %+v %v", f, err)
+	}
+	f, err = With(WithOmitEmpty()).Buildargs(PostgreSQL,
+		&ArgsCfg{ID: 1, Age: 3}, "Name", "Age")
+	if err != nil || f.SQL != "SET age=$1" || f.Args[0] != int64(3) {
+		t.Fatalf("OmitEmpty: %+v %v", f, err)
+	}
+
+	c = With()
+	c.Validate = true
+	if _, err = c.Buildargs(nil, &ArgsCfg{Age: -1}, "Age"); err == nil {
+		t.Error("Validate ignored")
+	}
+
+	loc := time.FixedZone("X", 8*3600)
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	f, err = With(WithTime("", loc)).BuildWhere(PostgreSQL,
+		&ArgsCfg{ID: 2, Created: at}, "ID", "Created")
+	if err != nil || f.SQL != "id=$1 AND created=$2" {
+		t.Fatalf("%+v %v", f, err)
+	}
+	if got := f.Args[1].(time.Time); got.Location() != loc || !got.Equal(at) {
+		t.Errorf("created %v, want %v in %v", got, at, loc)
+	}
+}
+
+type ArgsPlace struct {
+	ID   int
+	Loc  string `db:"geo=4326"`
+	Wait time.Duration
+}
+
+func TestBuildargs(t *testing.T) {
+	d := []*ArgsPlace{{1, "POINT(1 2)", time.Second}, {2, "", 0}}
+	f, err := Buildargs(PostgreSQL, d)
+	want := Fragment{"(id,loc,wait) VALUES ($1,ST_GeomFromText($2,4326)," +
+		"$3::interval),($4,$5,$6::interval)", []interface{}{int64(1),
+		"POINT(1 2)", "1 seconds", int64(2), nil, "0 seconds"}}
+	if err != nil || !reflect.DeepEqual(f, want) {
+		t.Errorf("PostgreSQL:\n got %v %v\nwant %v", f, err, want)
+	}
+	f, err = Buildargs(nil, d[0], "Loc", "Wait")
+	want = Fragment{"SET loc=ST_GeomFromText(?,4326),wait=?",
+		[]interface{}{"POINT(1 2)", int64(time.Second)}}
+	if err != nil || !reflect.DeepEqual(f, want) {
+		t.Errorf("nil dialect:\n got %v %v\nwant %v", f, err, want)
+	}
+	if _, err = Buildargs(MySQL, d[0], "Lc"); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err = Buildargs(MySQL, *d[0]); err == nil {
+		t.Error("struct value accepted")
+	}
+}
+
+type ArgsUser struct {
+	ID   int `db:"pk"`
+	Name string
+	Dept *int
+}
+
+func TestBuildWhere(t *testing.T) {
+	dept := 3
+	f, err := BuildWhere(PostgreSQL, &ArgsUser{7, "x", &dept}, "Name",
+		"Dept")
+	want := Fragment{"name=$1 AND dept=$2", []interface{}{"x", int64(3)}}
+	if err != nil || !reflect.DeepEqual(f, want) {
+		t.Errorf("PostgreSQL:\n got %v %v\nwant %v", f, err, want)
+	}
+	f, err = BuildWhere(PostgreSQL, &ArgsUser{ID: 7}, "Dept", "Name")
+	want = Fragment{"dept IS NULL AND name=$1", []interface{}{""}}
+	if err != nil || !reflect.DeepEqual(f, want) {
+		t.Errorf("NULL:\n got %v %v\nwant %v", f, err, want)
+	}
+	f, err = BuildWhere(MySQL, &ArgsUser{ID: 7})
+	want = Fragment{"id=?", []interface{}{int64(7)}}
+	if err != nil || !reflect.DeepEqual(f, want) {
+		t.Errorf("primary key:\n got %v %v\nwant %v", f, err, want)
+	}
+	if _, err = BuildWhere(MySQL, &ArgsPlace{ID: 1}); err == nil {
+		t.Error("default fields without primary key accepted")
+	}
+	if _, err = BuildWhere(MySQL, &ArgsUser{}, "Nme"); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err = BuildWhere(MySQL, ArgsUser{}, "Name"); err == nil {
+		t.Error("struct value accepted")
+	}
+}
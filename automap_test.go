@@ -0,0 +1,61 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+// one type per entry point, as only the first use maps it
+type (
+	AmClone   struct{ ID int }
+	AmDiff    struct{ ID int }
+	AmHash    struct{ ID int }
+	AmToMap   struct{ ID int }
+	AmFromMap struct{ ID int }
+	AmArgs    struct{ ID int }
+	AmInto    struct{ ID int }
+	AmReduce  struct{ ID int }
+	AmFields  struct{ ID int }
+)
+
+func TestAutomapEntryPoints(t *testing.T) {
+	if _, err := Clone(&AmClone{1}); err != nil {
+		t.Error(err)
+	}
+	if cs, err := Diff(&AmDiff{1}, &AmDiff{2}); err != nil || len(cs) != 1 {
+		t.Error(cs, err)
+	}
+	if _, err := Hash(&AmHash{1}); err != nil {
+		t.Error(err)
+	}
+	if m, err := ToMap(&AmToMap{1}); err != nil || m["id"] != int64(1) {
+		t.Error(m, err)
+	}
+	var fm AmFromMap
+	if err := FromMap(&fm, map[string]interface{}{"id": 3}); err != nil ||
+		fm.ID != 3 {
+		t.Error(fm, err)
+	}
+	if f, err := Buildargs(nil, &AmArgs{1}); err != nil || f.SQL != "SET id=?" {
+		t.Error(f, err)
+	}
+	if fs, err := FieldNames(AmFields{}); err != nil || len(fs) != 1 {
+		t.Error(fs, err)
+	}
+
+	rows := testRows(t, []string{"id"}, []driver.Value{int64(5)})
+	buf := make([]AmInto, 1)
+	if n, err := ScanInto(rows, buf); err != nil || n != 1 || buf[0].ID != 5 {
+		t.Error(n, err)
+	}
+	rows.Close()
+	rows = testRows(t, []string{"id"}, []driver.Value{int64(5)},
+		[]driver.Value{int64(6)})
+	sum, err := ScanReduce(rows, 0, func(s int, r *AmReduce) (int, error) {
+		return s + r.ID, nil
+	})
+	if err != nil || sum != 11 {
+		t.Error(sum, err)
+	}
+	rows.Close()
+}
@@ -0,0 +1,242 @@
+// Package bench 以sqlaux的辅助函数对数据库施加可配置的插入、查询负载，报告吞
+// 吐量和延迟的百分位数，用于确定合适的批量大小，比较不同数据库方言、驱动及其
+// 参数的性能，如：
+//
+//	r, err := bench.Run(ctx, db, User{}, bench.Options{Op: "insert",
+//		N: 1000, Batch: 100, Concurrency: 4})
+//	fmt.Println(r)
+//
+// 结构须已映射并登记表名（见sqlaux.MapTable），插入的数据由sqlauxtest.FakeSlice
+// 生成。Main 为命令行形式，见cmd/sqlauxbench。
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/laokz/sqlaux"
+	"github.com/laokz/sqlaux/sqlauxtest"
+)
+
+// Options 为负载的配置。
+type Options struct {
+	Op          string         // "insert"或"select"
+	N           int            // 操作（语句）数，缺省为100
+	Batch       int            // 每条INSERT的行数或SELECT的LIMIT，缺省为1
+	Concurrency int            // 并发执行的连接数，缺省为1
+	Fields      []string       // 插入、查询的字段，缺省为全部，同Buildstr
+	Dialect     sqlaux.Dialect // nil 时用sqlaux.DetectDialect探测
+}
+
+// Result 为一次负载的结果，各延迟为单条语句（不含生成数据）的耗时。
+type Result struct {
+	Op      string
+	Ops     int // 成功的操作数
+	Rows    int // 插入或接收的行数
+	Errors  int // 失败的操作数
+	Elapsed time.Duration
+	P50     time.Duration
+	P90     time.Duration
+	P99     time.Duration
+	Max     time.Duration
+	Err     error // 第一个错误
+}
+
+// String 以一行文本报告r。
+func (r Result) String() string {
+	s := fmt.Sprintf("%s: %d ops, %d rows in %v, %.1f ops/s, %.1f rows/s, "+
+		"latency p50 %v p90 %v p99 %v max %v", r.Op, r.Ops, r.Rows,
+		r.Elapsed.Round(time.Millisecond), persec(r.Ops, r.Elapsed),
+		persec(r.Rows, r.Elapsed), r.P50, r.P90, r.P99, r.Max)
+	if r.Errors > 0 {
+		s += fmt.Sprintf(", %d errors (first: %v)", r.Errors, r.Err)
+	}
+	return s
+}
+
+// persec 返回n在d时间内的速率。
+func persec(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// Run 在db上以o的配置对stru所登记的表执行负载：insert 以BuildInsert插入每批
+// 随机生成的Batch行，select 以Selectstr生成"SELECT 列... FROM 表 LIMIT
+// Batch"并以QueryContext接收。共执行N条语句，由Concurrency个goroutine分担。
+// 单条语句失败不中止负载，计入Result.Errors；配置错误时返回error。
+// stru 以变量值的形式作参数，可以取零值。
+func Run(ctx context.Context, db *sql.DB, stru interface{},
+	o Options) (Result, error) {
+	table := sqlaux.Tablename(stru)
+	if table == "" {
+		return Result{}, fmt.Errorf("Run: %T has no registered table", stru)
+	}
+	t := reflect.TypeOf(stru)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if o.Op != "insert" && o.Op != "select" {
+		return Result{}, fmt.Errorf("Run: unknown op %q", o.Op)
+	}
+	if o.N <= 0 {
+		o.N = 100
+	}
+	if o.Batch <= 0 {
+		o.Batch = 1
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	if o.Dialect == nil {
+		var err error
+		if o.Dialect, _, err = sqlaux.DetectDialect(ctx, db); err != nil {
+			return Result{}, fmt.Errorf("Run: %v", err)
+		}
+	}
+	var query string
+	if o.Op == "select" {
+		cols, err := sqlaux.Selectstr(stru, o.Fields...)
+		if err != nil {
+			return Result{}, fmt.Errorf("Run: %v", err)
+		}
+		query = fmt.Sprintf("SELECT %s FROM %s LIMIT %d", cols, table,
+			o.Batch)
+	}
+
+	r := Result{Op: o.Op}
+	lat := make([]time.Duration, 0, o.N)
+	var mu sync.Mutex // protects r and lat
+	next := make(chan struct{})
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range next {
+				d, n, err := once(ctx, db, t, o, query)
+				mu.Lock()
+				if err != nil {
+					if r.Errors++; r.Err == nil {
+						r.Err = err
+					}
+				} else {
+					r.Ops++
+					r.Rows += n
+					lat = append(lat, d)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < o.N && ctx.Err() == nil; i++ {
+		next <- struct{}{}
+	}
+	close(next)
+	wg.Wait()
+	r.Elapsed = time.Since(start)
+
+	sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+	pct := func(p int) time.Duration {
+		if len(lat) == 0 {
+			return 0
+		}
+		return lat[(len(lat)-1)*p/100]
+	}
+	r.P50, r.P90, r.P99, r.Max = pct(50), pct(90), pct(99), pct(100)
+	return r, nil
+}
+
+// once 执行一次操作，返回语句的耗时和行数。t 为结构类型，query 为查询语句。
+func once(ctx context.Context, db *sql.DB, t reflect.Type, o Options,
+	query string) (time.Duration, int, error) {
+	p := reflect.New(reflect.SliceOf(reflect.PtrTo(t)))
+	if o.Op == "insert" {
+		if err := sqlauxtest.FakeSlice(p.Interface(), o.Batch); err != nil {
+			return 0, 0, err
+		}
+		q, args, err := sqlaux.BuildInsert(o.Dialect, p.Elem().Interface(),
+			o.Fields...)
+		if err != nil {
+			return 0, 0, err
+		}
+		start := time.Now()
+		if _, err = sqlaux.ExecContext(ctx, db, q, args...); err != nil {
+			return 0, 0, err
+		}
+		return time.Since(start), o.Batch, nil
+	}
+	start := time.Now()
+	err := sqlaux.QueryContext(ctx, db, query, nil, p.Interface())
+	if err != nil {
+		return 0, 0, err
+	}
+	return time.Since(start), p.Elem().Len(), nil
+}
+
+// Main 为命令行形式的Run：解析命令行参数，在-dsn所指的数据库上对stru中由
+// -struct选定（缺省为全部）的结构依次执行负载，并逐行报告结果。出错时以状态
+// 码2退出。驱动须由调用者导入，如：
+//
+//	import _ "github.com/lib/pq"
+//
+//	func main() { bench.Main(User{}, Order{}) }
+func Main(stru ...interface{}) {
+	var o Options
+	driver := flag.String("driver", "", "database/sql driver name")
+	dsn := flag.String("dsn", "", "data source name")
+	name := flag.String("struct", "", "benchmark only this struct")
+	flag.StringVar(&o.Op, "op", "insert", "workload: insert or select")
+	flag.IntVar(&o.N, "n", 100, "number of statements")
+	flag.IntVar(&o.Batch, "batch", 1, "rows per INSERT, or SELECT LIMIT")
+	flag.IntVar(&o.Concurrency, "c", 1, "concurrent connections")
+	fields := flag.String("fields", "", "comma separated field names")
+	truncate := flag.Bool("truncate", false, "empty tables before insert")
+	flag.Parse()
+	if *fields != "" {
+		o.Fields = strings.Split(*fields, ",")
+	}
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	db, err := sql.Open(*driver, *dsn)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(o.Concurrency)
+	ctx := context.Background()
+	found := false
+	for _, s := range stru {
+		n := reflect.Indirect(reflect.ValueOf(s)).Type().Name()
+		if *name != "" && n != *name {
+			continue
+		}
+		found = true
+		if *truncate && o.Op == "insert" {
+			if err = sqlaux.Truncate(ctx, db, s); err != nil {
+				fail(err)
+			}
+		}
+		r, err := Run(ctx, db, s, o)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("%s %v\n", n, r)
+	}
+	if !found {
+		fail(fmt.Errorf("no struct to benchmark"))
+	}
+}
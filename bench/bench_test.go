@@ -0,0 +1,136 @@
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/laokz/sqlaux"
+)
+
+// bdriver 为测试用的数据库驱动：执行语句记录到bexecs，查询返回两行，语句含
+// "fail"时出错。
+type bdriver struct{}
+
+var (
+	bmu    sync.Mutex
+	bexecs []string
+	bdb    *sql.DB
+)
+
+func init() {
+	sql.Register("sqlaux-bench-test", bdriver{})
+	bdb, _ = sql.Open("sqlaux-bench-test", "")
+}
+
+func (bdriver) Open(string) (driver.Conn, error) { return bconn{}, nil }
+
+type bconn struct{}
+
+func (bconn) Prepare(q string) (driver.Stmt, error) { return bstmt{q}, nil }
+func (bconn) Close() error                          { return nil }
+func (bconn) Begin() (driver.Tx, error)             { return bconn{}, nil }
+func (bconn) Commit() error                         { return nil }
+func (bconn) Rollback() error                       { return nil }
+
+type bstmt struct{ q string }
+
+func (bstmt) Close() error  { return nil }
+func (bstmt) NumInput() int { return -1 }
+
+func (s bstmt) Exec([]driver.Value) (driver.Result, error) {
+	if strings.Contains(s.q, "fail") {
+		return nil, errors.New("exec failed")
+	}
+	bmu.Lock()
+	defer bmu.Unlock()
+	bexecs = append(bexecs, s.q)
+	return driver.RowsAffected(1), nil
+}
+
+func (s bstmt) Query([]driver.Value) (driver.Rows, error) {
+	return &brows{}, nil
+}
+
+type brows struct{ i int }
+
+func (*brows) Columns() []string { return []string{"id", "name"} }
+func (*brows) Close() error      { return nil }
+
+func (r *brows) Next(dest []driver.Value) error {
+	if r.i == 2 {
+		return io.EOF
+	}
+	r.i++
+	dest[0], dest[1] = int64(r.i), "x"
+	return nil
+}
+
+type BenchUser struct {
+	ID   int64  `db:"pk"`
+	Name string `db:"size=8"`
+}
+
+type BenchFail struct {
+	ID int64 `db:"pk"`
+}
+
+type BenchNone struct {
+	ID int64
+}
+
+func TestRun(t *testing.T) {
+	for s, table := range map[interface{}]string{BenchUser{}: "bench_users",
+		BenchFail{}: "fail"} {
+		if _, err := sqlaux.Signature(s); err != nil { // automapped
+			t.Fatal(err)
+		}
+		if err := sqlaux.MapTable(s, table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	r, err := Run(ctx, bdb, BenchUser{}, Options{Op: "insert", N: 10,
+		Batch: 3, Concurrency: 2, Dialect: sqlaux.PostgreSQL})
+	if err != nil || r.Ops != 10 || r.Rows != 30 || r.Errors != 0 ||
+		r.P50 > r.P90 || r.P90 > r.P99 || r.P99 > r.Max {
+		t.Errorf("insert: %v %v", r, err)
+	}
+	bmu.Lock()
+	qs := bexecs
+	bexecs = nil
+	bmu.Unlock()
+	if len(qs) != 10 || !strings.HasPrefix(qs[0],
+		"INSERT INTO bench_users (id,name) VALUES ($1,$2),($3,$4),") {
+		t.Errorf("insert statements: %q", qs)
+	}
+
+	r, err = Run(ctx, bdb, &BenchUser{}, Options{Op: "select", Batch: 5,
+		Dialect: sqlaux.PostgreSQL})
+	if err != nil || r.Op != "select" || r.Ops != 100 || r.Rows != 200 {
+		t.Errorf("select: %v %v", r, err)
+	}
+
+	r, err = Run(ctx, bdb, BenchFail{}, Options{Op: "insert", N: 3,
+		Dialect: sqlaux.PostgreSQL})
+	if err != nil || r.Ops != 0 || r.Errors != 3 || r.Err == nil ||
+		!strings.Contains(r.String(), "3 errors (first: ") {
+		t.Errorf("failing insert: %v %v", r, err)
+	}
+
+	if _, err = Run(ctx, bdb, BenchUser{}, Options{Op: "update"}); err == nil {
+		t.Error("unknown op accepted")
+	}
+	if _, err = Run(ctx, bdb, BenchNone{}, Options{Op: "insert"}); err == nil {
+		t.Error("struct without table accepted")
+	}
+	if _, err = Run(ctx, bdb, nil, Options{Op: "insert"}); err == nil {
+		t.Error("nil stru accepted")
+	}
+}
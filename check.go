@@ -0,0 +1,239 @@
+package sqlaux
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// checkT 为字段tag中"check="声明的约束。
+type checkT struct {
+	expr string // 约束表达式，CreateTableSQL生成CHECK (expr)
+	// conds 为可在客户端求值时表达式的各合取条件，否则为nil
+	conds []condT
+}
+
+// condT 为约束中的一个条件：列 op 值，或列 [NOT] IN (值,...)。
+type condT struct {
+	op   string        // >=、<=、>、<、=、<>、in、not in
+	vals []interface{} // float64或string
+}
+
+// parsecheck 解析列col的约束expr。expr 仅由以AND连接的、col与数值或字符串
+// 字面值的比较（>=、<=、>、<、=、<>、!=）及[NOT] IN (值,...)组成时，返回的
+// conds可在客户端求值，否则（如引用其他列、使用函数）conds为nil，约束仅用于
+// DDL。
+func parsecheck(expr, col string) *checkT {
+	c := &checkT{expr: expr}
+	ts, ok := checktokens(expr)
+	if !ok {
+		return c
+	}
+	var conds []condT
+	for i := 0; ; {
+		if i+2 >= len(ts) || strings.ToLower(ts[i]) != col {
+			return c
+		}
+		var d condT
+		switch op := strings.ToLower(ts[i+1]); op {
+		case ">=", "<=", ">", "<", "=", "<>", "!=":
+			v, ok := checklit(ts[i+2])
+			if !ok {
+				return c
+			}
+			if op == "!=" {
+				op = "<>"
+			}
+			d, i = condT{op, []interface{}{v}}, i+3
+		case "not", "in":
+			if i++; op == "not" {
+				if strings.ToLower(ts[i+1]) != "in" {
+					return c
+				}
+				i++
+				op = "not in"
+			}
+			if i+1 >= len(ts) || ts[i+1] != "(" {
+				return c
+			}
+			d.op = op
+			for i += 2; ; i += 2 {
+				if i+1 >= len(ts) {
+					return c
+				}
+				v, ok := checklit(ts[i])
+				if !ok {
+					return c
+				}
+				d.vals = append(d.vals, v)
+				if ts[i+1] == ")" {
+					break
+				}
+				if ts[i+1] != "," {
+					return c
+				}
+			}
+			i += 2
+		default:
+			return c
+		}
+		conds = append(conds, d)
+		if i == len(ts) {
+			break
+		}
+		if strings.ToLower(ts[i]) != "and" {
+			return c
+		}
+		i++
+	}
+	c.conds = conds
+	return c
+}
+
+// checktokens 将约束表达式s切分为标识符、字面值、运算符和括号逗号，字符串字面值
+// 保留其单引号。有不认识的字符时返回false。
+func checktokens(s string) ([]string, bool) {
+	var ts []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			ts = append(ts, s[i:i+1])
+			i++
+		case c == '\'':
+			j := i + 1
+			for ; j < len(s); j++ {
+				if s[j] == '\'' {
+					if j+1 < len(s) && s[j+1] == '\'' { // escaped
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j == len(s) {
+				return nil, false
+			}
+			ts = append(ts, s[i:j+1])
+			i = j + 1
+		case strings.IndexByte("<>=!", c) != -1:
+			j := i + 1
+			if j < len(s) && strings.IndexByte("<>=", s[j]) != -1 {
+				j++
+			}
+			ts = append(ts, s[i:j])
+			i = j
+		case c == '_' || c == '.' || c == '-' || c == '+' ||
+			c >= '0' && c <= '9' || c >= 'a' && c <= 'z' ||
+			c >= 'A' && c <= 'Z':
+			j := i + 1
+			for ; j < len(s); j++ {
+				c := s[j]
+				if !(c == '_' || c == '.' || c >= '0' && c <= '9' ||
+					c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+					break
+				}
+			}
+			ts = append(ts, s[i:j])
+			i = j
+		default:
+			return nil, false
+		}
+	}
+	return ts, true
+}
+
+// checklit 返回约束中的字面值t：数值为float64，字符串为string。
+func checklit(t string) (interface{}, bool) {
+	if len(t) >= 2 && t[0] == '\'' {
+		return strings.Replace(t[1:len(t)-1], "''", "'", -1), true
+	}
+	f, err := strconv.ParseFloat(t, 64)
+	return f, err == nil
+}
+
+// holds 报告字段值v是否满足约束的全部条件。NULL（nil指针）及不可比较的值视
+// 为满足，与数据库CHECK约束对NULL的处理一致。
+func (c *checkT) holds(v reflect.Value) bool {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return true
+	}
+	var x interface{}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		x = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		x = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		x = v.Float()
+	case reflect.String:
+		x = v.String()
+	default:
+		return true
+	}
+	for _, d := range c.conds {
+		if !d.holds(x) {
+			return false
+		}
+	}
+	return true
+}
+
+// holds 报告值x是否满足条件d，类型不同的比较视为满足。
+func (d condT) holds(x interface{}) bool {
+	cmp := func(y interface{}) (int, bool) {
+		switch a := x.(type) {
+		case float64:
+			b, ok := y.(float64)
+			switch {
+			case !ok:
+				return 0, false
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			}
+			return 0, true
+		case string:
+			b, ok := y.(string)
+			if !ok {
+				return 0, false
+			}
+			return strings.Compare(a, b), true
+		}
+		return 0, false
+	}
+	if d.op == "in" || d.op == "not in" {
+		in := false
+		for _, y := range d.vals {
+			if r, ok := cmp(y); !ok {
+				return true
+			} else if r == 0 {
+				in = true
+			}
+		}
+		return in == (d.op == "in")
+	}
+	r, ok := cmp(d.vals[0])
+	if !ok {
+		return true
+	}
+	switch d.op {
+	case ">=":
+		return r >= 0
+	case "<=":
+		return r <= 0
+	case ">":
+		return r > 0
+	case "<":
+		return r < 0
+	case "=":
+		return r == 0
+	}
+	return r != 0 // <>
+}
@@ -0,0 +1,47 @@
+package sqlaux
+
+import (
+	"strings"
+	"testing"
+)
+
+type CheckUser struct {
+	Age    int     `db:"check='age >= 0 AND age < 150'"`
+	Status string  `db:"check='status IN (''a'',''it''''s'')'"`
+	Kind   *string `db:"check='kind NOT IN (''x'') AND kind <> ''y'''"`
+	Score  float64 `db:"check='score <= max_score'"`
+}
+
+func TestCheck(t *testing.T) {
+	c := With()
+	c.Validate = true
+	x, y := "x", "z"
+	tests := []struct {
+		u   CheckUser
+		bad string
+	}{
+		{CheckUser{0, "a", nil, 1e9}, ""},
+		{CheckUser{149, "it's", &y, 0}, ""},
+		{CheckUser{-1, "a", nil, 0}, "age"},
+		{CheckUser{150, "a", nil, 0}, "age"},
+		{CheckUser{1, "b", nil, 0}, "status"},
+		{CheckUser{1, "a", &x, 0}, "kind"},
+	}
+	for i, tt := range tests {
+		_, err := c.Buildstr(&tt.u)
+		if tt.bad == "" && err != nil || tt.bad != "" && (err == nil ||
+			!strings.Contains(err.Error(), `column "`+tt.bad+`"`)) {
+			t.Errorf("%d: %v, want violation of %q", i, err, tt.bad)
+		}
+	}
+	if _, err := Buildstr(&tests[2].u); err != nil {
+		t.Errorf("checked without Validate: %v", err)
+	}
+
+	s, err := CreateTableSQL(CheckUser{}, "u", PostgreSQL)
+	if err != nil || !strings.Contains(s,
+		`"age" bigint NOT NULL CHECK (age >= 0 AND age < 150)`) ||
+		!strings.Contains(s, `CHECK (status IN ('a','it''s'))`) {
+		t.Errorf("CreateTableSQL: %s %v", s, err)
+	}
+}
@@ -0,0 +1,244 @@
+package sqlaux
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// childT 为子表字段的子表名、外键列名和值列名，来自tag中的
+// "child=子表:外键列:值列"。
+type childT struct {
+	table, fk, col string
+}
+
+// initchild 在mp中为名为s的结构（可能为嵌套结构）中基址为b、经由via的子表
+// 字段f建立映射项，spec为其tag选项"child="的值。
+func initchild(mp map[string]entryT, s string, f reflect.StructField,
+	b uintptr, via []hopT, spec string) error {
+	p := strings.Split(spec, ":")
+	if len(p) != 3 || p[0] == "" || p[1] == "" || p[2] == "" {
+		return fmt.Errorf("bad tagged 'child' %q", spec)
+	}
+	if f.Type.Kind() != reflect.Slice ||
+		f.Type.Elem().Kind() == reflect.Struct ||
+		f.Type.Elem().Kind() == reflect.Ptr {
+		return fmt.Errorf("tagged 'child' but not slice of simple type")
+	}
+	outer, name := s, f.Name
+	if dot := strings.Index(s, "."); dot != -1 {
+		outer, name = s[:dot], s[dot+1:]+"."+f.Name
+	}
+	e := mp["c."+outer]
+	ns, _ := e.name.([]string)
+	mp["c."+outer] = entryT{name: append(ns, name)}
+	mp["c."+outer+"."+name] = entryT{childT{strings.ToLower(p[0]),
+		strings.ToLower(p[1]), strings.ToLower(p[2])}, b + f.Offset, f.Type,
+		nil, via}
+	return nil
+}
+
+// childrows 检查data的类型形如[]*struct或*struct，返回其各行的基址和结构名，
+// 及结构唯一的主键字段映射项。
+func childrows(data interface{}) ([]unsafe.Pointer, string, entryT,
+	error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr {
+		v = reflect.ValueOf([]interface{}{data})
+	}
+	if v.Kind() != reflect.Slice {
+		return nil, "", entryT{}, fmt.Errorf("data not like []*struct or " +
+			"*struct")
+	}
+	var ps []unsafe.Pointer
+	var t reflect.Type
+	var stru string
+	for i := 0; i < v.Len(); i++ {
+		p := reflect.ValueOf(v.Index(i).Interface())
+		if p.Kind() != reflect.Ptr || p.Elem().Kind() != reflect.Struct ||
+			p.IsNil() || stru != "" && p.Type().Elem().Name() != stru {
+			return nil, "", entryT{}, fmt.Errorf("data not like []*struct " +
+				"or *struct")
+		}
+		t, stru = p.Type().Elem(), p.Type().Elem().Name()
+		ps = append(ps, p.UnsafePointer())
+	}
+	if stru == "" {
+		return nil, "", entryT{}, nil
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return nil, "", entryT{}, err
+	}
+	if _, ok := mapping[stru]; !ok {
+		return nil, "", entryT{}, fmt.Errorf("%q has no mapping", stru)
+	}
+	pks := pkeys(mapping, stru)
+	if len(pks) != 1 {
+		return nil, "", entryT{}, fmt.Errorf("%q has not one primary key",
+			stru)
+	}
+	return ps, stru, pks[0], nil
+}
+
+// SaveChildren 将data各行子表字段（tag中有"child=子表:外键列:值列"的切片字段，
+// 不是表列）的元素写为子表的行：先删除子表中这些行的所有旧值，再插入
+// "(主键, 元素)"各行，从而经典的一对多值列表（如文章的标签）可以随主表一起
+// 插入或更新，如：
+//
+//	Tags []string `db:"child=post_tags:post_id:tag"`
+//
+// 对应子表post_tags(post_id, tag)。调用者应在主表INSERT、UPDATE的同一事务
+// （见WithTx）中调用，db 为nil时由路由函数选择。值按d的规范生成字面值。
+//
+// 约定：data的类型形如[]*struct或*struct，结构须有唯一的主键（tag中有"pk"）。
+func SaveChildren(ctx context.Context, db Querier, d Dialect,
+	data interface{}) error {
+	ps, stru, pk, err := childrows(data)
+	if err != nil {
+		return fmt.Errorf("SaveChildren: %v", err)
+	}
+	mapping := loadmap() // after childrows automaps
+	cs, ok := mapping["c."+stru]
+	if !ok || len(ps) == 0 {
+		return nil
+	}
+	if d == nil {
+		return fmt.Errorf("SaveChildren: no dialect argument")
+	}
+	if db, err = route(ctx, db, RouteWrite, stru); err != nil {
+		return fmt.Errorf("SaveChildren: %v", err)
+	}
+
+	keys := make([]string, len(ps))
+	for i, p := range ps {
+		var k strings.Builder
+		err = pk.value(&k, d, conf(), "", reflect.NewAt(pk.typ, pk.addr(p)))
+		if err != nil {
+			return fmt.Errorf("SaveChildren: %v", err)
+		}
+		keys[i] = k.String()
+	}
+	for _, n := range cs.name.([]string) {
+		e := mapping["c."+stru+"."+n]
+		c := e.name.(childT)
+		stmts := []string{"DELETE FROM " + c.table + " WHERE " + c.fk +
+			" IN (" + strings.Join(keys, ",") + ")"}
+		var ins strings.Builder
+		for i, p := range ps {
+			vs := reflect.NewAt(e.typ, e.addr(p)).Elem()
+			for j := 0; j < vs.Len(); j++ {
+				val, err := valueof(vs.Index(j))
+				if err != nil {
+					return fmt.Errorf("SaveChildren: %s.%s %v", stru, n, err)
+				}
+				if ins.Len() == 0 {
+					ins.WriteString("INSERT INTO " + c.table + " (" + c.fk +
+						"," + c.col + ") VALUES ")
+				} else {
+					ins.WriteString(",")
+				}
+				ins.WriteString("(" + keys[i] + ",")
+				if err = literal(&ins, d, conf(), val); err != nil {
+					return fmt.Errorf("SaveChildren: %s.%s %v", stru, n, err)
+				}
+				ins.WriteString(")")
+			}
+		}
+		if ins.Len() > 0 {
+			stmts = append(stmts, ins.String())
+		}
+		for _, q := range stmts {
+			q, args, err := rewrite(ctx, q, nil)
+			if err != nil {
+				return fmt.Errorf("SaveChildren: %v", err)
+			}
+			if _, err = db.ExecContext(ctx, q, args...); err != nil {
+				return fmt.Errorf("SaveChildren: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadChildren 为dest各行从子表读取其子表字段的元素（见SaveChildren），覆盖
+// 字段原有的值；子表中没有对应行的字段为nil。元素的顺序取决于数据库，不保证
+// 与写入时相同。db 为nil时由路由函数选择，d 同SaveChildren。
+//
+// 约定：dest的类型形如[]*struct或*struct，通常为Scan接收的结果。
+func LoadChildren(ctx context.Context, db Querier, d Dialect,
+	dest interface{}) error {
+	ps, stru, pk, err := childrows(dest)
+	if err != nil {
+		return fmt.Errorf("LoadChildren: %v", err)
+	}
+	mapping := loadmap() // after childrows automaps
+	cs, ok := mapping["c."+stru]
+	if !ok || len(ps) == 0 {
+		return nil
+	}
+	if d == nil {
+		return fmt.Errorf("LoadChildren: no dialect argument")
+	}
+	if db, err = route(ctx, db, RouteRead, stru); err != nil {
+		return fmt.Errorf("LoadChildren: %v", err)
+	}
+
+	keys := make([]string, len(ps))
+	rows := make(map[string]unsafe.Pointer, len(ps)) // pkey -> row
+	pks := []entryT{pk}
+	for i, p := range ps {
+		var k strings.Builder
+		err = pk.value(&k, d, conf(), "", reflect.NewAt(pk.typ, pk.addr(p)))
+		if err != nil {
+			return fmt.Errorf("LoadChildren: %v", err)
+		}
+		keys[i] = k.String()
+		rows[pkey(p, pks)] = p
+	}
+	for _, n := range cs.name.([]string) {
+		e := mapping["c."+stru+"."+n]
+		c := e.name.(childT)
+		for _, p := range ps { // no merging
+			reflect.NewAt(e.typ, e.addrw(p)).Elem().Set(
+				reflect.Zero(e.typ))
+		}
+		q := "SELECT " + c.fk + "," + c.col + " FROM " + c.table + " WHERE " +
+			c.fk + " IN (" + strings.Join(keys, ",") + ")"
+		q, args, err := rewrite(ctx, q, nil)
+		if err != nil {
+			return fmt.Errorf("LoadChildren: %v", err)
+		}
+		if err = loadchild(ctx, db, q, args, e, pk, rows); err != nil {
+			return fmt.Errorf("LoadChildren: %s.%s %v", stru, n, err)
+		}
+	}
+	return nil
+}
+
+// loadchild 执行子表查询q，将各行的值追加到rows中外键所指行的子表字段e。
+func loadchild(ctx context.Context, db Querier, q string, args []interface{},
+	e, pk entryT, rows map[string]unsafe.Pointer) error {
+	rs, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+	fk := reflect.New(pk.typ)
+	for rs.Next() {
+		val := reflect.New(e.typ.Elem())
+		if err = rs.Scan(fk.Interface(), val.Interface()); err != nil {
+			return err
+		}
+		p, ok := rows[pkey(fk.UnsafePointer(), []entryT{{offset: 0,
+			typ: pk.typ}})]
+		if !ok {
+			continue
+		}
+		f := reflect.NewAt(e.typ, e.addrw(p)).Elem()
+		f.Set(reflect.Append(f, val.Elem()))
+	}
+	return rs.Err()
+}
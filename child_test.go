@@ -0,0 +1,85 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type ChildPost struct {
+	ID    int64 `db:"pk"`
+	Title string
+	Tags  []string `db:"child=Post_Tags:post_id:tag"`
+}
+
+func TestChildrenSQL(t *testing.T) {
+	ctx := context.Background()
+	ps := []*ChildPost{{1, "a", []string{"x", "it's"}}, {2, "b", nil}}
+	takeExecs()
+	if err := SaveChildren(ctx, tdb, PostgreSQL, ps); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"DELETE FROM post_tags WHERE post_id IN (1,2)",
+		"INSERT INTO post_tags (post_id,tag) VALUES (1,'x'),(1,'it''s')"}
+	if got := takeExecs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SaveChildren:\n got %q\nwant %q", got, want)
+	}
+
+	// no elements at all: only the DELETE
+	err := SaveChildren(ctx, tdb, PostgreSQL, &ChildPost{ID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"DELETE FROM post_tags WHERE post_id IN (3)"}
+	if got := takeExecs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SaveChildren:\n got %q\nwant %q", got, want)
+	}
+
+	q := "SELECT post_id,tag FROM post_tags WHERE post_id IN (1,2)"
+	tresult(q, []string{"post_id", "tag"}, []driver.Value{int64(2), "z"},
+		[]driver.Value{int64(1), "y"}, []driver.Value{int64(9), "orphan"})
+	ps[1].Tags = []string{"stale"}
+	if err := LoadChildren(ctx, tdb, PostgreSQL, ps); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ps[0].Tags, []string{"y"}) ||
+		!reflect.DeepEqual(ps[1].Tags, []string{"z"}) {
+		t.Errorf("LoadChildren: %q %q", ps[0].Tags, ps[1].Tags)
+	}
+
+	tresult(q, []string{"post_id", "tag"})
+	if err := LoadChildren(ctx, tdb, PostgreSQL, ps); err != nil {
+		t.Fatal(err)
+	}
+	if ps[0].Tags != nil || ps[1].Tags != nil {
+		t.Errorf("LoadChildren without rows: %q %q", ps[0].Tags, ps[1].Tags)
+	}
+}
+
+type ChildNoPK struct {
+	Title string
+	Tags  []string `db:"child=t:id:tag"`
+}
+
+func TestChildField(t *testing.T) {
+	s, err := Buildstr(&ChildPost{1, "a", []string{"x"}})
+	if err != nil || s != `SET id=1,title="a"` {
+		t.Errorf("Buildstr: %s %v", s, err)
+	}
+	ctx := context.Background()
+	p := &ChildPost{ID: 1}
+	takeExecs()
+	if err = SaveChildren(ctx, tdb, nil, p); err == nil {
+		t.Error("SaveChildren without dialect accepted")
+	}
+	if err = SaveChildren(ctx, tdb, MySQL, &ChildNoPK{}); err == nil {
+		t.Error("struct without primary key accepted")
+	}
+	if err = LoadChildren(ctx, tdb, MySQL, *p); err == nil {
+		t.Error("LoadChildren into a struct value accepted")
+	}
+	if got := takeExecs(); got != nil {
+		t.Errorf("executed %q", got)
+	}
+}
@@ -0,0 +1,112 @@
+package sqlaux
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Clone 按映射复制data的所有映射字段（含计算列），返回新变量，切片、映射、
+// 指针、接口等引用类型字段深度复制，因而修改副本不影响原变量，可用作Diff的
+// 快照。未映射的字段（如非导出字段）不复制，为nil的嵌入结构指针在副本中也为
+// nil。字段值不能含有循环引用。
+//
+// 约定：data的类型形如*struct，返回值类型与data相同。
+func Clone(data interface{}) (interface{}, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct ||
+		v.IsNil() {
+		return nil, fmt.Errorf("Clone: data not like *struct")
+	}
+	stru := v.Type().Elem().Name()
+	mapping, err := automap(loadmap(), v.Type().Elem())
+	if err != nil {
+		return nil, fmt.Errorf("Clone: %v", err)
+	}
+	e, ok := mapping[stru]
+	if !ok {
+		return nil, fmt.Errorf("Clone: %q has no mapping", stru)
+	}
+
+	c := reflect.New(v.Type().Elem())
+	for _, n := range e.name.([]string) {
+		m := mapping["0."+stru+"."+n]
+		if src, dst := cloneptrs(m, v.UnsafePointer(),
+			c.UnsafePointer()); src.IsValid() {
+			dst.Elem().Set(deepcopy(src.Elem()))
+		}
+	}
+	return c.Interface(), nil
+}
+
+// cloneptrs 返回地址为p的结构中字段m的指针，及副本q中对应字段的指针。m 经
+// 由的结构指针在p中为nil时返回nil，q 中的保持为nil；否则在q中分配之。
+func cloneptrs(m entryT, p, q unsafe.Pointer) (reflect.Value,
+	reflect.Value) {
+	for _, h := range m.via {
+		if p = *(*unsafe.Pointer)(unsafe.Add(p, h.offset)); p == nil {
+			return reflect.Value{}, reflect.Value{}
+		}
+		pq := (*unsafe.Pointer)(unsafe.Add(q, h.offset))
+		if *pq == nil {
+			*pq = reflect.New(h.typ).UnsafePointer()
+		}
+		q = *pq
+	}
+	return reflect.NewAt(m.typ, unsafe.Add(p, m.offset)),
+		reflect.NewAt(m.typ, unsafe.Add(q, m.offset))
+}
+
+// deepcopy 返回v的深度副本。结构的非导出成员为浅复制。
+func deepcopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(deepcopy(v.Elem()))
+		return p
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepcopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepcopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for it := v.MapRange(); it.Next(); {
+			c.SetMapIndex(deepcopy(it.Key()), deepcopy(it.Value()))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepcopy(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v) // unexported members
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepcopy(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package sqlaux
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type CloneLeaf struct{ Note string }
+
+type CloneMid struct {
+	Level int
+	*CloneLeaf
+}
+
+type CloneDoc struct {
+	ID   int `db:"pk"`
+	Tags []string
+	*CloneMid
+}
+
+func TestCloneShape(t *testing.T) {
+	for _, d := range []*CloneDoc{
+		{ID: 1},
+		{ID: 2, CloneMid: &CloneMid{}},
+		{ID: 3, CloneMid: &CloneMid{Level: 1,
+			CloneLeaf: &CloneLeaf{"x"}}},
+	} {
+		c, err := Clone(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := c.(*CloneDoc)
+		if (got.CloneMid == nil) != (d.CloneMid == nil) {
+			t.Errorf("%d: CloneMid %v, want %v", d.ID, got.CloneMid, d.CloneMid)
+			continue
+		}
+		if d.CloneMid == nil {
+			continue
+		}
+		if got.CloneMid == d.CloneMid || got.Level != d.Level ||
+			(got.CloneLeaf == nil) != (d.CloneLeaf == nil) {
+			t.Errorf("%d: got %+v, want %+v", d.ID, got.CloneMid, d.CloneMid)
+		}
+		if d.CloneLeaf != nil && (got.CloneLeaf == d.CloneLeaf ||
+			got.Note != d.Note) {
+			t.Errorf("%d: got %+v", d.ID, got.CloneLeaf)
+		}
+	}
+
+	d := &CloneDoc{Tags: []string{"a"}}
+	c, _ := Clone(d)
+	c.(*CloneDoc).Tags[0] = "b"
+	if d.Tags[0] != "a" {
+		t.Error("Clone shares the slice")
+	}
+}
+
+type CloneItem struct {
+	ID    int
+	Attrs map[string][]int `db:"json"`
+	Note  *string
+	At    time.Time
+	seen  bool
+}
+
+func TestClone(t *testing.T) {
+	note := "n"
+	d := &CloneItem{1, map[string][]int{"a": {1}}, &note,
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), true}
+	c, err := Clone(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := c.(*CloneItem)
+	if want := *d; got.ID != want.ID || !reflect.DeepEqual(got.Attrs,
+		want.Attrs) || *got.Note != note || !got.At.Equal(want.At) {
+		t.Errorf("Clone: got %+v, want %+v", *got, want)
+	}
+	if got.seen {
+		t.Error("Clone copied an unmapped field")
+	}
+	got.Attrs["a"][0], *got.Note = 2, "m"
+	if d.Attrs["a"][0] != 1 || *d.Note != "n" {
+		t.Error("Clone shares the map or the pointer")
+	}
+	if cs, _ := Diff(d, got); len(cs) != 2 {
+		t.Errorf("Diff with the changed clone: %+v", cs)
+	}
+	if _, err = Clone(*d); err == nil {
+		t.Error("Clone of a struct value accepted")
+	}
+}
@@ -0,0 +1,44 @@
+// sqlauxbench 以sqlaux的辅助函数对数据库施加插入、查询负载，报告吞吐量和延迟
+// 的百分位数，见包bench。
+//
+// 用法：
+//
+//	sqlauxbench -driver 驱动名 -dsn 数据源 [-op insert|select] [-n 100]
+//		[-batch 1] [-c 1] [-fields 字段,...] [-truncate]
+//
+// 负载作用于内置的示例结构Sample，其表sqlaux_bench须已存在，可由
+// sqlaux.CreateTableSQL(Sample{}, "", d)生成。本命令不含任何数据库驱动，构建
+// 前须在本目录添加导入驱动的文件，如：
+//
+//	package main
+//
+//	import _ "github.com/lib/pq"
+//
+// 要测试自己的结构，以之调用bench.Main即可。出错时以状态码2退出。
+package main
+
+import (
+	"time"
+
+	"github.com/laokz/sqlaux"
+	"github.com/laokz/sqlaux/bench"
+)
+
+// Sample 为示例结构。
+type Sample struct {
+	_       struct{} `db:"table=sqlaux_bench"`
+	ID      int64    `db:"pk"`
+	Name    string   `db:"size=32"`
+	Score   float64
+	Created time.Time
+}
+
+func init() {
+	if err := sqlaux.MapStruct(Sample{}); err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+	bench.Main(Sample{})
+}
@@ -0,0 +1,77 @@
+// sqlauxcheck 检查代码库中的SQL字符串常量与sqlaux映射的一致性：简单的SELECT、
+// INSERT语句中的每一列都应是某个已映射结构的映射列，否则运行时Scan等将报错，
+// 见vet.Queries。
+//
+// 用法：
+//
+//	sqlauxcheck [-tag db] [目录 ...]
+//
+// 目录缺省为"./..."，以"/..."结尾时递归检查其所有子目录。有检查结果时以状态
+// 码1退出。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/laokz/sqlaux"
+	"github.com/laokz/sqlaux/vet"
+)
+
+func main() {
+	c := sqlaux.GetConfig()
+	flag.StringVar(&c.Tag, "tag", c.Tag, "struct tag name")
+	flag.Parse()
+	if err := sqlaux.SetConfig(c); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+
+	var dirs []string
+	for _, d := range args {
+		dirs = append(dirs, expand(d)...)
+	}
+	ds, err := vet.Queries(dirs...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for _, d := range ds {
+		fmt.Println(d)
+	}
+	if len(ds) > 0 {
+		os.Exit(1)
+	}
+}
+
+// expand 将以"/..."结尾的目录d展开为其所有含Go源文件的子目录。
+func expand(d string) []string {
+	if !strings.HasSuffix(d, "/...") {
+		return []string{d}
+	}
+	var dirs []string
+	filepath.Walk(strings.TrimSuffix(d, "/..."), func(p string,
+		fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if n := fi.Name(); p != d && n != "." && (strings.HasPrefix(n,
+				".") || n == "testdata" || n == "vendor") {
+				return filepath.SkipDir
+			}
+			if m, _ := filepath.Glob(filepath.Join(p, "*.go")); len(m) > 0 {
+				dirs = append(dirs, p)
+			}
+		}
+		return nil
+	})
+	return dirs
+}
@@ -0,0 +1,82 @@
+// sqlauxvet 静态检查使用sqlaux的Go源码，报告struct tag错误、重复列名、未映射
+// 的结构，以及与接收结构不符的SELECT语句，见包vet。
+//
+// 用法：
+//
+//	sqlauxvet [-tag db] [-snake] [目录 ...]
+//
+// -snake 表示被检查的程序以SnakeCase作NameMapper。目录缺省为当前目录，以
+// "/..."结尾时递归检查其所有子目录。有检查结果时以状态码1退出。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/laokz/sqlaux"
+	"github.com/laokz/sqlaux/vet"
+)
+
+func main() {
+	c := sqlaux.GetConfig()
+	flag.StringVar(&c.Tag, "tag", c.Tag, "struct tag name")
+	snake := flag.Bool("snake", false, "snake_case default column names")
+	flag.Parse()
+	if *snake {
+		c.NameMapper = sqlaux.SnakeCase
+	}
+	if err := sqlaux.SetConfig(c); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	found := false
+	for _, d := range dirs {
+		for _, dir := range expand(d) {
+			ds, err := vet.Dir(dir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			for _, d := range ds {
+				fmt.Println(d)
+				found = true
+			}
+		}
+	}
+	if found {
+		os.Exit(1)
+	}
+}
+
+// expand 将以"/..."结尾的目录d展开为其所有含Go源文件的子目录。
+func expand(d string) []string {
+	if !strings.HasSuffix(d, "/...") {
+		return []string{d}
+	}
+	var dirs []string
+	filepath.Walk(strings.TrimSuffix(d, "/..."), func(p string,
+		fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if n := fi.Name(); p != d && n != "." && (strings.HasPrefix(n,
+				".") || n == "testdata" || n == "vendor") {
+				return filepath.SkipDir
+			}
+			if m, _ := filepath.Glob(filepath.Join(p, "*.go")); len(m) > 0 {
+				dirs = append(dirs, p)
+			}
+		}
+		return nil
+	})
+	return dirs
+}
@@ -0,0 +1,97 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type ConcRow struct {
+	ID   int `db:"pk"`
+	Name string
+}
+
+type (
+	ConcA struct{ A int }
+	ConcB struct{ B int }
+	ConcC struct{ C int }
+	ConcD struct{ D int }
+)
+
+// TestConcurrentMapScan 在MapStruct修改映射的同时Scan（自动映射ConcRow），
+// 需以-race运行。
+func TestConcurrentMapScan(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for _, v := range []interface{}{ConcA{}, ConcB{}, ConcC{}, ConcD{}} {
+		wg.Add(1)
+		go func(v interface{}) {
+			defer wg.Done()
+			// already mapped by an earlier run with -count
+			err := MapStruct(v)
+			if err != nil && !strings.Contains(err.Error(), "already mapped") {
+				errs <- err
+			}
+		}(v)
+	}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				rows := testRows(t, []string{"id", "name"},
+					[]driver.Value{int64(i), "a"})
+				var d []*ConcRow
+				err := Scan(rows, &d)
+				rows.Close()
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(d) != 1 || d[0].ID != i || d[0].Name != "a" {
+					t.Errorf("got %+v", d)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if _, err := FieldNames(ConcD{}); err != nil {
+		t.Error(err)
+	}
+}
+
+type ConcE struct{ E int }
+
+func TestMapStructAtRuntime(t *testing.T) {
+	err := MapStruct(ConcE{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "stru is nil") {
+		t.Errorf("nil stru: %v", err)
+	}
+	if _, ok := loadmap()["ConcE"]; ok {
+		t.Error("mapped ConcE though MapStruct failed")
+	}
+	for _, bad := range []interface{}{3, struct{ A int }{}} {
+		if err = MapStruct(bad); err == nil {
+			t.Errorf("MapStruct(%#v) accepted", bad)
+		}
+	}
+
+	c := GetConfig()
+	defer SetConfig(c)
+	c.InitOnly = true
+	SetConfig(c)
+	if err = MapStruct(&ConcE{}); err == nil ||
+		!strings.Contains(err.Error(), "init()") {
+		t.Errorf("InitOnly: %v", err)
+	}
+	// neither automapped
+	if _, err = Signature(ConcE{}); err == nil {
+		t.Error("automapped under InitOnly")
+	}
+}
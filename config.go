@@ -0,0 +1,273 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Config 汇集sqlaux的各项全局配置，前几个成员的含义与同名的已废弃导出变量相
+// 同，其余配置项没有对应的导出变量。
+//
+// 导出变量只在首次使用sqlaux时读取一次，作为缺省的全局配置（见GetConfig），
+// 此后对它们的修改被忽略：运行中修改导出变量既不是并发安全的，又会影响同一
+// 进程中无关的代码。应以整体原子替换的方式修改全局配置（见SetConfig），以按
+// 调用覆盖的方式修改单次调用的配置（见Config.Scan、Config.Buildstr）。
+type Config struct {
+	Tag, Key, Op  string // 映射时解析的struct tag，见Tag
+	Validate      bool
+	Deterministic bool
+	MaxRows       int
+	CheckDialect  Dialect
+	ZeroTime      ZeroTimePolicy
+	ZeroTimeValue time.Time
+	TimeLayout    string
+	TimeZone      *time.Location
+
+	Dialect  Dialect // Buildstr等按其规范拼接字面值，nil 时按Go语法
+	Capacity int     // Scan为每个dest预分配的行数
+	Lenient  bool    // Scan丢弃没有映射的结果列，而不是报错
+	Partial  bool    // ScanContext被取消时写入已接收的行
+	Logger   Logger  // 不为nil时，ExecContext 等在执行每条语句前调用之
+	// Progress 不为nil时，Export、DumpInsertScript 在每批之后以进度调用之
+	Progress func(p Progress)
+	// OmitEmpty 为true时，Buildstr等生成的SET值串中不含零值字段，同tag中的
+	// "omitempty"
+	OmitEmpty bool
+	// NameMapper 不为nil时，MapStruct为没有tag列名的字段以其结果作为缺省列
+	// 名，代替字段名的小写，如设为SnakeCase使CreatedAt对应created_at。结果须
+	// 为小写的合法标识符
+	NameMapper func(field string) string
+	// InitOnly 为true时，MapStruct、MapType、MapTable等映射函数和Use、
+	// SetRouter、Observe、OnTx等注册函数只能在init()中调用（严格模式），以
+	// 确保所有映射和钩子在程序启动时一次建立、tag错误尽早暴露，这时也不自动
+	// 映射（见automap）。缺省时可以在任何时候（如插件加载、测试中）安全地调
+	// 用
+	InitOnly bool
+	// ScriptBatch 为DumpInsertScript默认的每条INSERT语句的最大行数，<=0 时
+	// 为500，缺省为500
+	ScriptBatch int
+	// PlanCacheSize 为Scan接收计划缓存的最大条目数，超出时清空缓存重新累积，
+	// <=0 时不缓存，缺省为1024。接收计划由dest结构和查询结果列名决定，动态
+	// 拼接的SELECT语句会产生大量不同的计划，可通过Stats()观察命中率来调整
+	PlanCacheSize int
+}
+
+// Logger 为语句日志函数，query、args 为经中间件改写后将要执行的语句及其参
+// 数。
+type Logger func(ctx context.Context, query string, args []interface{})
+
+// Option 为按调用覆盖配置的选项，用于With。
+type Option func(c *Config)
+
+// WithDialect 选项设置Dialect。
+func WithDialect(d Dialect) Option {
+	return func(c *Config) { c.Dialect = d }
+}
+
+// WithNullPolicy 选项设置time.Time零值与NULL的处理策略，即ZeroTime及
+// ZeroTimeValue（仅ZeroTimeSentinel策略使用）。
+func WithNullPolicy(p ZeroTimePolicy, sentinel time.Time) Option {
+	return func(c *Config) { c.ZeroTime, c.ZeroTimeValue = p, sentinel }
+}
+
+// WithTime 选项设置time.Time字面值的格式TimeLayout和时区TimeZone。
+func WithTime(layout string, loc *time.Location) Option {
+	return func(c *Config) { c.TimeLayout, c.TimeZone = layout, loc }
+}
+
+// WithCapacity 选项设置Capacity。
+func WithCapacity(n int) Option {
+	return func(c *Config) { c.Capacity = n }
+}
+
+// WithLenient 选项设置Lenient为true。
+func WithLenient() Option {
+	return func(c *Config) { c.Lenient = true }
+}
+
+// WithPartial 选项设置Partial为true。
+func WithPartial() Option {
+	return func(c *Config) { c.Partial = true }
+}
+
+// WithLogger 选项设置Logger。
+func WithLogger(l Logger) Option {
+	return func(c *Config) { c.Logger = l }
+}
+
+// WithProgress 选项设置Progress。
+func WithProgress(fn func(p Progress)) Option {
+	return func(c *Config) { c.Progress = fn }
+}
+
+// WithOmitEmpty 选项设置OmitEmpty为true，用于部分更新：
+//
+//	s, err := sqlaux.With(sqlaux.WithOmitEmpty()).Buildstr(&User{Name: "x"})
+func WithOmitEmpty() Option {
+	return func(c *Config) { c.OmitEmpty = true }
+}
+
+// With 返回以opts依次修改当前全局配置后的配置，用于按调用覆盖，如：
+//
+//	err := sqlaux.With(sqlaux.WithLenient(), sqlaux.WithCapacity(100)).
+//		Scan(rows, &users)
+//
+// 各选项不影响全局配置和其它调用。
+func With(opts ...Option) Config {
+	return GetConfig().With(opts...)
+}
+
+// With 返回以opts依次修改c后的配置，c 本身不变。
+func (c Config) With(opts ...Option) Config {
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+var config atomic.Value // *Config, set by SetConfig
+
+// SetConfig 以c原子地替换全局配置，通常以GetConfig的结果修改而来。已建立的
+// 映射不受Tag、Key、Op、NameMapper、CheckDialect的改变影响。Tag、Key、Op 不
+// 能为空。
+func SetConfig(c Config) error {
+	if c.Tag == "" || c.Key == "" || c.Op == "" {
+		return fmt.Errorf("SetConfig: empty Tag, Key or Op")
+	}
+	config.Store(&c)
+	return nil
+}
+
+// GetConfig 返回当前的全局配置。从未调用SetConfig时，返回首次使用sqlaux时
+// 由各导出变量组成的缺省配置。
+func GetConfig() Config {
+	return *conf()
+}
+
+// conf 返回当前的全局配置，调用者不能修改之。首次调用时以各导出变量建立缺省
+// 配置，此后不再读取它们。
+func conf() *Config {
+	if c, ok := config.Load().(*Config); ok {
+		return c
+	}
+	config.CompareAndSwap(nil, &Config{Tag: Tag, Key: Key, Op: Op,
+		Validate: Validate, Deterministic: Deterministic, MaxRows: MaxRows,
+		CheckDialect: CheckDialect, ZeroTime: ZeroTime,
+		ZeroTimeValue: ZeroTimeValue, TimeLayout: TimeLayout,
+		TimeZone: TimeZone, ScriptBatch: 500,
+		PlanCacheSize: 1024}) // SetConfig may win
+	return config.Load().(*Config)
+}
+
+// Scan 与Scan相同，但以c代替全局配置中的MaxRows、Capacity、Lenient、
+// ZeroTime、ZeroTimeValue，不影响其它调用。
+func (c Config) Scan(rows *sql.Rows, dest ...interface{}) error {
+	o := &scanOpts{mp: loadmap(), cfg: &c}
+	if err := scan(rows, o, dest); err != nil {
+		return fmt.Errorf("Scan: %w", err)
+	}
+	return nil
+}
+
+// ScanContext 与ScanContext相同，但按c的配置，同Config.Scan，且以c代替全
+// 局配置中的Partial。
+func (c Config) ScanContext(ctx context.Context, rows *sql.Rows,
+	dest ...interface{}) error {
+	o := &scanOpts{mp: loadmap(), cfg: &c, ctx: ctx}
+	if err := scan(rows, o, dest); err != nil {
+		return fmt.Errorf("ScanContext: %w", err)
+	}
+	return nil
+}
+
+// Buildstr 与Buildstr相同，但以c代替全局配置中的Validate、Deterministic、
+// ZeroTime、ZeroTimeValue、TimeLayout、TimeZone、Dialect、OmitEmpty，不影响
+// 其它调用。
+func (c Config) Buildstr(data interface{}, field ...string) (string, error) {
+	var sql strings.Builder
+	if err := c.buildTo(&sql, data, field...); err != nil {
+		return "", fmt.Errorf("Buildstr: %w", err)
+	}
+	return sql.String(), nil
+}
+
+// BuildstrTo 与BuildstrTo相同，但按c的配置，同Config.Buildstr。
+func (c Config) BuildstrTo(w io.Writer, data interface{}, field ...string) error {
+	ew := &errWriter{w: w}
+	err := c.buildTo(ew, data, field...)
+	if err == nil {
+		err = ew.err
+	}
+	if err != nil {
+		return fmt.Errorf("BuildstrTo: %w", err)
+	}
+	return nil
+}
+
+// buildTo 为Buildstr、BuildstrTo的公共实现，按c的配置向w写入data的值串。
+func (c *Config) buildTo(w io.Writer, data interface{},
+	field ...string) (err error) {
+	if data == nil {
+		return fmt.Errorf("data is nil")
+	}
+	t := structof(reflect.TypeOf(data))
+	if len(loadhooks().observers) > 0 && t.Kind() == reflect.Struct {
+		n := 1
+		if v := reflect.ValueOf(data); v.Kind() == reflect.Slice {
+			n = v.Len()
+		}
+		defer observe("build", []reflect.Type{t}, time.Now(), &n, &err)
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return err
+	}
+	return build(w, c.Dialect, c, mapping, data, field...)
+}
+
+// Buildargs 与Buildargs相同，但以c代替全局配置中的Validate、ZeroTime、
+// ZeroTimeValue、TimeZone、OmitEmpty，不影响其它调用。
+func (c Config) Buildargs(d Dialect, data interface{},
+	field ...string) (Fragment, error) {
+	return buildargs(&c, d, data, field...)
+}
+
+// BuildWhere 与BuildWhere相同，但按c的配置，同Config.Buildargs。
+func (c Config) BuildWhere(d Dialect, data interface{},
+	field ...string) (Fragment, error) {
+	return buildWhere(&c, d, data, field...)
+}
+
+// ExecContext 与ExecContext相同，但以c代替全局配置中的Logger，不影响其它调
+// 用。
+func (c Config) ExecContext(ctx context.Context, db Querier, query string,
+	args ...interface{}) (sql.Result, error) {
+	return execContext(ctx, &c, db, query, args)
+}
+
+// QueryContext 与QueryContext相同，但按c的配置，同Config.ScanContext和
+// Config.ExecContext。
+func (c Config) QueryContext(ctx context.Context, db Querier, query string,
+	args []interface{}, dest ...interface{}) error {
+	return queryContext(ctx, &c, db, query, args, dest)
+}
+
+// Export 与Export相同，但以c代替全局配置中的Progress。
+func (c Config) Export(ctx context.Context, db Querier, d Dialect,
+	table string, after ResumeToken, batch int, dest interface{},
+	fn func(next ResumeToken) error) error {
+	return export(ctx, &c, db, d, table, after, batch, dest, fn)
+}
+
+// DumpInsertScript 与DumpInsertScript相同，但以c代替全局配置中的Progress及
+// 拼接字面值的各项配置（同Config.Buildstr，Dialect 除外）。
+func (c Config) DumpInsertScript(w io.Writer, data interface{},
+	opts ScriptOpts) error {
+	return dumpInsertScript(w, &c, data, opts)
+}
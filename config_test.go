@@ -0,0 +1,119 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type CfgSnake struct {
+	ID        int
+	CreatedAt int
+}
+
+type CfgPlan struct {
+	ID int
+}
+
+func TestConfigSnapshot(t *testing.T) {
+	old := GetConfig()
+	defer SetConfig(old)
+	if old.PlanCacheSize != 1024 {
+		t.Errorf("default PlanCacheSize %d", old.PlanCacheSize)
+	}
+	v := Validate
+	Validate = !old.Validate
+	defer func() { Validate = v }()
+	if conf().Validate != old.Validate {
+		t.Error("variable read after first use")
+	}
+
+	c := old
+	c.NameMapper = SnakeCase
+	c.PlanCacheSize = 0
+	if err := SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ToMap(&CfgSnake{1, 2})
+	if err != nil || m["created_at"] != int64(2) {
+		t.Errorf("NameMapper: %v %v", m, err)
+	}
+	rows := testRows(t, []string{"id"}, []driver.Value{int64(1)})
+	defer rows.Close()
+	var d []*CfgPlan
+	if err = Scan(rows, &d); err != nil || len(d) != 1 {
+		t.Fatal(err)
+	}
+	if n := Stats().PlanCached; n != 0 {
+		t.Errorf("%d plans cached with PlanCacheSize 0", n)
+	}
+}
+
+type CfgTagged struct {
+	ID   int    `sql:"name:uid"`
+	Name string `db:"col=n"`
+}
+
+func TestConfigTag(t *testing.T) {
+	old := GetConfig()
+	defer SetConfig(old)
+	c := old
+	c.Tag, c.Key, c.Op = "sql", "name", ":"
+	if err := SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Signature(CfgTagged{})
+	if err != nil || len(s) != 2 || s[0] != "uid" || s[1] != "name" {
+		t.Errorf("Signature: %q %v", s, err)
+	}
+	for _, bad := range []Config{{Key: "col", Op: "="},
+		{Tag: "db", Op: "="}, {Tag: "db", Key: "col"}} {
+		if err = SetConfig(bad); err == nil {
+			t.Errorf("SetConfig(%+v) accepted", bad)
+		}
+	}
+	if GetConfig().Tag != "sql" {
+		t.Error("a rejected config replaced the global one")
+	}
+
+	// a per-call config leaves the global one alone
+	rows := testRows(t, []string{"uid"}, []driver.Value{int64(1)},
+		[]driver.Value{int64(2)})
+	defer rows.Close()
+	var d []*CfgTagged
+	c.MaxRows = 1
+	if err = c.Scan(rows, &d); err == nil {
+		t.Error("Config.Scan ignored MaxRows")
+	}
+	if GetConfig().MaxRows != old.MaxRows {
+		t.Error("Config.Scan changed the global MaxRows")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	base := With(WithDialect(PostgreSQL))
+	c := base.With(WithCapacity(8), WithLenient())
+	if base.Capacity != GetConfig().Capacity || base.Lenient ||
+		GetConfig().Dialect != nil {
+		t.Error("With changed its receiver or the global config")
+	}
+	s, err := c.Buildstr(&CfgPlan{1})
+	if err != nil || s != "SET id=1" {
+		t.Errorf("Buildstr: %s %v", s, err)
+	}
+	s, _ = With(WithDialect(MySQL)).Buildstr(&ShareOrder{1, "it's"})
+	if s != `SET id=1,name='it\'s'` {
+		t.Errorf("WithDialect: %s", s)
+	}
+
+	rows := testRows(t, []string{"id", "extra"}, []driver.Value{int64(1), 2})
+	defer rows.Close()
+	var d []*CfgPlan
+	if err = c.Scan(rows, &d); err != nil || len(d) != 1 || cap(d) != 8 {
+		t.Errorf("Scan: len %d cap %d %v", len(d), cap(d), err)
+	}
+	rows = testRows(t, []string{"id", "extra"}, []driver.Value{int64(1), 2})
+	defer rows.Close()
+	if err = base.Scan(rows, &d); err == nil {
+		t.Error("extra column accepted without WithLenient")
+	}
+}
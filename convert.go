@@ -0,0 +1,194 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decode 将原始数据库值src（如defer字段所接收的值）转换后存入dest，用于应
+// 用程序延后解码多态列。dest 须为非nil指针，其指向的类型实现了sql.Scanner接
+// 口时调用其Scan，否则按以下规则转换：
+//
+//	● src为nil时，dest指向指针、接口、切片、映射则置为nil，否则报错。
+//	● 数值、布尔、字符串、[]byte、time.Time之间按常规方式相互转换，如
+//		[]byte("12")可存入*int，int64(1)可存入*bool，溢出时报错。
+//	● 其它情况下src的类型可转换为dest所指类型时直接转换，否则报错。
+func Decode(src, dest interface{}) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("Decode: dest not a non-nil pointer")
+	}
+	if err := convert(dv.Elem(), src); err != nil {
+		return fmt.Errorf("Decode: %v", err)
+	}
+	return nil
+}
+
+// timeType 为time.Time类型。
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeLayouts 为从字符串解析时间时依次尝试的格式。
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00", "2006-01-02"}
+
+// convert 将src转换后存入可设置的dv，规则见Decode。
+func convert(dv reflect.Value, src interface{}) error {
+	if dv.CanAddr() {
+		if s, ok := dv.Addr().Interface().(sql.Scanner); ok {
+			return s.Scan(src)
+		}
+	}
+	if src == nil {
+		switch dv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			dv.Set(reflect.Zero(dv.Type()))
+			return nil
+		}
+		return fmt.Errorf("cannot convert NULL into %q", dv.Type())
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dv.Type()) {
+		if b, ok := src.([]byte); ok { // never keep driver's buffer
+			src = append([]byte(nil), b...)
+		}
+		dv.Set(reflect.ValueOf(src))
+		return nil
+	}
+
+	var str string // textual form of src
+	switch s := src.(type) {
+	case string:
+		str = s
+	case []byte:
+		str = string(s)
+	case time.Time:
+		str = s.Format(time.RFC3339Nano)
+	default:
+		str = fmt.Sprint(s)
+	}
+
+	var err error
+	switch dv.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(dv.Type().Elem())
+		if err = convert(p.Elem(), src); err == nil {
+			dv.Set(p)
+		}
+		return err
+	case reflect.String:
+		dv.SetString(str)
+		return nil
+	case reflect.Slice:
+		if dv.Type().Elem().Kind() == reflect.Uint8 {
+			dv.SetBytes([]byte(str))
+			return nil
+		}
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(str); err == nil {
+			dv.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		var i int64
+		if b, ok := src.(bool); ok && b {
+			i = 1
+		} else if !ok {
+			i, err = strconv.ParseInt(str, 10, 64)
+		}
+		if err == nil && dv.OverflowInt(i) {
+			return fmt.Errorf("%v overflows %q", src, dv.Type())
+		} else if err == nil {
+			dv.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		var u uint64
+		if b, ok := src.(bool); ok && b {
+			u = 1
+		} else if !ok {
+			u, err = strconv.ParseUint(str, 10, 64)
+		}
+		if err == nil && dv.OverflowUint(u) {
+			return fmt.Errorf("%v overflows %q", src, dv.Type())
+		} else if err == nil {
+			dv.SetUint(u)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(str, 64); err == nil &&
+			dv.OverflowFloat(f) {
+			return fmt.Errorf("%v overflows %q", src, dv.Type())
+		} else if err == nil {
+			dv.SetFloat(f)
+			return nil
+		}
+	case reflect.Struct:
+		if dv.Type() == timeType {
+			for _, l := range timeLayouts {
+				var t time.Time
+				if t, err = time.Parse(l, str); err == nil {
+					dv.Set(reflect.ValueOf(t))
+					return nil
+				}
+			}
+		}
+	}
+	if sv.Type().ConvertibleTo(dv.Type()) {
+		dv.Set(sv.Convert(dv.Type()))
+		return nil
+	}
+	return fmt.Errorf("cannot convert %T(%v) into %q", src, src, dv.Type())
+}
+
+// boolT 为bool字段的等价类型。各数据库及其驱动对布尔列的表示不一，boolT 统
+// 一接收：布尔值；整数（如MySQL的TINYINT(1)，非0为true）；单字节的[]byte（如
+// MySQL的BIT(1)）；不区分大小写的"t"/"f"、"true"/"false"、"y"/"n"、
+// "yes"/"no"、"on"/"off"、"1"/"0"文本（如PostgreSQL的't'/'f'）。
+type boolT bool
+
+var (
+	boolType  = reflect.TypeOf(false)
+	boolTType = reflect.TypeOf(boolT(false))
+)
+
+func (b *boolT) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case bool:
+		*b = boolT(v)
+		return nil
+	case int64:
+		*b = v != 0
+		return nil
+	case []byte:
+		if len(v) == 1 && v[0] <= 1 { // BIT(1)
+			*b = v[0] == 1
+			return nil
+		}
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("cannot scan %T into bool", src)
+	}
+	switch strings.ToLower(s) {
+	case "1", "t", "true", "y", "yes", "on":
+		*b = true
+	case "0", "f", "false", "n", "no", "off":
+		*b = false
+	default:
+		return fmt.Errorf("cannot scan %q into bool", s)
+	}
+	return nil
+}
+
+func (b boolT) Value() (driver.Value, error) { return bool(b), nil }
@@ -0,0 +1,92 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+type ConvAttr struct {
+	ID  int
+	Val interface{} `db:"defer"`
+}
+
+func TestDeferDecode(t *testing.T) {
+	rows := testRows(t, []string{"id", "val"},
+		[]driver.Value{int64(1), []byte("12")},
+		[]driver.Value{int64(2), nil})
+	defer rows.Close()
+	var d []*ConvAttr
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := d[0].Val.([]byte); !ok || string(b) != "12" ||
+		d[1].Val != nil {
+		t.Fatalf("deferred values %#v %#v", d[0].Val, d[1].Val)
+	}
+	var n int
+	if err := Decode(d[0].Val, &n); err != nil || n != 12 {
+		t.Errorf("Decode into int: %d %v", n, err)
+	}
+	p := &n
+	if err := Decode(d[1].Val, &p); err != nil || p != nil {
+		t.Errorf("Decode NULL into pointer: %v %v", p, err)
+	}
+	if err := Decode(d[1].Val, &n); err == nil {
+		t.Error("Decode NULL into int accepted")
+	}
+	var tm time.Time
+	err := Decode("2020-01-02 03:04:05", &tm)
+	if err != nil || !tm.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Decode into time.Time: %v %v", tm, err)
+	}
+	var ns sql.NullString
+	if err = Decode([]byte("x"), &ns); err != nil || ns.String != "x" ||
+		!ns.Valid {
+		t.Errorf("Decode into sql.Scanner: %v %v", ns, err)
+	}
+	if err = Decode(int64(300), new(int8)); err == nil {
+		t.Error("Decode overflow accepted")
+	}
+	if err = Decode(1, n); err == nil {
+		t.Error("Decode into non-pointer accepted")
+	}
+}
+
+type ConvFlag struct {
+	ID int
+	On bool
+	Ok *bool
+}
+
+func TestBoolScan(t *testing.T) {
+	rows := testRows(t, []string{"id", "on", "ok"},
+		[]driver.Value{int64(1), []byte{1}, "f"},
+		[]driver.Value{int64(2), "F", int64(2)},
+		[]driver.Value{int64(3), int64(1), nil},
+		[]driver.Value{int64(4), "YES", "off"},
+		[]driver.Value{int64(5), true, []byte("t")})
+	defer rows.Close()
+	var d []*ConvFlag
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	on := []bool{true, false, true, true, true}
+	ok := []interface{}{false, true, nil, false, true}
+	for i, f := range d {
+		if f.On != on[i] || (f.Ok == nil) != (ok[i] == nil) ||
+			f.Ok != nil && *f.Ok != ok[i] {
+			t.Errorf("row %d: %v %v", i, f.On, f.Ok)
+		}
+	}
+	if s, _ := Buildstr(d[1]); s != "SET id=2,on=false,ok=true" {
+		t.Errorf("Buildstr: %s", s)
+	}
+
+	bad := testRows(t, []string{"on"}, []driver.Value{"maybe"})
+	defer bad.Close()
+	if err := Scan(bad, &d); err == nil {
+		t.Error("bool from \"maybe\" accepted")
+	}
+}
@@ -0,0 +1,340 @@
+package sqlaux
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CreateTableSQL 根据stru的映射，按d的规范生成名为table的CREATE TABLE语句，
+// 可用于测试和初始化脚本。table 为""时取stru所登记的表名（见Tablename），可
+// 以带模式名前缀（如"app.users"），其各部分分别加引号。列按字段顺序排列，
+// 列类型优先取tag中的"dbtype="或其简写"type="（与"size="组合，如
+// dbtype=varchar size=64生成varchar(64)，也可直接写type=varchar(64)），
+// 否则由字段类型推导，无法推导时报错。字段为指针或sql.Null*类型时列可为
+// NULL，否则为NOT NULL，这与Scan的NULL处理一致（time.Time字段还取决于
+// ZeroTime策略），tag中的"null"、"notnull"可覆盖之。tag 中有"pk"的字段组成
+// 表的PRIMARY KEY。d为MySQL时，tag中的"charset="、"collate="生成列的
+// CHARACTER SET、COLLATE属性。tag 中的"check="生成列的CHECK约束，
+// "ref=表.列"生成表的FOREIGN KEY约束，d为MySQL时"comment="生成列的COMMENT
+// 属性（其他数据库见CommentSQL）。
+// stru 以变量值的形式作参数，可以取零值。
+func CreateTableSQL(stru interface{}, table string, d Dialect) (string, error) {
+	t, err := structarg(stru)
+	if err != nil {
+		return "", fmt.Errorf("CreateTableSQL: %v", err)
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return "", fmt.Errorf("CreateTableSQL: %v", err)
+	}
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return "", fmt.Errorf("CreateTableSQL: %q has no mapping", t)
+	}
+	if table == "" {
+		table = tablename(mapping, t.Name())
+	}
+	if table == "" || d == nil {
+		return "", fmt.Errorf("CreateTableSQL: no table or dialect argument")
+	}
+
+	var sql strings.Builder
+	sql.WriteString("CREATE TABLE " + quotetable(d, table) + " (")
+	if w, ok := mapping["w."+t.Name()]; ok { // except computed columns
+		e = w
+	}
+	zt := conf().ZeroTime
+	for i, n := range e.name.([]string) {
+		if i > 0 {
+			sql.WriteString(",")
+		}
+		m := mapping["0."+t.Name()+"."+n]
+		typ, err := columntype(d, m)
+		if err != nil {
+			return "", fmt.Errorf("CreateTableSQL: %s.%s %v", t.Name(), n, err)
+		}
+		sql.WriteString("\n  " + d.QuoteIdent(m.name.(string)) + " " + typ)
+		if m.info != nil && d.Name() == "mysql" {
+			if m.info.charset != "" {
+				sql.WriteString(" CHARACTER SET " + m.info.charset)
+			}
+			if m.info.collate != "" {
+				sql.WriteString(" COLLATE " + m.info.collate)
+			}
+		}
+		if m.notnull(zt) {
+			sql.WriteString(" NOT NULL")
+		}
+		if m.info != nil && m.info.check != nil {
+			sql.WriteString(" CHECK (" + m.info.check.expr + ")")
+		}
+		if m.info != nil && m.info.comment != "" && d.Name() == "mysql" {
+			sql.WriteString(" COMMENT " + d.EscapeString(m.info.comment))
+		}
+	}
+	if pks := pkeys(mapping, t.Name()); len(pks) > 0 {
+		sql.WriteString(",\n  PRIMARY KEY (")
+		for i, m := range pks {
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString(d.QuoteIdent(m.name.(string)))
+		}
+		sql.WriteString(")")
+	}
+	for _, n := range e.name.([]string) {
+		m := mapping["0."+t.Name()+"."+n]
+		if m.info == nil || m.info.ref == "" {
+			continue
+		}
+		p := strings.Split(m.info.ref, ".")
+		sql.WriteString(",\n  FOREIGN KEY (" + d.QuoteIdent(m.name.(string)) +
+			") REFERENCES " + d.QuoteIdent(p[0]) + " (" + d.QuoteIdent(p[1]) +
+			")")
+	}
+	sql.WriteString("\n)")
+	return sql.String(), nil
+}
+
+// CreateIndexSQL 根据stru的映射，按d的规范生成表table的CREATE INDEX语句，
+// 每个索引一条，按索引名排序。字段tag中的"index=名"、"unique=名"声明列所属
+// 的（唯一）索引，可以用逗号分隔多个索引名；同名的各列按字段顺序组成复合索
+// 引。"where=条件"声明列所属索引为部分索引，只索引满足条件的行，如软删除表
+// 中未删除行的邮箱唯一：
+//
+//	Email string `db:"unique=uq_email where='deleted_at IS NULL'"`
+//
+// 生成：CREATE UNIQUE INDEX "uq_email" ON "t" ("email") WHERE deleted_at
+// IS NULL。同一索引的各列声明不同的条件，或d为不支持部分索引的MySQL时报错。
+// stru 以变量值的形式作参数，可以取零值。
+func CreateIndexSQL(stru interface{}, table string, d Dialect) ([]string,
+	error) {
+	t, err := structarg(stru)
+	if err != nil {
+		return nil, fmt.Errorf("CreateIndexSQL: %v", err)
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return nil, fmt.Errorf("CreateIndexSQL: %v", err)
+	}
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return nil, fmt.Errorf("CreateIndexSQL: %q has no mapping", t)
+	}
+	if table == "" || d == nil {
+		return nil, fmt.Errorf("CreateIndexSQL: no table or dialect argument")
+	}
+
+	type indexT struct {
+		unique bool
+		cols   []string
+		where  string
+	}
+	idx := make(map[string]*indexT)
+	add := func(names string, unique bool, col, where string) error {
+		for _, n := range strings.Split(names, ",") {
+			x, ok := idx[n]
+			if !ok {
+				x = &indexT{unique: unique, where: where}
+				idx[n] = x
+			}
+			if x.unique != unique {
+				return fmt.Errorf("index %q both unique and not", n)
+			}
+			if x.where != where {
+				return fmt.Errorf("index %q different where", n)
+			}
+			x.cols = append(x.cols, d.QuoteIdent(col))
+		}
+		return nil
+	}
+	for _, n := range e.name.([]string) {
+		m := mapping["0."+t.Name()+"."+n]
+		if m.info == nil || m.info.index == "" && m.info.unique == "" {
+			continue
+		}
+		if m.info.where != "" && d.Name() == "mysql" {
+			return nil, fmt.Errorf("CreateIndexSQL: %s.%s partial index "+
+				"not supported by mysql", t.Name(), n)
+		}
+		col := m.name.(string)
+		if m.info.index != "" {
+			if err := add(m.info.index, false, col, m.info.where); err != nil {
+				return nil, fmt.Errorf("CreateIndexSQL: %s.%s %v", t.Name(),
+					n, err)
+			}
+		}
+		if m.info.unique != "" {
+			if err := add(m.info.unique, true, col, m.info.where); err != nil {
+				return nil, fmt.Errorf("CreateIndexSQL: %s.%s %v", t.Name(),
+					n, err)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(idx))
+	for n := range idx {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	stmts := make([]string, len(names))
+	for i, n := range names {
+		x := idx[n]
+		s := "CREATE INDEX "
+		if x.unique {
+			s = "CREATE UNIQUE INDEX "
+		}
+		s += d.QuoteIdent(n) + " ON " + quotetable(d, table) + " (" +
+			strings.Join(x.cols, ",") + ")"
+		if x.where != "" {
+			s += " WHERE " + x.where
+		}
+		stmts[i] = s
+	}
+	return stmts, nil
+}
+
+// CommentSQL 根据stru的映射，按d的规范为表table中tag有"comment="的列生成
+// COMMENT ON COLUMN语句，每列一条，按字段顺序排列，如：
+//
+//	Name string `db:"comment='用户的真实姓名'"`
+//
+// 生成：COMMENT ON COLUMN "t"."name" IS '用户的真实姓名'。由此从结构生成的表
+// 在数据库中带有可读的说明，数据字典工具可直接读取。MySQL的列注释已由
+// CreateTableSQL生成，SQLite不支持注释，两者均返回nil。
+// stru 以变量值的形式作参数，可以取零值。
+func CommentSQL(stru interface{}, table string, d Dialect) ([]string,
+	error) {
+	t, err := structarg(stru)
+	if err != nil {
+		return nil, fmt.Errorf("CommentSQL: %v", err)
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return nil, fmt.Errorf("CommentSQL: %v", err)
+	}
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return nil, fmt.Errorf("CommentSQL: %q has no mapping", t)
+	}
+	if table == "" || d == nil {
+		return nil, fmt.Errorf("CommentSQL: no table or dialect argument")
+	}
+	if d.Name() == "mysql" || d.Name() == "sqlite" {
+		return nil, nil
+	}
+	if w, ok := mapping["w."+t.Name()]; ok { // except computed columns
+		e = w
+	}
+
+	var stmts []string
+	for _, n := range e.name.([]string) {
+		m := mapping["0."+t.Name()+"."+n]
+		if m.info == nil || m.info.comment == "" {
+			continue
+		}
+		stmts = append(stmts, "COMMENT ON COLUMN "+quotetable(d, table)+"."+
+			d.QuoteIdent(m.name.(string))+" IS "+
+			d.EscapeString(m.info.comment))
+	}
+	return stmts, nil
+}
+
+// notnull 报告在零值时间策略zt下，映射项e所对应的字段是否不能接收NULL，即
+// 其列应为NOT NULL。
+func (e entryT) notnull(zt ZeroTimePolicy) bool {
+	return e.info == nil && (e.typ != timeTType || zt == ZeroTimeKeep ||
+		zt == ZeroTimeError) || e.info != nil && !e.info.nullable
+}
+
+// columntype 返回映射项m在d中的列类型。
+func columntype(d Dialect, m entryT) (string, error) {
+	var size int
+	if m.info != nil {
+		if m.info.geo && m.info.dbtype == "" {
+			return "geometry", nil
+		}
+		if m.info.json && m.info.dbtype == "" {
+			switch d.Name() {
+			case "mysql":
+				return "json", nil
+			case "postgres":
+				return "jsonb", nil
+			}
+			return "text", nil
+		}
+		if m.info.unit != "" && m.info.dbtype == "" {
+			if d.Name() == "postgres" {
+				return "interval", nil
+			}
+			return "bigint", nil
+		}
+		size = m.info.size
+		if m.info.dbtype != "" {
+			if size > 0 && !strings.Contains(m.info.dbtype, "(") {
+				return fmt.Sprintf("%s(%d)", m.info.dbtype, size), nil
+			}
+			return m.info.dbtype, nil
+		}
+	}
+
+	t := m.typ
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "database/sql" && strings.HasPrefix(t.Name(), "Null") &&
+		t.Kind() == reflect.Struct { // the first field holds the value
+		t = t.Field(0).Type
+	}
+	switch t {
+	case netTypes[ipType], netTypes[ipnetType], netTypes[macType]:
+		if d.Name() != "postgres" {
+			return "varchar(43)", nil
+		}
+		return map[reflect.Type]string{netTypes[ipType]: "inet",
+			netTypes[ipnetType]: "cidr", netTypes[macType]: "macaddr"}[t], nil
+	}
+	if t == timeType || t == timeTType {
+		if d.Name() == "postgres" {
+			return "timestamp", nil
+		}
+		return "datetime", nil
+	}
+
+	my, pg := d.Name() == "mysql", d.Name() == "postgres"
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int8, reflect.Uint8:
+		if my {
+			return "tinyint", nil
+		}
+		return "smallint", nil
+	case reflect.Int16, reflect.Uint16:
+		return "smallint", nil
+	case reflect.Int32, reflect.Uint32:
+		return "integer", nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64,
+		reflect.Uintptr:
+		return "bigint", nil
+	case reflect.Float32:
+		return "real", nil
+	case reflect.Float64:
+		return "double precision", nil
+	case reflect.String:
+		if size > 0 {
+			return fmt.Sprintf("varchar(%d)", size), nil
+		}
+		return "text", nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if pg {
+				return "bytea", nil
+			}
+			return "blob", nil
+		}
+	}
+	return "", fmt.Errorf("cannot derive column type of %q, tag 'dbtype'", t)
+}
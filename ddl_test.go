@@ -0,0 +1,181 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DdlEvent struct {
+	ID     int64
+	At     time.Time
+	Closed *time.Time
+}
+
+func TestCreateTableTime(t *testing.T) {
+	s, err := CreateTableSQL(DdlEvent{}, "event", PostgreSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CREATE TABLE "event" (
+  "id" bigint NOT NULL,
+  "at" timestamp NOT NULL,
+  "closed" timestamp
+)`
+	if s != want {
+		t.Errorf("CreateTableSQL:\n got %s\nwant %s", s, want)
+	}
+
+	old := GetConfig()
+	defer SetConfig(old)
+	c := old
+	c.ZeroTime = ZeroTimeNull
+	SetConfig(c)
+	s, err = CreateTableSQL(&DdlEvent{}, "event", MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, "`at` datetime,") {
+		t.Errorf("time.Time column under ZeroTimeNull:\n%s", s)
+	}
+}
+
+type DdlNull struct {
+	ID    int64
+	Nick  *string
+	Score sql.NullFloat64
+	Memo  string  `db:"null"`
+	Rate  *string `db:"notnull"`
+}
+
+func TestCreateTableNull(t *testing.T) {
+	s, err := CreateTableSQL(DdlNull{}, "n", SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CREATE TABLE "n" (
+  "id" bigint NOT NULL,
+  "nick" text,
+  "score" double precision,
+  "memo" text,
+  "rate" text NOT NULL
+)`
+	if s != want {
+		t.Errorf("CreateTableSQL:\n got %s\nwant %s", s, want)
+	}
+	if _, err = CreateTableSQL((*DdlNull)(nil), "n", SQLite); err != nil {
+		t.Errorf("CreateTableSQL(nil pointer): %v", err)
+	}
+	for _, bad := range []interface{}{nil, 3} {
+		if _, err = CreateTableSQL(bad, "n", SQLite); err == nil {
+			t.Errorf("CreateTableSQL(%#v) accepted", bad)
+		}
+	}
+}
+
+type DdlUser struct {
+	ID      int64
+	Email   string     `db:"unique=uq_email where='deleted_at IS NULL'"`
+	Tenant  int        `db:"index=ix_tenant,ix_tenant_name"`
+	Name    string     `db:"index=ix_tenant_name"`
+	Deleted *time.Time `db:"col=deleted_at"`
+}
+
+type DdlBadIndex struct {
+	A int `db:"unique=ix_a"`
+	B int `db:"index=ix_a"`
+}
+
+func TestCreateIndexSQL(t *testing.T) {
+	s, err := CreateIndexSQL(&DdlUser{}, "users", PostgreSQL)
+	want := []string{
+		`CREATE INDEX "ix_tenant" ON "users" ("tenant")`,
+		`CREATE INDEX "ix_tenant_name" ON "users" ("tenant","name")`,
+		`CREATE UNIQUE INDEX "uq_email" ON "users" ("email") ` +
+			`WHERE deleted_at IS NULL`,
+	}
+	if err != nil || strings.Join(s, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CreateIndexSQL:\n got %q %v\nwant %q", s, err, want)
+	}
+	if _, err = CreateIndexSQL(DdlUser{}, "users", MySQL); err == nil {
+		t.Error("partial index for MySQL accepted")
+	}
+	if s, err = CreateIndexSQL(DdlEvent{}, "event", MySQL); err != nil ||
+		len(s) != 0 {
+		t.Errorf("no index: %q %v", s, err)
+	}
+	if _, err = CreateIndexSQL(DdlBadIndex{}, "t", SQLite); err == nil {
+		t.Error("index both unique and not accepted")
+	}
+	if _, err = CreateIndexSQL(nil, "t", SQLite); err == nil {
+		t.Error("nil stru accepted")
+	}
+}
+
+type DdlDoc struct {
+	ID   int64
+	Name string `db:"comment='it''s the name'"`
+	Tag  string
+}
+
+func TestCommentSQL(t *testing.T) {
+	s, err := CommentSQL(DdlDoc{}, "doc", PostgreSQL)
+	want := `COMMENT ON COLUMN "doc"."name" IS 'it''s the name'`
+	if err != nil || len(s) != 1 || s[0] != want {
+		t.Errorf("CommentSQL:\n got %q %v\nwant %s", s, err, want)
+	}
+	for _, d := range []Dialect{MySQL, SQLite} {
+		if s, err = CommentSQL(&DdlDoc{}, "doc", d); err != nil || s != nil {
+			t.Errorf("%s: %q %v", d.Name(), s, err)
+		}
+	}
+	c, err := CreateTableSQL(DdlDoc{}, "doc", MySQL)
+	if err != nil || !strings.Contains(c,
+		"`name` text NOT NULL COMMENT 'it\\'s the name'") {
+		t.Errorf("CreateTableSQL: %s %v", c, err)
+	}
+	if _, err = CommentSQL(nil, "doc", PostgreSQL); err == nil {
+		t.Error("nil stru accepted")
+	}
+	if _, err = CommentSQL(DdlDoc{}, "", PostgreSQL); err == nil {
+		t.Error("empty table accepted")
+	}
+}
+
+type DdlTyped struct {
+	ID    int64   `db:"pk type=serial"`
+	Code  string  `db:"type=varchar(64) notnull"`
+	Name  *string `db:"dbtype=varchar size=32"`
+	Price float64 `db:"type='numeric(10, 2)'"`
+	Raw   []byte
+}
+
+type DdlBadType struct {
+	A string `db:"dbtype=text type=varchar"`
+}
+
+func TestCreateTableType(t *testing.T) {
+	s, err := CreateTableSQL(DdlTyped{}, "app.item", PostgreSQL)
+	want := `CREATE TABLE "app"."item" (
+  "id" serial NOT NULL,
+  "code" varchar(64) NOT NULL,
+  "name" varchar(32),
+  "price" numeric(10, 2) NOT NULL,
+  "raw" bytea NOT NULL,
+  PRIMARY KEY ("id")
+)`
+	if err != nil || s != want {
+		t.Errorf("CreateTableSQL:\n got %s %v\nwant %s", s, err, want)
+	}
+	s, err = CreateTableSQL(DdlTyped{}, "item", MySQL)
+	if err != nil || !strings.Contains(s, "`raw` blob NOT NULL") {
+		t.Errorf("MySQL: %s %v", s, err)
+	}
+	if _, err = CreateTableSQL(DdlBadType{}, "t", MySQL); err == nil {
+		t.Error("conflicting dbtype and type accepted")
+	}
+	if _, err = CreateTableSQL(DdlTyped{}, "t", nil); err == nil {
+		t.Error("nil dialect accepted")
+	}
+}
@@ -0,0 +1,117 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Features 为数据库服务器的版本及其与SQL生成有关的能力。
+type Features struct {
+	Version   string // 服务器版本，如"14.5"、"8.0.33"、"10.6.12-MariaDB"
+	Numbered  bool   // 占位符为$1、$2...，否则为?
+	Returning bool   // 支持INSERT ... RETURNING
+	Upsert    string // 冲突更新语法，"ON CONFLICT"或"ON DUPLICATE KEY UPDATE"
+	Sequence  bool   // 支持序列，见NextVal
+}
+
+// DetectDialect 探测db所连接的数据库，返回对应的内置方言及服务器能力，免去
+// 手工配置。服务器由版本查询version()、sqlite_version()识别，驱动类型的包
+// 路径含"sqlite"时先查询后者；version()须为PostgreSQL、MySQL或MariaDB的版本
+// 串，其他服务器（如CockroachDB）或都无法识别时报错。
+// db 为nil时由路由函数选择读句柄，路由的结构名同ExecContext。
+func DetectDialect(ctx context.Context, db *sql.DB) (Dialect, Features,
+	error) {
+	var f Features
+	if db == nil {
+		q, err := route(ctx, nil, RouteRead, "")
+		if err != nil {
+			return nil, f, fmt.Errorf("DetectDialect: %v", err)
+		}
+		db = q.(*sql.DB)
+	}
+
+	// the driver hints the first query to try
+	queries := []string{"SELECT version()", "SELECT sqlite_version()"}
+	drv := strings.ToLower(reflect.Indirect(reflect.ValueOf(
+		db.Driver())).Type().PkgPath())
+	if strings.Contains(drv, "sqlite") {
+		queries[0], queries[1] = queries[1], queries[0]
+	}
+	var err error
+	var sqlite bool
+	for _, q := range queries {
+		if err = queryRow(ctx, db, q, nil, &f.Version); err == nil {
+			sqlite = q == "SELECT sqlite_version()"
+			break
+		}
+	}
+	if err != nil {
+		return nil, f, fmt.Errorf("DetectDialect: %v", err)
+	}
+
+	var d Dialect
+	switch v := f.Version; {
+	case sqlite:
+		d = SQLite
+		f.Returning = atleast(v, 3, 35)
+		if atleast(v, 3, 24) {
+			f.Upsert = "ON CONFLICT"
+		}
+	case strings.HasPrefix(v, "PostgreSQL "):
+		d, f.Version = PostgreSQL, strings.Fields(v)[1]
+		f.Numbered, f.Returning, f.Sequence = true, true, true
+		if atleast(f.Version, 9, 5) {
+			f.Upsert = "ON CONFLICT"
+		}
+	case mysqlish(v):
+		d, f.Upsert = MySQL, "ON DUPLICATE KEY UPDATE"
+		if strings.Contains(v, "MariaDB") {
+			f.Returning, f.Sequence = atleast(v, 10, 5), atleast(v, 10, 3)
+		}
+	default:
+		return nil, f, fmt.Errorf("DetectDialect: unknown server %q", v)
+	}
+	return d, f, nil
+}
+
+// mysqlish 报告version()的结果v是否为MySQL或MariaDB的版本串，即形如
+// "8.0.33"、"5.7.44-log"、"10.6.12-MariaDB"：以"-"之前为三段数字的版本号。
+func mysqlish(v string) bool {
+	if strings.Contains(v, "MariaDB") {
+		return true
+	}
+	num := strings.SplitN(v, "-", 2)[0]
+	parts := strings.Split(num, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	for _, p := range parts {
+		if p == "" || strings.Trim(p, "0123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// atleast 报告版本串v（如"10.6.12-MariaDB"）的主次版本号是否不低于
+// major.minor。
+func atleast(v string, major, minor int) bool {
+	n := [2]int{}
+	for i, s := range strings.SplitN(v, ".", 3) {
+		if i > 1 {
+			break
+		}
+		end := strings.IndexFunc(s, func(c rune) bool {
+			return c < '0' || c > '9'
+		})
+		if end == -1 {
+			end = len(s)
+		}
+		n[i], _ = strconv.Atoi(s[:end])
+	}
+	return n[0] > major || n[0] == major && n[1] >= minor
+}
@@ -0,0 +1,73 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func TestDetectDialectServers(t *testing.T) {
+	defer tresult("SELECT version()", nil)
+	for _, c := range []struct {
+		version string
+		want    Dialect
+	}{
+		{"PostgreSQL 14.5 on x86_64-pc-linux-gnu", PostgreSQL},
+		{"8.0.33", MySQL},
+		{"5.7.44-log", MySQL},
+		{"10.6.12-MariaDB-1:10.6.12+maria~ubu2004", MySQL},
+		{"CockroachDB CCL v23.1.11 (x86_64-pc-linux-gnu)", nil},
+		{"Microsoft SQL Server 2019", nil},
+		{"16.1", nil},
+	} {
+		tresult("SELECT version()", []string{"v"},
+			[]driver.Value{c.version})
+		d, _, err := DetectDialect(context.Background(), tdb)
+		if c.want == nil {
+			if err == nil || !strings.Contains(err.Error(), "unknown server") {
+				t.Errorf("%q: %v, %v", c.version, d, err)
+			}
+			continue
+		}
+		if err != nil || d != c.want {
+			t.Errorf("%q: %v, %v, want %v", c.version, d, err, c.want)
+		}
+	}
+}
+
+func TestDetectFeatures(t *testing.T) {
+	defer tresult("SELECT version()", nil)
+	defer tresult("SELECT sqlite_version()", nil)
+	for _, c := range []struct {
+		version, sqlite string
+		want            Features
+	}{
+		{"PostgreSQL 9.4.26 on x86_64", "",
+			Features{"9.4.26", true, true, "", true}},
+		{"PostgreSQL 16.1", "",
+			Features{"16.1", true, true, "ON CONFLICT", true}},
+		{"8.0.33", "", Features{"8.0.33", false, false,
+			"ON DUPLICATE KEY UPDATE", false}},
+		{"10.6.12-MariaDB", "", Features{"10.6.12-MariaDB", false, true,
+			"ON DUPLICATE KEY UPDATE", true}},
+		{"", "3.31.1", Features{"3.31.1", false, false, "ON CONFLICT",
+			false}},
+		{"", "3.45.0", Features{"3.45.0", false, true, "ON CONFLICT",
+			false}},
+	} {
+		if c.version != "" {
+			tresult("SELECT version()", []string{"v"},
+				[]driver.Value{c.version})
+		} else {
+			tresult("SELECT version()", nil) // no such function
+			tresult("SELECT sqlite_version()", []string{"v"},
+				[]driver.Value{c.sqlite})
+		}
+		_, f, err := DetectDialect(context.Background(), tdb)
+		if err != nil || f != c.want {
+			t.Errorf("%q%q: got %+v %v, want %+v", c.version, c.sqlite, f,
+				err, c.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package sqlaux
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect 描述不同数据库在SQL文本层面的差异，sqlaux据此生成符合该数据库规范
+// 的标识符和字面值。sqlaux内置了MySQL、PostgreSQL、SQLite三种实现，调用者也可
+// 以自行实现。
+type Dialect interface {
+	// Name 返回数据库名称，内置实现分别为"mysql"、"postgres"、"sqlite"。
+	Name() string
+	// QuoteIdent 返回加引号的标识符（表名、列名等）。
+	QuoteIdent(id string) string
+	// EscapeString 返回转义并加引号后的字符串字面值。
+	EscapeString(s string) string
+	// Placeholder 返回第n个（从1开始）参数的占位符，如"?"、"$1"。
+	Placeholder(n int) string
+	// FormatTime 返回时间t的字面值，如'2006-01-02 15:04:05'。
+	FormatTime(t time.Time) string
+}
+
+// timeLayout 为内置实现的时间字面值格式。
+const timeLayout = "2006-01-02 15:04:05.999999"
+
+// TimeLayout 不为""时，Buildstr等按该格式（time.Time.Format的layout）拼接
+// time.Time字面值，代替Dialect.FormatTime，如"2006-01-02"用于DATE列。
+// TimeZone 不为nil时，time.Time值先转为该时区再格式化，如数据库会话时区为
+// UTC而程序使用本地时间时设为time.UTC。
+//
+// Deprecated: 以SetConfig或WithTime选项设置Config.TimeLayout、TimeZone；这
+// 两个变量只在首次使用sqlaux时读取一次。
+var (
+	TimeLayout = ""
+	TimeZone   *time.Location
+)
+
+// 内置的数据库方言。
+var (
+	MySQL      Dialect = mysqlT{}
+	PostgreSQL Dialect = postgresT{}
+	SQLite     Dialect = sqliteT{}
+)
+
+type mysqlT struct{}
+
+func (mysqlT) Name() string { return "mysql" }
+
+func (mysqlT) QuoteIdent(id string) string {
+	return "`" + strings.Replace(id, "`", "``", -1) + "`"
+}
+
+// mysqlEscaper 与mysql_real_escape_string()的转义规则一致。
+var mysqlEscaper = strings.NewReplacer("\\", "\\\\", "'", "\\'", "\"", "\\\"",
+	"\x00", "\\0", "\n", "\\n", "\r", "\\r", "\x1a", "\\Z")
+
+func (mysqlT) EscapeString(s string) string {
+	return "'" + mysqlEscaper.Replace(s) + "'"
+}
+
+func (mysqlT) Placeholder(n int) string { return "?" }
+
+func (d mysqlT) FormatTime(t time.Time) string {
+	return d.EscapeString(t.Format(timeLayout))
+}
+
+// postgresT 假设standard_conforming_strings为on（9.1起的默认值）。
+type postgresT struct{}
+
+func (postgresT) Name() string { return "postgres" }
+
+func (postgresT) QuoteIdent(id string) string {
+	return `"` + strings.Replace(id, `"`, `""`, -1) + `"`
+}
+
+func (postgresT) EscapeString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func (postgresT) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (d postgresT) FormatTime(t time.Time) string {
+	return d.EscapeString(t.Format(timeLayout))
+}
+
+type sqliteT struct{ postgresT }
+
+func (sqliteT) Name() string { return "sqlite" }
+
+func (sqliteT) Placeholder(n int) string { return "?" }
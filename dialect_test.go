@@ -0,0 +1,82 @@
+package sqlaux
+
+import (
+	"testing"
+	"time"
+)
+
+type DialRow struct {
+	ID   int
+	Note string
+	At   time.Time
+}
+
+func TestDialect(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	tests := []struct {
+		d                 Dialect
+		ident, str, ph, s string
+	}{
+		{MySQL, "`a``b`", `'it\'s\\ \"x\"\n'`, "?",
+			`SET id=1,note='it\'s',at='2024-01-02 03:04:05.6'`},
+		{PostgreSQL, `"a""b"`, `'it''s\ "x"` + "\n'", "$2",
+			`SET id=1,note='it''s',at='2024-01-02 03:04:05.6'`},
+		{SQLite, `"a""b"`, `'it''s\ "x"` + "\n'", "?",
+			`SET id=1,note='it''s',at='2024-01-02 03:04:05.6'`},
+	}
+	for _, tt := range tests {
+		d := tt.d
+		id := "a" + tt.ident[:1] + "b"
+		if s := d.QuoteIdent(id); s != tt.ident {
+			t.Errorf("%s QuoteIdent: got %s, want %s", d.Name(), s, tt.ident)
+		}
+		if s := d.EscapeString("it's\\ \"x\"\n"); s != tt.str {
+			t.Errorf("%s EscapeString: got %s, want %s", d.Name(), s, tt.str)
+		}
+		if s := d.Placeholder(2); s != tt.ph {
+			t.Errorf("%s Placeholder: got %s, want %s", d.Name(), s, tt.ph)
+		}
+		s, err := With(WithDialect(d)).Buildstr(&DialRow{1, "it's", at})
+		if err != nil || s != tt.s {
+			t.Errorf("%s Buildstr:\n got %s %v\nwant %s", d.Name(), s, err,
+				tt.s)
+		}
+	}
+	s, _ := Buildstr(&DialRow{1, "it's", at})
+	if want := `SET id=1,note="it's",at="2024-01-02 03:04:05.6"`; s != want {
+		t.Errorf("no dialect:\n got %s\nwant %s", s, want)
+	}
+}
+
+type DialEvent struct {
+	At   time.Time
+	Till *time.Time
+}
+
+func TestTimeLiteral(t *testing.T) {
+	at := time.Date(2024, 1, 2, 23, 4, 5, 0, time.UTC)
+	e := &DialEvent{At: at}
+	loc := time.FixedZone("X", 8*3600)
+	tests := []struct {
+		c    Config
+		want string
+	}{
+		{With(), `SET at="2024-01-02 23:04:05",till=NULL`},
+		{With(WithTime("2006-01-02", nil)), `SET at="2024-01-02",till=NULL`},
+		{With(WithTime("", loc)), `SET at="2024-01-03 07:04:05",till=NULL`},
+		{With(WithTime("2006-01-02", loc), WithDialect(PostgreSQL)),
+			`SET at='2024-01-03',till=NULL`},
+		{With(WithDialect(MySQL)), `SET at='2024-01-02 23:04:05',till=NULL`},
+	}
+	for i, tt := range tests {
+		if s, err := tt.c.Buildstr(e); err != nil || s != tt.want {
+			t.Errorf("%d:\n got %s %v\nwant %s", i, s, err, tt.want)
+		}
+	}
+	e.Till = &at
+	s, err := Buildstr(e)
+	if want := `SET at="2024-01-02 23:04:05",till="2024-01-02 23:04:05"`; err !=
+		nil || s != want {
+		t.Errorf("pointer:\n got %s %v\nwant %s", s, err, want)
+	}
+}
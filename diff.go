@@ -0,0 +1,81 @@
+package sqlaux
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Change 为两个结构变量间一个映射字段的差异。Field 为字段名（嵌套结构成员为
+// 全名），Column 为列名，Old、New 分别为字段在两个变量中的值。
+type Change struct {
+	Field  string
+	Column string
+	Old    interface{}
+	New    interface{}
+}
+
+// Diff 按映射逐一比较a、b的所有映射字段（含计算列），返回值不同的字段，顺序
+// 同字段定义顺序，用于审计日志、条件更新（如以差异字段作Buildstr的field参数）
+// 和测试断言等。time.Time按时刻比较，其它按reflect.DeepEqual比较。
+//
+// 约定：a、b的类型相同，形如*struct。
+func Diff(a, b interface{}) ([]Change, error) {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() != reflect.Ptr || va.Elem().Kind() != reflect.Struct ||
+		va.IsNil() || vb.Type() != va.Type() || vb.IsNil() {
+		return nil, fmt.Errorf("Diff: a, b not like the same *struct")
+	}
+	stru := va.Type().Elem().Name()
+	mapping, err := automap(loadmap(), va.Type().Elem())
+	if err != nil {
+		return nil, fmt.Errorf("Diff: %v", err)
+	}
+	e, ok := mapping[stru]
+	if !ok {
+		return nil, fmt.Errorf("Diff: %q has no mapping", stru)
+	}
+
+	var cs []Change
+	for _, n := range e.name.([]string) {
+		m := mapping["0."+stru+"."+n]
+		x := reflect.NewAt(m.typ, m.addr(va.UnsafePointer())).Elem()
+		y := reflect.NewAt(m.typ, m.addr(vb.UnsafePointer())).Elem()
+		if equal(x, y) {
+			continue
+		}
+		cs = append(cs, Change{n, m.name.(string),
+			fieldOf(va.Elem(), n).Interface(),
+			fieldOf(vb.Elem(), n).Interface()})
+	}
+	return cs, nil
+}
+
+// equal 报告同类型的字段值x、y是否相等，time.Time（及其等价类型）按时刻比较。
+func equal(x, y reflect.Value) bool {
+	if x.Type() == timeType || x.Type() == timeTType {
+		return x.Convert(timeType).Interface().(time.Time).Equal(
+			y.Convert(timeType).Interface().(time.Time))
+	}
+	return reflect.DeepEqual(x.Interface(), y.Interface())
+}
+
+// fieldOf 返回结构值v中名为n（嵌套结构成员为全名）的字段。嵌套结构为指针时
+// 经由其所指的结构，指针为nil时返回字段的零值。
+func fieldOf(v reflect.Value, n string) reflect.Value {
+	for _, f := range strings.Split(n, ".") {
+		if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+			if v.IsNil() {
+				v = reflect.Zero(v.Type().Elem())
+			} else {
+				v = v.Elem()
+			}
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		v = v.FieldByName(f)
+	}
+	return v
+}
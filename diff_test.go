@@ -0,0 +1,82 @@
+package sqlaux
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type DiffAudit struct {
+	By string
+	At time.Time
+}
+
+type diffDoc struct {
+	ID int `db:"pk"`
+	*DiffAudit
+}
+
+func TestDiffEmbeddedPointer(t *testing.T) {
+	if err := MapStruct(diffDoc{}); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := Diff(&diffDoc{DiffAudit: &DiffAudit{By: "x"}},
+		&diffDoc{DiffAudit: &DiffAudit{By: "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 1 || cs[0].Field != "DiffAudit.By" || cs[0].Old != "x" ||
+		cs[0].New != "y" {
+		t.Fatalf("got %+v", cs)
+	}
+
+	// a nil pointer compares as zero values
+	cs, err = Diff(&diffDoc{}, &diffDoc{DiffAudit: &DiffAudit{By: "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 1 || cs[0].Old != "" || cs[0].New != "y" {
+		t.Fatalf("got %+v", cs)
+	}
+}
+
+func TestByTimeEmbeddedPointer(t *testing.T) {
+	r := ByTime("DiffAudit.At", "200601")
+	at := time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)
+	p, err := r("docs", &diffDoc{DiffAudit: &DiffAudit{At: at}})
+	if err != nil || p != "docs_202103" {
+		t.Fatalf("got %q, %v", p, err)
+	}
+	if p, err = r("docs", &diffDoc{}); err != nil || p != "docs_000101" {
+		t.Fatalf("nil pointer: got %q, %v", p, err)
+	}
+}
+
+type DiffUser struct {
+	ID    int `db:"pk"`
+	Name  string
+	Tags  []string
+	Since time.Time `db:"col=since_at"`
+}
+
+func TestDiff(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := &DiffUser{1, "a", []string{"x"}, at}
+	b := &DiffUser{1, "b", []string{"x", "y"},
+		at.In(time.FixedZone("X", 3600))}
+	cs, err := Diff(a, b)
+	want := []Change{{"Name", "name", "a", "b"},
+		{"Tags", "tags", []string{"x"}, []string{"x", "y"}}}
+	if err != nil || !reflect.DeepEqual(cs, want) {
+		t.Errorf("Diff:\n got %+v %v\nwant %+v", cs, err, want)
+	}
+	if cs, err = Diff(a, a); err != nil || cs != nil {
+		t.Errorf("Diff of equal values: %+v %v", cs, err)
+	}
+	if _, err = Diff(a, &diffDoc{}); err == nil {
+		t.Error("Diff of different types accepted")
+	}
+	if _, err = Diff(*a, *b); err == nil {
+		t.Error("Diff of struct values accepted")
+	}
+}
@@ -21,8 +21,9 @@ SQLAUX supports two kinds of mappings:
 1. name map: lowercase(column name)!=lowercase(field name). It is required.
 SQLAUX identifies the corresponding relationship between fields and column
 names through struct tag. By default, all exported field names (including
-nested structure members except time. time) are lowercased as their
-corresponding DB table column names.
+nested structure members except time.Time, sql.Scanner and sqlaux builtin
+equivalent types) are lowercased as their corresponding DB table column
+names.
 
 2. type map: field type cannot be directly used for DB I/O. It is optional.
 In general, fields should use custom types at this point, and implement the
@@ -43,18 +44,116 @@ The three export variables are for struct tag, used to identify the DB
 column names. Tag is tag name; Key is key of column name; Op is key-value
 separator. eg, `db:"col=xxx yyy=zzz"`
 
+Besides the column name, a field tag may carry options: "size=N" declares
+the max character length, "dbtype=T" (or "type=T") declares the DB column
+type. Values containing spaces can be single quoted, eg.
+`db:"check='age >= 0'"`, with a single quote inside written twice.
+A field tagged "check=expr" has the column constraint CHECK (expr) in
+CreateTableSQL. When Validate is true, simple constraints on the column
+itself, ie. comparisons with number or string literals and [NOT] IN lists
+joined by AND, such as 'age >= 0 AND age < 200' or
+'status IN (''a'',''b'')', are also checked before building; others are
+left to the database.
+A field tagged "omitempty" is left out of the SET form built by Buildstr,
+Buildargs and BuildUpdateByPK when it holds the zero value, for partial
+updates; WithOmitEmpty (Config.OmitEmpty) does so for all fields.
+An interface{} field tagged "defer" receives the raw driver value in Scan,
+to be decoded later by Decode or application logic, and its dynamic value
+is rendered by Buildstr.
+For MySQL, "charset=CS" and "collate=CO" make string literals rendered as
+_CS'...' COLLATE CO, and column definitions carry the same attributes, so
+mixed-charset schemas compare correctly.
+A string field tagged "geo" or "geo=SRID" holds a WKT geometry. It is
+rendered as ST_GeomFromText('WKT',SRID) (GeomFromText for SQLite), and Scan
+accepts MySQL internal format, PostGIS hex EWKB, WKB or WKT, converting
+them to WKT.
+A time.Duration field is stored as an integer in the unit of its "unit="
+tag (ns, us, ms, s; default ns), or as INTERVAL for PostgreSQL. Scan also
+accepts floats, PostgreSQL interval text and Go duration text.
+A pointer field such as *int, *string or *time.Time receives NULL as nil
+and allocates for other values; it is written as NULL when nil.
+A bool field accepts booleans, integers (eg. TINYINT(1)), single-byte
+[]byte (eg. BIT(1)) and texts like 't'/'f', 'yes'/'no', 'on'/'off'.
+net.IP, net.IPNet and net.HardwareAddr fields are stored as their text
+forms, ie. PostgreSQL INET, CIDR, MACADDR or string columns elsewhere.
+NetWithin and NetContains render containment conditions on them.
+A slice, map, pointer, struct or array field tagged "json" is stored as
+JSON text, eg. a []Child field as a JSON array column; nil is NULL.
+A field tagged "scan_default=V" receives V after Scan when its column is
+absent from the result set or NULL, eg. for feature flags and newly added
+columns.
+A nested struct field tagged "override='Field:col,Inner.Field:col2'"
+overrides the column names of its members, so a reused embedded struct
+(eg. AuditFields) can map to different columns in different parents. The
+outer override wins; overriding an unknown member is an error.
+A nested struct field tagged "prefix=P" maps its members' columns with the
+prefix P, eg. Address fields to addr_street, addr_city. Prefixes of nested
+levels are concatenated; an override is only prefixed by the outer levels.
+A nested struct may be a pointer (eg. an embedded *AuditFields shared by
+many structs): Scan and other writers allocate it when nil, and Buildstr
+and other readers take the zero values of its members when nil. A pointer
+back to an enclosing struct type (eg. a list node) is not nested.
+A field tagged "pk" is (part of) the primary key.
+A field tagged "optional" maps a column that may not exist yet, eg. during a
+rolling deployment against an older schema: Scan leaves the field untouched
+when the column is absent and receives it when present, and CheckColumns
+does not report it missing. A primary key cannot be optional.
+A field tagged "alias=a,b" is also scanned from columns named a or b, eg.
+`db:"col=email alias=mail"` during a column rename; Buildstr and the other
+builders always use the canonical column name.
+A slice field tagged "child=table:fk:col" is not a column: its elements are
+rows (pk, element) of the child table, see SaveChildren.
+A field tagged "ref=table.col" is a foreign key referencing that column:
+CreateTableSQL adds a FOREIGN KEY constraint for it, and CheckOrphans finds
+rows whose referenced row is missing.
+A field tagged "comment=text" carries a description of its column into the
+DDL: a COMMENT attribute in CreateTableSQL for MySQL, or a COMMENT ON COLUMN
+statement from CommentSQL for other databases.
+An integer field tagged "seq=name" takes its value from that sequence by
+AssignSeq.
+
+var Validate = false
+
+When Validate is true, Buildstr etc. check field values against the tag
+declared constraints (eg. size) before building, so violations are caught
+before the driver round trip.
+
+var Deterministic = false
+
+When Deterministic is true, Buildstr and all the other builders render
+byte-identical SQL for identical inputs across runs, for statement caches
+and golden tests: fields always in mapping order, keyed items always sorted
+by key, time.Time values converted to UTC before formatting (independent of
+the local time zone), and values without a deterministic literal (their Go
+syntax may contain pointer addresses) rejected.
+
+Mappings are published as read-only snapshots, so MapStruct and MapType may
+be called at any time, eg. by plugins or tests; so may the other registration
+functions (MapTable, MapHistory, MapPartition, Use, SetRouter, Observe, OnTx).
+Setting Config.InitOnly to true restores the strict mode: all of them must
+be called in init().
+Otherwise every function taking a struct, its pointer or a slice of them
+(Scan and its variants, the Build* family, Selectstr(As), Clone, Diff, Hash,
+ToMap, FromMap, the DDL generators etc.) maps an unmapped named struct
+automatically on first use; explicit MapStruct still checks tags at startup.
+Functions that need a registered table or history still require MapTable or
+MapHistory.
+
 func MapStruct(stru ...interface{}) error
 
 MapStruct establishes name mappings between Go struct and DB table. The
-caller needs to run it for each struct in init() for explicit mapping.
+caller usually runs it for each struct in init() for explicit mapping, but
+it is safe to call at any time and concurrently (unless Config.InitOnly).
+When any of stru fails, none is mapped.
 stru is struct needs to be mapped and takes the form of a variable value,
 which can be zero value.
 
 func MapType(orig, self interface{}) error
 
 MapType establishes type mappings for Go struct and DB table. The caller
-needs to call this in init() for each case, where the Go primitive type is
-used in the program and the custom type is used for DB I/O.
+calls this (usually in init()) for each case, where the Go primitive type is
+used in the program and the custom type is used for DB I/O. Like MapStruct
+it is safe at any time; cached Scan plans are dropped.
 orig, self are primitive and custom type values, respectively, and you can
 use zero values.
 sql.Scanner receiver is pointer type, driver.Valuer receiver is value type.
@@ -66,7 +165,9 @@ Scan receives all the results of the current result set (that is, a single
 SELECT) from rows where the query has been executed, overwriting dest with
 the results. Scan does not actively close rows after receiving.
 Convention:
-	● Each dest type takes the form of *[]*struct.
+	● Each dest type takes the form of *[]*struct, or *struct which receives
+	only the first row; an empty result set then returns sql.ErrNoRows
+	(test with errors.Is) and leaves every dest untouched.
 	● Table by table when SELECT columns, the tables are optionally
 	separated by '' empty columns. When two tables have duplicate names at
 	the "junction", SQLAUX treats them as columns of the previous table by
@@ -86,5 +187,859 @@ Convention:
 	is, prefix its parent struct names except the outermost.
 Note: Buildstr does not limit the length of the result string, and callers
 need to prevent SQL statements from getting too long.
+
+func BuildstrTo(w io.Writer, data interface{}, field ...string) error
+
+BuildstrTo is the same as Buildstr, but streams the result to w instead of
+materializing the whole string in memory, eg. when generating huge import
+scripts. The first write error of w stops the building and is returned.
+Callers may buffer w by themselves.
+
+func Buildcol(data interface{}, column ...string) (string, error)
+
+Buildcol is like Buildstr, but renders values strictly in the caller-given
+column order, so hand-prepared statements such as "INSERT INTO t (a,b,c)"
+and sqlaux values can't drift apart. The result takes the form:
+"VALUES (val1,val2,...),..."
+Convention:
+	● The data type is like []*struct or *struct.
+	● column are DB column names (case-insensitive). They must be mapped,
+	non-empty and not duplicated.
+
+type Dialect interface
+
+Dialect describes SQL text differences of databases: identifier quoting,
+string escaping, placeholders (Placeholder(n), eg. "?" or "$1") and time
+literals (FormatTime). Builtin MySQL, PostgreSQL and SQLite are provided,
+callers can implement their own. Buildstr renders literals by the Dialect
+of the configuration (see Config), package-level or per call.
+
+func DumpInsertScript(w io.Writer, data interface{}, opts ScriptOpts) error
+
+DumpInsertScript writes a complete, transaction-wrapped SQL script of data
+into w for DBAs to apply offline. The data type is like []*struct or
+*struct. Rows are split into INSERT statements of opts.Batch rows
+(Config.ScriptBatch, default 500, if not positive), and literals are escaped
+by opts.Dialect. A schema-qualified opts.Table ("schema.table") is quoted
+part by part.
+
+func Stats() Statistics
+
+Stats reports the runtime statistics of sqlaux: hits and misses of the Scan
+plan cache, hits and misses of the prepared statements of all StmtCaches
+(StmtHitRate), number of mapped structs and per-struct column counts. It
+helps to tune Config.PlanCacheSize (the plan cache bound, default 1024, <=0
+disables it) and the StmtCache size, and detect misses caused by dynamic
+SQL.
+
+func CreateTableSQL(stru interface{}, table string, d Dialect) (string, error)
+
+CreateTableSQL generates a CREATE TABLE statement from the mapping of stru;
+an empty table means the registered one (see Tablename). Column types come
+from the "dbtype=" (with "size=") tag or its short form "type=", eg.
+`db:"type=varchar(64) notnull"`, or are derived from the field types.
+Pointer and sql.Null* fields are nullable, others are NOT NULL, consistent
+with Scan; tag "null" or "notnull" overrides that. Fields tagged "pk" make
+up the PRIMARY KEY.
+
+func Decode(src, dest interface{}) error
+
+Decode converts the raw DB value src (eg. received by a "defer" field) and
+stores it into dest, which must be a non-nil pointer. sql.Scanner dest is
+honored, otherwise common conversions among numbers, bools, strings,
+[]byte and time.Time apply.
+
+var (
+	ZeroTime      = ZeroTimeKeep
+	ZeroTimeValue time.Time
+)
+
+ZeroTime is the policy for zero time.Time values: ZeroTimeKeep writes them
+as is; ZeroTimeNull writes NULL; ZeroTimeSkip omits them from SET and
+writes DEFAULT in VALUES; ZeroTimeError refuses to write them;
+ZeroTimeSentinel writes ZeroTimeValue instead. Reversely, except for Keep
+and Error, Scan receives NULL (and the sentinel) as zero time.
+
+func ForUpdate(d Dialect, wait LockWait) string
+func ForShare(d Dialect, wait LockWait) string
+
+ForUpdate and ForShare return the locking clause of d, eg.
+" FOR UPDATE SKIP LOCKED", to be appended to a SELECT or passed as the lock
+argument of BuildSelect and Get for worker-queue patterns. wait is
+LockWaiting, LockNoWait or LockSkipLocked. SQLite has no row locks, "" is
+returned; a nil d gives the standard syntax, so a forgotten dialect never
+silently drops the lock.
+
+func Selectstr(stru interface{}, field ...string) (string, error)
+
+Selectstr generates the SELECT column list "col1,col2,..." of stru's field,
+keeping SELECT statements in sync with the mapping. By default, all mapped
+fields are listed. A computed field tagged "expr=E" (eg. a window function)
+is rendered as "E AS col", so it can be received by Scan directly. Computed
+fields are excluded from writing.
+
+func SelectstrAs(alias string, stru interface{}, field ...string) (string, error)
+
+SelectstrAs is the same as Selectstr, but prefixes every column with the
+table alias "alias.", for joins. Expressions of computed fields are not
+prefixed.
+
+func Union(all bool, dest interface{}, part ...Fragment) (Fragment, error)
+
+Union joins SELECT statements with UNION (UNION ALL if all), merging their
+arguments and renumbering PostgreSQL $n placeholders. If dest is not nil,
+the result columns are validated against the mapping of dest, which can be
+a struct value, pointer or the Scan dest like &[]*T. Parts can't have
+ORDER BY or LIMIT, append them to the result instead.
+
+func Sample(d Dialect, table string, size float64, percent bool) (string, error)
+
+Sample returns a FROM item randomly sampling table: size percent of rows if
+percent, or size rows otherwise. PostgreSQL percent sampling uses
+TABLESAMPLE SYSTEM (block level, fast but coarse); elsewhere a subquery
+ordered or filtered by the random function is used, which scans the whole
+table; it is aliased by the table name without schema. With a nil d the
+table name is not quoted and RANDOM() is used.
+
+var CheckDialect Dialect
+func CheckIdent(d Dialect, id string) error
+
+CheckIdent checks identifier id against the limits of d: length (63 bytes
+for PostgreSQL, 64 characters for MySQL) and reserved words, as sqlaux
+doesn't quote column names. If CheckDialect is not nil, MapStruct checks all
+mapped columns with it, failing fast at init time instead of at the first
+statement.
+
+type Querier interface
+type Middleware func(ctx context.Context, query string, args []interface{}) (string, []interface{}, error)
+func Use(mw ...Middleware) error
+func ExecContext(ctx context.Context, db Querier, query string, args ...interface{}) (sql.Result, error)
+
+Querier is satisfied by *sql.DB, *sql.Tx and *sql.Conn. Use registers SQL
+rewriting middleware; like MapStruct it may be called at any time unless
+Config.InitOnly is set, affecting statements started later. Every statement
+executed by sqlaux, eg. by ExecContext, goes through the middleware chain in
+registration order first, so users can inject hints, comments or routing
+directives. A middleware error aborts the execution.
+
+func Annotate(app string, trace func(ctx context.Context) string) Middleware
+
+Annotate returns a middleware appending a sqlcommenter comment to
+statements, with content like
+"application='app',file='main.go%3A12',traceparent='...'", making database
+side logs attributable. app is the application name,
+omitted if empty; file is the caller of sqlaux; trace, if not nil, extracts
+the W3C traceparent from ctx. Statements already ending with a comment are
+left alone.
+
+type RouteOp int
+func SetRouter(r func(ctx context.Context, op RouteOp, stru string) *sql.DB) error
+func RouteStruct(ctx context.Context, stru interface{}) context.Context
+
+SetRouter sets the database router r, at any time unless Config.InitOnly
+is set. When the db argument of sqlaux execution helpers (eg. ExecContext)
+is nil, r selects the handle by operation type op (RouteRead or RouteWrite)
+and the struct name stru, eg. reads to replicas and writes to the primary.
+Functions taking a struct (SaveHistory, AssignSeq etc.) route by its name,
+QueryContext by the name of its only dest; the others (ExecContext, WithTx,
+WithAdvisoryLock, NextVal, Guard, DetectDialect) by the name carried in ctx
+by RouteStruct, or "" without one.
+
+var MaxRows = 0
+type RowLimitError struct{ Max int }
+func Guard(ctx context.Context, db Querier, max int, query string, args ...interface{}) error
+
+If MaxRows > 0, Scan stops and returns a wrapped *RowLimitError as soon as
+the result exceeds MaxRows rows, protecting services from scanning entire
+tables into memory. Guard pre-checks a SELECT by probing
+"SELECT COUNT(*) FROM (query LIMIT max+1)", returning *RowLimitError if the
+result would exceed max rows. query must not end with a LIMIT clause.
+
+func ScanReduce(rows *sql.Rows, seed interface{}, fn interface{}) (interface{}, error)
+
+ScanReduce folds the rows one by one into an accumulator by fn, which is
+like func(A, *T) (A, error), starting from seed of type A (nil for zero
+value). The result slice is never retained, so sums, maps or top-K can be
+computed over large result sets with constant memory. An error returned by
+fn stops the scanning. It doesn't close rows.
+
+func Signature(stru ...interface{}) ([]string, error)
+func CheckColumns(rows *sql.Rows, stru ...interface{}) error
+
+Signature returns all mapped columns of the structs, separated by "", ie.
+the result columns of a SELECT listing them table by table. CheckColumns
+verifies rows.Columns() against the signature before Scan (delimiters are
+optional, table prefixes are ignored), reporting missing, unexpected and
+misordered columns, and columns which Scan would receive into the wrong
+struct because of the junction name clash.
+
+func FieldNames(stru interface{}) ([]string, error)
+
+FieldNames returns all mapped field names of stru, ie. the valid field
+arguments of Buildstr etc., nested ones in full like "Inner.Field". Errors
+about unknown fields or columns suggest the closest valid name.
+
+func FromMap(dest interface{}, m map[string]interface{}) error
+
+FromMap assigns values of the column keyed m into the fields of dest, which
+is like *struct, for data from CSV, message queues or other dynamic sources.
+Values are received as by Scan (sql.Scanner, json, scan_default etc.), or
+converted by the rules of Decode; non-text values of json fields are
+received by their JSON encoding. Keys are case insensitive and may have a
+table prefix; an unmapped key is an error.
+
+func ToMap(data interface{}, field ...string) (map[string]interface{}, error)
+
+ToMap dumps field of data, which is like *struct, into a column keyed map
+of driver values, handled the same as Buildstr (driver.Valuer, json, zero
+time policy etc.) except that geo fields are WKT texts. By default, all
+mapped fields except computed ones are dumped.
+
+type Change struct{ Field, Column string; Old, New interface{} }
+func Diff(a, b interface{}) ([]Change, error)
+
+Diff compares all mapped fields (computed ones included) of a and b, which
+are of the same type like *struct, returning those differing in field
+order, for audit logs, conditional updates (eg. Buildstr of the changed
+fields) and test assertions. time.Time values are compared as instants,
+others by reflect.DeepEqual.
+
+func Clone(data interface{}) (interface{}, error)
+
+Clone copies all mapped fields (computed ones included) of data, which is
+like *struct, into a new variable of the same type via the precomputed
+offsets. Slices, maps, pointers and interfaces are deep copied, so the
+clone can serve as a snapshot for Diff. Unmapped fields are not copied and
+nil embedded struct pointers stay nil in the clone; cyclic values are not
+supported.
+
+func Hash(data interface{}, field ...string) (string, error)
+
+Hash returns the hex SHA-256 checksum over the "column=value" strings of
+field of data, which is like *struct. Values are rendered as by Buildstr,
+so the hash depends on the database values rather than Go types, enabling
+change detection, ETL deduplication and sync jobs. By default, all mapped
+fields except computed ones are hashed; the field order matters. The
+rendering ignores the global configuration: time.Time values are hashed in
+UTC with microseconds and zero times as is, so the same data hashes the same
+in every process and time zone.
+
+func ScanUnique(rows *sql.Rows, dest ...interface{}) error
+
+ScanUnique is the same as Scan, but deduplicates structs having primary key
+fields (tagged "pk") by their keys, keeping the first occurrence only. So
+when joins produce the same parent row repeatedly, each entity is received
+once. Structs without primary key are not deduplicated.
+
+func ScanPivot(rows *sql.Rows, dest interface{}) error
+
+ScanPivot pivots a key/value shaped result set (EAV or settings tables)
+into one struct per entity, appending to dest which is like *[]*struct.
+The key and value columns are declared by a blank field of the struct, eg.
+_ struct{} `db:"pivot=name:value"`. Other columns are entity columns and
+must be mapped; rows with the same primary key (tagged "pk") belong to the
+same entity. The key column names the mapped column receiving the value,
+converted by the rules of FromMap; unmapped keys are ignored.
+
+func ScanAlias(rows *sql.Rows, alias map[string]string, dest ...interface{}) error
+
+ScanAlias is the same as Scan, but receives result columns by the call
+site alias map (result column to mapped column, case insensitive), for
+expressions that shouldn't be globally mapped. Besides, any expression
+column aliased to a mapped column name, eg. "COUNT(*) AS headcount", is
+received by Scan etc. directly.
+
+type Mapping struct{ ... }
+func MapAdhoc(stru interface{}) (Mapping, error)
+func (m Mapping) Scan(rows *sql.Rows, dest ...interface{}) error
+func (m Mapping) Buildstr(data interface{}, field ...string) (string, error)
+func (m Mapping) BuildstrTo(w io.Writer, data interface{}, field ...string) error
+
+MapAdhoc builds a standalone mapping of stru for one-off report structs.
+Unlike MapStruct, it can be called at any time and doesn't touch the global
+registry. The methods of Mapping are the same as the package functions, but
+use the standalone mapping. A Mapping is safe for concurrent use.
+
+type MappingEntry struct{ Struct, Field, Column string; Offset uintptr; Type, Options string }
+func DumpMapping(w io.Writer) error
+func LoadMapping(r io.Reader) ([]MappingEntry, error)
+
+DumpMapping writes the global registry as JSON, one entry per mapped field
+(struct, field, column, offset, type used for reading and writing, tag
+options), sorted by struct name and field order, so users can inspect
+exactly how sqlaux interpreted their structs. LoadMapping reads it back,
+eg. to compare mappings between versions or environments.
+
+func ParseTag(tags string) (map[string]string, error)
+func SelectColumns(query string) ([]string, error)
+
+ParseTag parses a field tag value by the rules of sqlaux, and SelectColumns
+returns the result column names of a SELECT, for static analysis tools.
+Package vet and command sqlauxvet check Go sources using sqlaux at CI time,
+reporting tag syntax errors, duplicate columns, structs used with sqlaux
+but never mapped, and SELECT statements not matching the Scan dest.
+
+func vet.Queries(dirs ...string) ([]Diagnostic, error)
+
+Command sqlauxcheck (vet.Queries) cross-checks the SQL string literals and
+constants of a codebase against its mappings: every column selected by a
+simple SELECT or listed by an INSERT must be mapped by some struct, or
+Scan and friends will fail at runtime.
+
+func MapHistory(stru interface{}, table, history string) error
+func SaveHistory(ctx context.Context, db Querier, stru interface{}, where string, args ...interface{}) (int64, error)
+func ExecHistory(ctx context.Context, db *sql.DB, stru interface{}, where string, wargs []interface{}, query string, args ...interface{}) (sql.Result, error)
+
+MapHistory registers the table of a mapped struct together with its
+isomorphic history table; like MapStruct it may be called at any time.
+SaveHistory copies the rows matching where into the history table with
+INSERT ... SELECT over the writable mapped columns; run it in the
+transaction of the following UPDATE or DELETE. ExecHistory does both in one
+transaction of its own, rolling back on any error.
+
+func WithTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error
+func OnTx(h ...TxHook) error
+func SetLocal(settings func(ctx context.Context) map[string]string) TxHook
+
+WithTx runs fn in a transaction, committing when fn returns nil and rolling
+back on error or panic. Right after BEGIN it calls the hooks registered with
+OnTx (at any time unless Config.InitOnly is set). SetLocal makes a
+PostgreSQL hook that sets transaction local session variables through
+set_config(name, value, true), so that Row Level Security policies reading
+current_setting() see e.g. the tenant of the context.
+
+func WithAdvisoryLock(ctx context.Context, db *sql.DB, d Dialect, key string, fn func(tx *sql.Tx) error) error
+
+WithAdvisoryLock runs fn by WithTx while holding the application level
+advisory lock named key: pg_advisory_xact_lock (key hashed to bigint by
+FNV-1a) for PostgreSQL, GET_LOCK/RELEASE_LOCK on one connection for MySQL.
+It waits for the lock until ctx is done. Other dialects are not supported.
+
+func NextVal(ctx context.Context, db Querier, d Dialect, sequence string) (int64, error)
+func AssignSeq(ctx context.Context, db Querier, d Dialect, data interface{}) error
+
+NextVal fetches the next value of a sequence: nextval() for PostgreSQL,
+NEXTVAL() for MySQL (MariaDB 10.3+), and sequence.NEXTVAL for a custom
+dialect named "oracle". Tag an integer field with "seq=name" and AssignSeq
+fills its zero values in data ([]*struct or *struct) from the sequence, so
+keys are known before the INSERT on databases without RETURNING.
+
+type PartitionResolver func(table string, row interface{}) (string, error)
+func MapPartition(stru interface{}, r PartitionResolver) error
+func Partition(table string, row interface{}) (string, error)
+func Partitions(table string, data interface{}) (map[string]interface{}, error)
+func ByTime(field, layout string) PartitionResolver
+
+MapPartition registers a resolver picking the partition table of a row, so
+writes can go to the partition directly; like MapStruct it may be called at
+any time. Partition resolves
+one *struct row (the table itself when no resolver); Partitions groups a
+[]*struct by partition, keeping the row order. ByTime resolves to
+"table_" + field.Format(layout), eg. monthly partitions with "200601".
+
+type Features struct{ Version string; Numbered, Returning bool; Upsert string; Sequence bool }
+func DetectDialect(ctx context.Context, db *sql.DB) (Dialect, Features, error)
+
+DetectDialect sniffs the server behind db (driver package path, then
+version() or sqlite_version()) and returns the builtin Dialect with the
+server version and capabilities: $n placeholders, INSERT ... RETURNING,
+upsert syntax and sequences, instead of configuring them by hand. Only
+SQLite, PostgreSQL, MySQL and MariaDB are recognized; any other server
+reports an "unknown server" error rather than being taken for MySQL.
+
+func Join(sep string, part ...Fragment) Fragment
+func (f Fragment) Rebase(start int) Fragment
+func (f Fragment) Compact() Fragment
+
+Join concatenates fragments built independently, each numbering its
+PostgreSQL $n placeholders from $1, re-basing them by the preceding
+arguments. Rebase renumbers f to start at $start. Compact passes an equal
+argument (bool, number, string, []byte, time.Time) only once, pointing all
+its placeholders to the same $n; PostgreSQL infers a parameter type from
+its first use, so add casts where the contexts differ.
+
+func SaveChildren(ctx context.Context, db Querier, d Dialect, data interface{}) error
+func LoadChildren(ctx context.Context, db Querier, d Dialect, dest interface{}) error
+
+For the classic one-to-many value list, eg. Tags []string tagged
+"child=post_tags:post_id:tag", SaveChildren replaces the child table rows of
+each struct in data (deleting the old ones, then inserting (pk, element)
+rows); call it in the transaction of the parent INSERT or UPDATE.
+LoadChildren reassembles the slices of dest from the child table, in the
+order of the database. The struct must have exactly one "pk" field.
+
+func CheckSelect(query string, dest ...interface{}) error
+
+CheckSelect verifies before execution that the columns of a SELECT can be
+scanned into dest by the Scan convention: no more '' separated groups than
+dest, and each column mapped in the current or the successive struct. It
+diagnoses every bad column precisely: belonging to an earlier struct
+(misplaced ''), to a later one (missing ''), or to none (with the closest
+column name), instead of the general "has no mapping" of Scan.
+
+type StructStats struct{ Columns, Writable, HeadBytes, RowBytes int }
+func Structure(stru interface{}) (StructStats, error)
+func BatchSize(stru interface{}, max int) (int, error)
+
+Structure returns the metadata of a mapped struct for capacity planning:
+the number of (writable) columns, the bytes of the "(cols) VALUES " head
+and the estimated bytes per row of Buildstr's slice form (strings by
+"size=" or 32, json and geo by 64, others by their longest literal).
+BatchSize estimates how many rows fit in a statement of max bytes.
+
+func ScanInto(rows *sql.Rows, buf interface{}) (int, error)
+
+ScanInto scans rows into the caller's pre-allocated buf ([]struct) until it
+is full or rows are exhausted, returning the number of rows written. The
+remaining rows are left unread for the next call, so any number of results
+can be streamed in fixed memory without per-row allocation by sqlaux. Each
+element is zeroed just before a row is received into it; elements past the
+rows written are left untouched. A count less than len(buf) means the rows
+are exhausted and closed; don't call ScanInto on them again.
+
+func Buildargs(d Dialect, data interface{}, field ...string) (Fragment, error)
+func (c Config) Buildargs(d Dialect, data interface{}, field ...string) (Fragment, error)
+
+Buildargs is the parameterized Buildstr: instead of interpolating values it
+renders placeholders ($1, $2... for PostgreSQL, ? otherwise or for nil d)
+and returns the values as Args for db.Exec, leaving escaping to the driver.
+Values are processed like ToMap; geo fields render ST_GeomFromText($n) and
+PostgreSQL time.Duration fields $n::interval. Combine with Join or Rebase.
+Config.Buildargs follows c's Validate, ZeroTime, ZeroTimeValue, TimeZone
+(time.Time args are converted to it) and OmitEmpty instead of the global
+ones.
+
+func ScanShared(rows *sql.Rows, shared []string, dest ...interface{}) error
+
+ScanShared is Scan, but each result column named in shared (case
+insensitive) also feeds the same-named mapped fields of every other dest,
+so a column needed by several dest (eg. id) is selected only once. The
+field types must be convertible; an integer is never converted to a string.
+
+type Raw struct{ Data interface{} }
+
+A Raw field receives the driver's value as is on Scan ([]byte copied, NULL
+as nil) and passes it through as is on build, for columns whose
+representation must be preserved byte for byte, eg. exotic numeric formats.
+
+type Config struct {
+	Tag, Key, Op  string
+	Validate      bool
+	Deterministic bool
+	MaxRows       int
+	CheckDialect  Dialect
+	ZeroTime      ZeroTimePolicy
+	ZeroTimeValue time.Time
+	TimeLayout    string
+	TimeZone      *time.Location
+
+	Dialect       Dialect
+	Capacity      int
+	Lenient       bool
+	Partial       bool
+	Logger        Logger
+	Progress      func(p Progress)
+	OmitEmpty     bool
+	NameMapper    func(field string) string
+	InitOnly      bool
+	ScriptBatch   int
+	PlanCacheSize int
+}
+func SetConfig(c Config) error
+func GetConfig() Config
+func (c Config) Scan(rows *sql.Rows, dest ...interface{}) error
+func (c Config) Buildstr(data interface{}, field ...string) (string, error)
+func (c Config) BuildstrTo(w io.Writer, data interface{}, field ...string) error
+
+Config collects the package-level knobs; the first members mean the same as
+the deprecated exported variables of those names, the others have no
+variable. The variables are read only once, on first use of sqlaux, as the
+default configuration, and later changes to them are ignored: mutating them
+at run time would be racy and affect unrelated code. Set the knobs through
+SetConfig instead. SetConfig swaps the whole configuration atomically, usually a
+modified GetConfig; existing mappings are not affected by changes of Tag,
+Key, Op, NameMapper or CheckDialect. GetConfig returns the current
+configuration. The Config methods override the configuration for a single
+call: Scan uses c.MaxRows, Buildstr(To) use c.Validate, c.Deterministic and
+c.ZeroTime(Value); Scan, ScanContext and QueryContext also receive NULL
+and the sentinel into time.Time fields by c.ZeroTime(Value).
+
+type Logger func(ctx context.Context, query string, args []interface{})
+type Option func(c *Config)
+func WithDialect(d Dialect) Option
+func WithNullPolicy(p ZeroTimePolicy, sentinel time.Time) Option
+func WithCapacity(n int) Option
+func WithLenient() Option
+func WithLogger(l Logger) Option
+func WithPartial() Option
+func WithTime(layout string, loc *time.Location) Option
+func With(opts ...Option) Config
+func (c Config) With(opts ...Option) Config
+func (c Config) ExecContext(ctx context.Context, db Querier, query string, args ...interface{}) (sql.Result, error)
+
+Config also holds knobs without a variable: Dialect renders Buildstr
+literals by a dialect instead of Go syntax; Capacity preallocates rows for
+every Scan dest; Lenient makes Scan discard unmapped result columns instead
+of failing; Partial makes a cancelled ScanContext keep the rows received
+so far; Logger is called by ExecContext before each statement with the
+rewritten query and args. Options set them per call, eg.
+
+	err := sqlaux.With(sqlaux.WithLenient(), sqlaux.WithCapacity(100)).
+		Scan(rows, &users)
+
+With applies opts to a copy of the current configuration, so neither the
+global configuration nor other calls are affected.
+
+func ScanContext(ctx context.Context, rows *sql.Rows, dest ...interface{}) error
+func (c Config) ScanContext(ctx context.Context, rows *sql.Rows, dest ...interface{}) error
+
+ScanContext is the same as Scan, but checks ctx before each row. When ctx
+is cancelled it stops and returns an error wrapping ctx.Err() (test with
+errors.Is), leaving dest untouched; with Partial (see WithPartial) it first
+writes the rows received so far to dest, so batch processors can checkpoint
+their progress instead of losing everything.
+
+type ResumeToken string
+func TokenOf(row interface{}) (ResumeToken, error)
+func Export(ctx context.Context, db Querier, d Dialect, table string, after ResumeToken, batch int, dest interface{}, fn func(next ResumeToken) error) error
+
+Export reads all rows of table in primary key order by keyset pagination:
+each batch of at most batch rows overwrites dest (*[]*struct), then fn is
+called with next, the ResumeToken of the batch's last row, which fn may
+persist after processing the batch. Passing the last persisted token as
+after restarts an interrupted export without reprocessing; "" starts from
+the beginning. Each batch runs
+
+	SELECT cols FROM table WHERE pk1>$1 OR (pk1=$1 AND pk2>$2)
+		ORDER BY pk1,pk2 LIMIT batch
+
+with placeholders and row limiting by d. TokenOf returns the token of a
+*struct row. The struct must have a primary key, which cannot be binary.
+
+func BuildWhere(d Dialect, data interface{}, field ...string) (Fragment, error)
+func (c Config) BuildWhere(d Dialect, data interface{}, field ...string) (Fragment, error)
+
+BuildWhere generates the equality condition "col1=$1 AND col2=$2 AND ..." of
+data's (*struct) field for single-table lookups, returning the values as
+args like Buildargs. field defaults to the primary key. A NULL value (eg. a
+nil pointer) yields "col IS NULL" without a placeholder. Config.BuildWhere
+follows c like Config.Buildargs.
+
+func ScanChan(ctx context.Context, rows *sql.Rows, ch interface{}) error
+
+ScanChan sends every row of rows to ch (chan *struct) and closes ch at the
+end, usually called in its own goroutine to pipeline scanning and
+processing. The buffer size of ch, set by make(chan *T, n), is the buffer of
+the pipeline: when it is full (the consumer is slower than the database),
+ScanChan pauses reading the next row, so the driver stops receiving from
+the network too, and resumes as soon as the consumer takes a row; memory
+stays bounded by n rows. Cancelling ctx stops it with ctx.Err().
+
+func ReadYourWrites(ctx context.Context, window time.Duration, caughtUp func(ctx context.Context, replica *sql.DB, pos string) bool) context.Context
+func MarkWrite(ctx context.Context, pos string)
+
+ReadYourWrites returns a context in which, for window after a routed write,
+routed reads are pinned to the primary (the router is called with
+RouteWrite), preventing stale reads such as Get-after-Insert on replicas.
+Routed writes are recorded automatically; MarkWrite records a write done
+elsewhere, or the replication position (MySQL GTID, PostgreSQL LSN) after
+it. With a known position and a non-nil caughtUp, a read goes to the
+replica chosen by RouteRead as soon as caughtUp reports it has applied pos.
+
+var (
+	TimeLayout = ""
+	TimeZone   *time.Location
+)
+
+time.Time values are rendered by Buildstr as Dialect.FormatTime literals,
+eg. '2006-01-02 15:04:05', or as Go strings without a dialect. A non-empty
+TimeLayout formats them by that layout instead, eg. "2006-01-02" for DATE
+columns; a non-nil TimeZone converts them into that zone first, eg.
+time.UTC when the database session runs in UTC. WithTime sets both per
+call.
+
+type Labels struct {
+	Op     string
+	Struct string
+	Table  string
+}
+type Observer func(l Labels, rows int, d time.Duration, err error)
+func Observe(o ...Observer) error
+
+Observe registers metrics hooks, called synchronously after every observed
+operation with its labels, row count, duration and result. Labels derive
+from the mapping, no hand instrumentation needed: Op is scan, build,
+buildargs or exec; Struct is the struct name(s) joined by ","; Table is the
+registered table (see Tablename), or "". They have few values, suitable for
+Prometheus labels. Like MapStruct, Observe may be called at any time unless
+Config.InitOnly is set.
+
+func Buildupsert(d Dialect, data interface{}, conflict, update []string) (string, error)
+
+Buildupsert is the same as Buildstr for INSERT, but renders literals by d
+and appends its upsert clause: "ON DUPLICATE KEY UPDATE col=VALUES(col),..."
+for MySQL, "ON CONFLICT (cols) DO UPDATE SET col=EXCLUDED.col,..." for
+PostgreSQL and SQLite. conflict are the fields of the unique constraint
+(ignored by MySQL), the primary key by default; update are the fields to
+update on conflict, all other writable fields by default; with none the old
+row is kept.
+
+func QueryContext(ctx context.Context, db Querier, query string, args []interface{}, dest ...interface{}) error
+func (c Config) QueryContext(ctx context.Context, db Querier, query string, args []interface{}, dest ...interface{}) error
+
+QueryContext combines the usual query, scan and close steps: it rewrites
+query by the middleware, runs it on db, receives the result into dest by
+ScanContext and always closes the rows. A nil db is chosen by the router as
+a read; with one dest its struct name is passed as stru. Cancelling ctx
+stops receiving and returns an error wrapping ctx.Err().
+
+type PanicError struct {
+	Struct string
+	Column string
+	Value  interface{}
+	Stack  []byte
+}
+
+A panic while receiving or building values, usually from a bad mapping or
+from the caller's Scan or Value methods, no longer crashes the process:
+Scan, Buildstr, Buildargs, BuildWhere, ToMap and their variants recover it
+and return an error naming the struct and column being processed. The error
+wraps *PanicError, so errors.As retrieves it with the stack.
+
+type Rows[T any] func(yield func(*T, error) bool)
+func Iter[T any](rows *sql.Rows) Rows[T]
+
+With Go 1.23 or later, Iter consumes rows lazily by range-over-func:
+
+	for u, err := range sqlaux.Iter[User](rows) {
+
+Each row is received into a new *T by the usual mapping; no result slice is
+kept, and rows left after a break are not read. A receiving error is yielded
+as (nil, err) in the last iteration. Iter does not close rows.
+
+func ScanT[T any](rows *sql.Rows) ([]*T, error)
+
+With Go 1.18 or later, ScanT is the same as Scan with one dest, but takes
+the struct as the type parameter and returns the rows, eg.
+users, err := sqlaux.ScanT[User](rows), checked at compile time instead of
+the run time dest shape check.
+
+type Preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+func NewStmtCache(db Preparer, size int) *StmtCache
+func (c *StmtCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+func (c *StmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+func (c *StmtCache) Len() int
+func (c *StmtCache) Stats() (hits, misses uint64)
+func (c *StmtCache) Close() error
+
+StmtCache reuses a prepared *sql.Stmt per statement text on db. It is a
+Querier, so pass it instead of db to ExecContext, QueryContext etc.: the
+same struct and field set (and row count for slices) builds the same
+Buildargs placeholder statement, so repeated inserts and updates reuse their
+prepared statements automatically. Statements are keyed after middleware
+rewriting. When size (>0) is exceeded the least recently used statement is
+evicted; it is closed once no call is executing it, so concurrent callers
+never see a closed statement, and rows already returned stay readable.
+Stats reports the cache hits and misses.
+
+func Truncate(ctx context.Context, db Querier, stru interface{}) error
+func ArchiveThenDelete(ctx context.Context, db *sql.DB, stru interface{}, where string, args ...interface{}) (int64, error)
+
+Truncate empties the registered table of stru (see Tablename) by TRUNCATE
+TABLE, or DELETE FROM for SQLite, so the table name never comes from a
+call site string; the history table is kept. ArchiveThenDelete copies the
+rows matching where into the history table by SaveHistory and deletes them
+from the table in one transaction, returning the number of rows moved. If
+the copied and deleted counts differ, eg. under concurrent modification, it
+rolls back, so every row is either still in the table or in the history.
+
+func BuildUpdateByPK(data interface{}, field ...string) (string, error)
+func BuildDeleteByPK(data interface{}) (string, error)
+
+For a *struct whose struct has a primary key (tag "pk"), BuildUpdateByPK
+returns "SET col1=val1,... WHERE pk1=val1 AND ..." for "UPDATE table "+...;
+field defaults to all writable fields except the primary key.
+BuildDeleteByPK returns "WHERE pk1=val1 AND ..." for "DELETE FROM table "+
+.... A NULL primary key value is an error, as it never matches.
+
+type Progress struct {
+	Rows    int64
+	Bytes   int64
+	Elapsed time.Duration
+}
+func WithProgress(fn func(p Progress)) Option
+func (c Config) Export(ctx context.Context, db Querier, d Dialect, table string, after ResumeToken, batch int, dest interface{}, fn func(next ResumeToken) error) error
+func (c Config) DumpInsertScript(w io.Writer, data interface{}, opts ScriptOpts) error
+
+Long batch operations report their progress after every batch, for
+progress bars and heartbeat logs. Set the callback by WithProgress (or
+Config.Progress): DumpInsertScript calls it after each INSERT statement
+with the rows and bytes written so far, and Export after each batch's fn
+with the rows exported so far (Bytes is 0). Elapsed is the time
+since the operation started.
+
+func CreateIndexSQL(stru interface{}, table string, d Dialect) ([]string, error)
+
+CreateIndexSQL generates the CREATE INDEX statements of table from the
+mapping of stru, one per index, sorted by index name. A field tagged
+"index=name" or "unique=name" (comma separated for several) belongs to that
+(unique) index; fields of the same name form a composite index in field
+order. "where=cond" makes its indexes partial, eg. emails unique among rows
+not soft deleted:
+
+	Email string `db:"unique=uq_email where='deleted_at IS NULL'"`
+
+Different conditions in one index, or a partial index for MySQL, are
+errors.
+
+func CheckOrphans(ctx context.Context, db Querier, stru interface{}) error
+
+CheckOrphans counts the orphan rows of the table registered for stru (see
+Tablename): rows whose foreign key fields (tagged "ref=table.col") are not
+NULL but reference no existing row, for databases where foreign keys are
+disabled or never declared. It returns nil if there are none, or an error
+listing the count of each foreign key otherwise.
+
+func MapTable(stru interface{}, table string) error
+func Tablename(stru interface{}) string
+
+MapTable registers the table of a mapped struct, so functions can generate
+whole statements instead of fragments. The table can also be declared on a
+blank field and registered by MapStruct:
+
+	type User struct {
+		_    struct{} `db:"table=users"`
+		Name string
+	}
+
+Table names are lower case, optionally schema qualified like "app.users".
+Registering a different table for the same struct is an error. Tablename
+returns the table registered by MapTable, the tag or MapHistory, or "".
+
+func CommentSQL(stru interface{}, table string, d Dialect) ([]string, error)
+
+CommentSQL generates a COMMENT ON COLUMN statement for each column of table
+tagged "comment=", in field order, so tables created from structs carry
+human readable descriptions for data dictionaries. It returns nil for MySQL,
+whose comments are generated inline by CreateTableSQL, and for SQLite, which
+has no comments.
+
+func BuildInsert(d Dialect, data interface{}, field ...string) (string, []interface{}, error)
+func BuildUpdate(d Dialect, data interface{}, where Fragment, field ...string) (string, []interface{}, error)
+func BuildDelete(d Dialect, data interface{}, where Fragment) (string, []interface{}, error)
+
+BuildInsert, BuildUpdate and BuildDelete generate complete INSERT, UPDATE
+and DELETE statements for the registered table of data's struct (see
+Tablename), with values as placeholder arguments as by Buildargs:
+
+	query, args, err := sqlaux.BuildInsert(sqlaux.PostgreSQL, users)
+	_, err = db.ExecContext(ctx, query, args...)
+
+The $n placeholders of where start from $1 and are renumbered after those of
+SET. An empty where locates data's row by its primary key; a struct without
+one is an error, so a whole table is never updated or deleted by accident.
+BuildUpdate defaults field to all writable fields except primary keys.
+
+func BuildSelect(d Dialect, data interface{}, where Fragment, lock string) (string, []interface{}, error)
+func Get(ctx context.Context, db Querier, d Dialect, data interface{}, lock string) error
+
+BuildSelect generates "SELECT cols FROM table WHERE where" over all mapped
+columns, followed by lock, usually from ForUpdate or ForShare; where may end
+with ORDER BY or LIMIT, and an empty one locates data's row by its primary
+key. Get runs that primary key query and receives the row into data, which
+is like *struct, returning an error wrapping sql.ErrNoRows when absent. With
+a nil db Get routes to the write handle if lock is not "".
+
+func VerifyMapping(db *sql.DB, stru interface{}, table string) error
+
+VerifyMapping reads the column definitions of table from the system views
+(information_schema.columns for MySQL and PostgreSQL, pragma_table_info for
+SQLite) and checks that every mapped column of stru exists with a type
+compatible with its field, comparing the classes (integer, decimal, string,
+time, etc.) of the column type CreateTableSQL would derive. It returns a
+detailed diff of missing columns, incompatible types and nullable columns
+whose fields cannot receive NULL, so mapping drift is caught at startup
+instead of at the first failed query. Optional columns may be absent, alias
+names are accepted, computed columns and extra table columns are ignored.
+An empty table means the registered one (see Tablename); the dialect is
+Config.Dialect or detected by DetectDialect.
+
+func DumpSchema(w io.Writer, d Dialect, format string) error
+
+DumpSchema writes a data dictionary of all mapped structs to w as
+"markdown" or "html": one section per struct sorted by name, with its
+registered table (see Tablename) and a row per column giving the field, the
+column type derived for d as by CreateTableSQL, nullability, primary key
+and indexes, foreign key reference ("ref="), constraint ("check="),
+description ("comment=") and computed expression, followed by the child
+table fields ("child="). The document comes from the same struct
+definitions as the mapping, so it never drifts from the code.
+
+func DumpERD(w io.Writer, d Dialect, format string) error
+
+DumpERD writes an entity relationship diagram of all mapped structs to w as
+"mermaid" or "dot" (Graphviz). Each struct is an entity named after its
+registered table (see Tablename), or the struct name, with attributes of
+the column types derived for d as by CreateTableSQL, primary and foreign
+keys marked. Foreign keys ("ref=table.col") make many-to-one relationships
+and child table fields ("child=") one-to-many ones, giving an always up to
+date schema diagram without separate modeling tools.
+
+func sqlauxtest.Fake(dest interface{}) error
+func sqlauxtest.FakeSlice(dest interface{}, n int) error
+
+Package sqlauxtest fills mapped structs with plausible random values, for
+property based tests and load generation with the insert helpers. Strings
+respect "size=", simple "check=" constraints (numeric bounds and IN lists,
+ie. enums) are satisfied, integer primary keys increase within the process,
+and times are whole seconds within the last year. Replace sqlauxtest.Rand
+with a fixed seed source for reproducible data.
+
+func ScanMap(rows *sql.Rows) ([]map[string]interface{}, error)
+
+ScanMap receives each row of rows as a map keyed by column name, for ad hoc
+queries whose shape matches no mapped struct. Values are converted by the
+database type of their columns rather than kept in the driver's form:
+integers as int64, floats as float64, booleans as bool, DECIMAL and NUMERIC
+as string to keep precision, character types (JSON, UUID etc.) as string,
+dates and times as time.Time, binary as a copied []byte, NULL as nil, and
+other types as the driver returns them. Duplicate column names are an
+error. ScanMap does not close rows.
+
+func SnakeCase(field string) string
+
+When Config.NameMapper is not nil, MapStruct uses its result as the default
+column name of fields without a tagged column name, instead of the
+lowercased field name. Set it to SnakeCase to map CreatedAt to created_at;
+runs of capitals are one word, so UserID maps to user_id and HTTPServer to
+http_server. The result must be a lowercase identifier. Existing mappings
+are not affected by changing it.
+
+func bench.Run(ctx context.Context, db *sql.DB, stru interface{}, o bench.Options) (bench.Result, error)
+func bench.Main(stru ...interface{})
+
+Package bench runs configurable insert or select workloads against the
+registered table of stru through the sqlaux helpers: batches of rows
+generated by sqlauxtest.FakeSlice inserted by BuildInsert, or "SELECT ...
+LIMIT batch" received by QueryContext, spread over concurrent connections.
+It reports throughput and latency percentiles to help size batches and
+compare dialects and drivers. Main is its command line form; the
+cmd/sqlauxbench command runs it on a sample struct once a driver import is
+added.
+
+func sqlauxtest.NewRecorder(db Querier, dir string) *sqlauxtest.Recorder
+func sqlauxtest.Replay(dir string) *sql.DB
+
+A Recorder wraps a database handle as a Querier and writes the result of
+every query (column names, column types and row values) to a snapshot file
+in dir, one file per execution of a statement with its arguments, then
+returns the result to the caller unchanged. Exec statements pass through
+unrecorded. Replay returns a *sql.DB that serves the snapshots in dir back
+in execution order, repeating the last one, so integration scenarios can be
+replayed in unit tests without the database; Scan, ScanMap etc. behave as
+against the real database. Exec statements succeed affecting no rows, and
+queries without a snapshot are an error.
 */
 package sqlaux
@@ -0,0 +1,105 @@
+package sqlaux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// MappingEntry 为一个映射字段的描述，用于DumpMapping、LoadMapping。Field 为
+// 字段名（嵌套结构成员为全名），Offset 为字段相对结构的偏移量，Type 为读写时
+// 使用的类型（MapType映射的或sqlaux内置的等价类型），Options 为tag中的附加选
+// 项。
+type MappingEntry struct {
+	Struct  string  `json:"struct"`
+	Field   string  `json:"field"`
+	Column  string  `json:"column"`
+	Offset  uintptr `json:"offset"`
+	Type    string  `json:"type"`
+	Options string  `json:"options,omitempty"`
+}
+
+// DumpMapping 将全局映射以JSON格式（每个映射字段一项，按结构名及字段定义顺序
+// 排列）写入w，用于在Scan等行为异常时，查看sqlaux究竟如何解释了结构定义。
+func DumpMapping(w io.Writer) error {
+	mapping := loadmap()
+	var ss []string
+	for k := range mapping {
+		if !strings.Contains(k, ".") {
+			ss = append(ss, k)
+		}
+	}
+	sort.Strings(ss)
+	es := []MappingEntry{}
+	for _, s := range ss {
+		for _, f := range mapping[s].name.([]string) {
+			m := mapping["0."+s+"."+f]
+			es = append(es, MappingEntry{s, f, m.name.(string), m.offset,
+				m.typ.String(), m.info.String()})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(es); err != nil {
+		return fmt.Errorf("DumpMapping: %v", err)
+	}
+	return nil
+}
+
+// LoadMapping 从r中读取DumpMapping的输出，可用于比较不同版本程序、不同环境间
+// 的映射。
+func LoadMapping(r io.Reader) ([]MappingEntry, error) {
+	var es []MappingEntry
+	if err := json.NewDecoder(r).Decode(&es); err != nil {
+		return nil, fmt.Errorf("LoadMapping: %v", err)
+	}
+	return es, nil
+}
+
+// String 返回f的tag附加选项形式，如"size=20 notnull"，值的引用同tag，可由
+// ParseTag解析。f 为nil时返回""。
+func (f *fieldT) String() string {
+	if f == nil {
+		return ""
+	}
+	var s []string
+	add := func(b bool, o string) {
+		if b {
+			s = append(s, o)
+		}
+	}
+	quote := func(v string) string { // as parsetag unquotes
+		return "'" + strings.Replace(v, "'", "''", -1) + "'"
+	}
+	add(f.pk, "pk")
+	add(f.optional, "optional")
+	add(f.omitempty, "omitempty")
+	add(f.alias != "", "alias="+f.alias)
+	add(f.seq != "", "seq="+f.seq)
+	add(f.index != "", "index="+f.index)
+	add(f.unique != "", "unique="+f.unique)
+	add(f.where != "", "where="+quote(f.where))
+	add(f.ref != "", "ref="+f.ref)
+	add(f.comment != "", "comment="+quote(f.comment))
+	add(f.size > 0, fmt.Sprintf("size=%d", f.size))
+	add(f.dbtype != "", "dbtype="+f.dbtype)
+	add(f.nullable, "null")
+	add(!f.nullable, "notnull")
+	add(f.deferred, "defer")
+	add(f.charset != "", "charset="+f.charset)
+	add(f.collate != "", "collate="+f.collate)
+	add(f.geo && f.srid == 0, "geo")
+	add(f.geo && f.srid != 0, fmt.Sprintf("geo=%d", f.srid))
+	add(f.unit != "", "unit="+f.unit)
+	add(f.json, "json")
+	add(f.expr != "", "expr="+quote(f.expr))
+	if f.check != nil {
+		s = append(s, "check="+quote(f.check.expr))
+	}
+	if f.def.IsValid() {
+		s = append(s, "scan_default="+quote(fmt.Sprint(f.def.Interface())))
+	}
+	return strings.Join(s, " ")
+}
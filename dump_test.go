@@ -0,0 +1,43 @@
+package sqlaux
+
+import (
+	"bytes"
+	"testing"
+)
+
+type DumpPet struct {
+	ID   int    `db:"pk"`
+	Kind string `db:"col=kind check='kind <> ''cat''' size=8"`
+}
+
+func TestDumpMapping(t *testing.T) {
+	if _, err := Signature(DumpPet{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := DumpMapping(&b); err != nil {
+		t.Fatal(err)
+	}
+	es, err := LoadMapping(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []MappingEntry
+	for _, e := range es {
+		if e.Struct == "DumpPet" {
+			got = append(got, e)
+		}
+	}
+	if len(got) != 2 || got[0].Field != "ID" || got[0].Column != "id" ||
+		got[0].Type != "int" || got[1].Offset == 0 {
+		t.Fatalf("got %+v", got)
+	}
+	kv, err := ParseTag(got[1].Options)
+	if err != nil || kv["check"] != "kind <> 'cat'" || kv["size"] != "8" {
+		t.Errorf("Options %q: %v %v", got[1].Options, kv, err)
+	}
+
+	if _, err = LoadMapping(bytes.NewBufferString("{")); err == nil {
+		t.Error("LoadMapping accepted bad JSON")
+	}
+}
@@ -0,0 +1,145 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 以下为time.Duration字段按tag中的"unit="所替换的等价类型，数据库中的值为以
+// 该单位计的整数；Scan 还接受浮点数、PostgreSQL的INTERVAL文本（如"1 day
+// 02:00:00.5"）和Go的时长文本（如"1h30m"）。
+type (
+	durNs time.Duration
+	durUs time.Duration
+	durMs time.Duration
+	durS  time.Duration
+)
+
+func (d *durNs) Scan(src interface{}) error {
+	return scandur((*time.Duration)(d), src, time.Nanosecond)
+}
+func (d *durUs) Scan(src interface{}) error {
+	return scandur((*time.Duration)(d), src, time.Microsecond)
+}
+func (d *durMs) Scan(src interface{}) error {
+	return scandur((*time.Duration)(d), src, time.Millisecond)
+}
+func (d *durS) Scan(src interface{}) error {
+	return scandur((*time.Duration)(d), src, time.Second)
+}
+
+func (d durNs) Value() (driver.Value, error) { return int64(d), nil }
+func (d durUs) Value() (driver.Value, error) {
+	return int64(time.Duration(d) / time.Microsecond), nil
+}
+func (d durMs) Value() (driver.Value, error) {
+	return int64(time.Duration(d) / time.Millisecond), nil
+}
+func (d durS) Value() (driver.Value, error) {
+	return int64(time.Duration(d) / time.Second), nil
+}
+
+// durationType 为time.Duration类型。
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durUnits 为"unit="的可选值及其对应的单位、等价类型。
+var durUnits = map[string]struct {
+	unit time.Duration
+	typ  reflect.Type
+}{
+	"ns": {time.Nanosecond, reflect.TypeOf(durNs(0))},
+	"us": {time.Microsecond, reflect.TypeOf(durUs(0))},
+	"ms": {time.Millisecond, reflect.TypeOf(durMs(0))},
+	"s":  {time.Second, reflect.TypeOf(durS(0))},
+}
+
+// scandur 将src按单位unit转换为时长存入d，NULL转换为0。
+func scandur(d *time.Duration, src interface{}, unit time.Duration) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*d = 0
+		return nil
+	case int64:
+		*d = time.Duration(v) * unit
+		return nil
+	case float64:
+		*d = time.Duration(v * float64(unit))
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("cannot scan %T into time.Duration", src)
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*d = time.Duration(i) * unit
+		return nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		*d = time.Duration(f * float64(unit))
+		return nil
+	}
+	if v, err := time.ParseDuration(s); err == nil {
+		*d = v
+		return nil
+	}
+	v, err := parseInterval(s)
+	*d = v
+	return err
+}
+
+// parseInterval 解析PostgreSQL默认输出格式（IntervalStyle=postgres）的INTERVAL
+// 文本，如"-1 days +02:03:04.5"。年、月的长度不固定，出现时报错。
+func parseInterval(s string) (time.Duration, error) {
+	var d time.Duration
+	fs := strings.Fields(s)
+	for i := 0; i < len(fs); i++ {
+		f := fs[i]
+		if strings.Contains(f, ":") { // [-+]HH:MM:SS[.ffffff]
+			neg := strings.HasPrefix(f, "-")
+			hms := strings.Split(strings.TrimLeft(f, "-+"), ":")
+			if len(hms) != 3 {
+				return 0, fmt.Errorf("bad interval %q", s)
+			}
+			h, e1 := strconv.Atoi(hms[0])
+			m, e2 := strconv.Atoi(hms[1])
+			sec, e3 := strconv.ParseFloat(hms[2], 64)
+			if e1 != nil || e2 != nil || e3 != nil {
+				return 0, fmt.Errorf("bad interval %q", s)
+			}
+			t := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+				time.Duration(sec*float64(time.Second))
+			if neg {
+				t = -t
+			}
+			d += t
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || i+1 == len(fs) {
+			return 0, fmt.Errorf("bad interval %q", s)
+		}
+		i++
+		switch strings.TrimSuffix(fs[i], "s") {
+		case "day":
+			d += time.Duration(n) * 24 * time.Hour
+		default: // year, mon
+			if n != 0 {
+				return 0, fmt.Errorf("interval %q not a fixed duration", s)
+			}
+		}
+	}
+	return d, nil
+}
+
+// intervaltext 返回d对应的PostgreSQL INTERVAL字面值。
+func intervaltext(d time.Duration) string {
+	return "INTERVAL '" + strconv.FormatFloat(d.Seconds(), 'f', -1, 64) +
+		" seconds'"
+}
@@ -0,0 +1,52 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DurJob struct {
+	ID      int
+	Timeout time.Duration
+	Backoff time.Duration `db:"unit=ms"`
+}
+
+func TestDuration(t *testing.T) {
+	rows := testRows(t, []string{"id", "timeout", "backoff"},
+		[]driver.Value{int64(1), int64(5), int64(1500)},
+		[]driver.Value{int64(2), "1 day 02:00:00.5", []byte("-00:00:01")},
+		[]driver.Value{int64(3), "1h", 2.5})
+	defer rows.Close()
+	var d []*DurJob
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	want := []*DurJob{{1, 5, 1500 * time.Millisecond},
+		{2, 26*time.Hour + 500*time.Millisecond, -time.Second},
+		{3, time.Hour, 2500 * time.Microsecond}}
+	if !reflect.DeepEqual(d, want) {
+		for i := range d {
+			t.Errorf("row %d: got %v, want %v", i, *d[i], *want[i])
+		}
+	}
+
+	s, err := Buildstr(d[:2])
+	if want := "(id,timeout,backoff) VALUES (1,5,1500)," +
+		"(2,93600500000000,-1000)"; err != nil || s != want {
+		t.Errorf("Buildstr:\n got %s %v\nwant %s", s, err, want)
+	}
+	var b strings.Builder
+	err = DumpInsertScript(&b, d[0], ScriptOpts{Table: "t",
+		Dialect: PostgreSQL})
+	if err != nil || !strings.Contains(b.String(), "VALUES (1,"+
+		"INTERVAL '0.000000005 seconds',INTERVAL '1.5 seconds');") {
+		t.Errorf("PostgreSQL: %s %v", b.String(), err)
+	}
+	s, err = CreateTableSQL(DurJob{}, "t", MySQL)
+	if err != nil || !strings.Contains(s, "`backoff` bigint NOT NULL") {
+		t.Errorf("CreateTableSQL: %s %v", s, err)
+	}
+}
@@ -0,0 +1,57 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type EmbAudit struct {
+	By   string
+	Note string
+}
+
+type EmbUser struct {
+	ID   int `db:"pk"`
+	Name string
+	*EmbAudit
+}
+
+func TestEmbeddedPointer(t *testing.T) {
+	rows := testRows(t, []string{"id", "name", "by"},
+		[]driver.Value{int64(1), "a", "root"})
+	defer rows.Close()
+	var us []*EmbUser
+	if err := Scan(rows, &us); err != nil {
+		t.Fatal(err)
+	}
+	if len(us) != 1 || us[0].EmbAudit == nil || us[0].By != "root" {
+		t.Fatalf("Scan: %+v", us)
+	}
+
+	s, err := Buildstr(&EmbUser{ID: 2, Name: "b"})
+	if err != nil || s != `SET id=2,name="b",by="",note=""` {
+		t.Errorf("Buildstr with nil pointer: %s %v", s, err)
+	}
+	f, err := Buildargs(PostgreSQL, &EmbUser{ID: 2,
+		EmbAudit: &EmbAudit{By: "x"}}, "Name", "EmbAudit.By")
+	if err != nil || f.SQL != "SET name=$1,by=$2" || f.Args[1] != "x" {
+		t.Errorf("Buildargs: %+v %v", f, err)
+	}
+
+	c, err := Clone(us[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := c.(*EmbUser)
+	if u.EmbAudit == nil || u.EmbAudit == us[0].EmbAudit || u.By != "root" {
+		t.Errorf("Clone: %+v %+v", u, u.EmbAudit)
+	}
+	u.By = "other"
+	if us[0].By != "root" {
+		t.Error("Clone shares the embedded pointer")
+	}
+	if c, err = Clone(&EmbUser{ID: 3}); err != nil ||
+		c.(*EmbUser).EmbAudit != nil {
+		t.Errorf("Clone with nil pointer: %+v %v", c, err)
+	}
+}
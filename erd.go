@@ -0,0 +1,131 @@
+package sqlaux
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DumpERD 将全部已映射结构的实体关系图以format（"mermaid"或"dot"）格式写入
+// w：每个结构为一个实体，以其登记的表名（见Tablename）命名，没有时以结构名命
+// 名，属性为按d推导的列类型（同CreateTableSQL）和列名，并标出主键、外键；外
+// 键（"ref=表.列"）生成多对一的关系，子表字段（"child="）生成一对多的关系。
+// 输出可由Mermaid或Graphviz渲染，从而无需单独的建模工具，图也总是与代码一
+// 致。引用未映射的表时，该表作为没有属性的实体出现。
+func DumpERD(w io.Writer, d Dialect, format string) error {
+	if d == nil {
+		return fmt.Errorf("DumpERD: no dialect argument")
+	}
+	if format != "mermaid" && format != "dot" {
+		return fmt.Errorf("DumpERD: unknown format %q", format)
+	}
+	mapping, zt := loadmap(), conf().ZeroTime
+	var ss []string
+	for k := range mapping {
+		if !strings.Contains(k, ".") {
+			ss = append(ss, k)
+		}
+	}
+	sort.Strings(ss)
+
+	type attrT struct{ typ, col, key string }
+	type relT struct{ from, to, label, card string }
+	ents := make(map[string][]attrT, len(ss))
+	var names []string
+	var rels []relT
+	for _, s := range ss {
+		name := tablename(mapping, s)
+		if name == "" {
+			name = s
+		}
+		names = append(names, name)
+		var attrs []attrT
+		for _, f := range mapping[s].name.([]string) {
+			m := mapping["0."+s+"."+f]
+			typ, err := columntype(d, m)
+			if err != nil {
+				typ = "?"
+			}
+			a := attrT{typ: typ, col: m.name.(string)}
+			if m.info != nil && m.info.pk {
+				a.key = "PK"
+			}
+			if m.info != nil && m.info.ref != "" {
+				if a.key != "" {
+					a.key += ","
+				}
+				a.key += "FK"
+				card := "}o--||"
+				if !m.notnull(zt) {
+					card = "}o--o|"
+				}
+				rels = append(rels, relT{name,
+					m.info.ref[:strings.Index(m.info.ref, ".")], a.col, card})
+			}
+			attrs = append(attrs, a)
+		}
+		ents[name] = attrs
+		if cs, ok := mapping["c."+s]; ok {
+			for _, f := range cs.name.([]string) {
+				c := mapping["c."+s+"."+f].name.(childT)
+				rels = append(rels, relT{c.table, name, c.fk, "}o--||"})
+			}
+		}
+	}
+
+	ew := &errWriter{w: w}
+	if format == "mermaid" {
+		id := func(s string) string {
+			return strings.Map(func(c rune) rune {
+				if c == '_' || c == '-' || c >= '0' && c <= '9' ||
+					c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+					return c
+				}
+				return '_'
+			}, s)
+		}
+		io.WriteString(ew, "erDiagram\n")
+		for _, n := range names {
+			io.WriteString(ew, "    "+id(n)+" {\n")
+			for _, a := range ents[n] {
+				io.WriteString(ew, "        "+id(a.typ)+" "+id(a.col))
+				if a.key != "" {
+					io.WriteString(ew, " "+a.key)
+				}
+				io.WriteString(ew, "\n")
+			}
+			io.WriteString(ew, "    }\n")
+		}
+		for _, r := range rels {
+			fmt.Fprintf(ew, "    %s %s %s : %q\n", id(r.from), r.card,
+				id(r.to), r.label)
+		}
+	} else {
+		esc := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}",
+			`\}`, "|", `\|`, "<", `\<`, ">", `\>`)
+		io.WriteString(ew, "digraph schema {\n    rankdir=LR;\n"+
+			"    node [shape=record];\n")
+		for _, n := range names {
+			fmt.Fprintf(ew, "    \"%s\" [label=\"{%s|", esc.Replace(n),
+				esc.Replace(n))
+			for _, a := range ents[n] {
+				io.WriteString(ew, esc.Replace(a.col+" : "+a.typ))
+				if a.key != "" {
+					io.WriteString(ew, " "+a.key)
+				}
+				io.WriteString(ew, `\l`)
+			}
+			io.WriteString(ew, "}\"];\n")
+		}
+		for _, r := range rels {
+			fmt.Fprintf(ew, "    \"%s\" -> \"%s\" [label=\"%s\"];\n",
+				esc.Replace(r.from), esc.Replace(r.to), esc.Replace(r.label))
+		}
+		io.WriteString(ew, "}\n")
+	}
+	if ew.err != nil {
+		return fmt.Errorf("DumpERD: %v", ew.err)
+	}
+	return nil
+}
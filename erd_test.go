@@ -0,0 +1,69 @@
+package sqlaux
+
+import (
+	"strings"
+	"testing"
+)
+
+type ErdOrder struct {
+	_     struct{} `db:"table=erd_orders"`
+	ID    int64    `db:"pk"`
+	Buyer *int64   `db:"ref=erd_users.id"`
+	Tags  []string `db:"child=erd_order_tags:order_id:tag"`
+}
+
+type ErdLine struct {
+	_       struct{} `db:"table=erd_lines"`
+	OrderID int64    `db:"pk col=order_id ref=erd_orders.id"`
+	Price   float64  `db:"type='numeric(10, 2)'"`
+}
+
+func TestDumpERD(t *testing.T) {
+	for _, s := range []interface{}{ErdOrder{}, ErdLine{}} {
+		if _, err := Signature(s); err != nil { // automapped
+			t.Fatal(err)
+		}
+	}
+	var b strings.Builder
+	if err := DumpERD(&b, PostgreSQL, "mermaid"); err != nil {
+		t.Fatal(err)
+	}
+	s := b.String()
+	for _, want := range []string{"erDiagram\n",
+		"    erd_lines {\n        bigint order_id PK,FK\n" +
+			"        numeric_10__2_ price\n    }\n",
+		"    erd_orders {\n        bigint id PK\n        bigint buyer FK\n" +
+			"    }\n",
+		`    erd_lines }o--|| erd_orders : "order_id"` + "\n",
+		`    erd_orders }o--o| erd_users : "buyer"` + "\n",
+		`    erd_order_tags }o--|| erd_orders : "order_id"` + "\n"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("mermaid without\n%s", want)
+		}
+	}
+
+	b.Reset()
+	if err := DumpERD(&b, MySQL, "dot"); err != nil {
+		t.Fatal(err)
+	}
+	s = b.String()
+	for _, want := range []string{"digraph schema {\n",
+		`    "erd_orders" [label="{erd_orders|id : bigint PK\l` +
+			`buyer : bigint FK\l}"];` + "\n",
+		`    "erd_lines" -> "erd_orders" [label="order_id"];` + "\n",
+		`    "erd_order_tags" -> "erd_orders" [label="order_id"];` + "\n"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("dot without\n%s", want)
+		}
+	}
+	if !strings.HasSuffix(s, "}\n") {
+		t.Errorf("dot not closed:\n%s", s)
+	}
+
+	if err := DumpERD(&b, nil, "dot"); err == nil {
+		t.Error("nil dialect accepted")
+	}
+	if err := DumpERD(&b, MySQL, "svg"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
@@ -0,0 +1,271 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// Querier 为执行SQL语句的数据库句柄，*sql.DB、*sql.Tx、*sql.Conn 都满足该接
+// 口。
+type Querier interface {
+	ExecContext(ctx context.Context, query string,
+		args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string,
+		args ...interface{}) (*sql.Rows, error)
+}
+
+// Middleware 为SQL改写中间件，在sqlaux执行每一条语句之前被调用，可以改写语
+// 句及其参数（如插入优化器提示、追踪注释、路由指示等），返回错误时终止执行。
+type Middleware func(ctx context.Context, query string,
+	args []interface{}) (string, []interface{}, error)
+
+// hooksT 为已注册的中间件链、路由函数、度量钩子和事务开始钩子，各钩子按注册
+// 顺序依次调用。与映射相同，钩子以只读快照的形式发布（见loadhooks、
+// updatehooks），因而可以在任何时候注册。
+type hooksT struct {
+	middleware []Middleware
+	router     func(ctx context.Context, op RouteOp, stru string) *sql.DB
+	observers  []Observer
+	txhooks    []TxHook
+}
+
+// hooks 为已注册钩子的当前快照，nohooks 为尚未注册任何钩子时的快照。
+var (
+	hooks   atomic.Value // *hooksT
+	nohooks hooksT
+)
+
+// loadhooks 返回已注册钩子的当前快照，调用者不能修改它。
+func loadhooks() *hooksT {
+	if h, ok := hooks.Load().(*hooksT); ok {
+		return h
+	}
+	return &nohooks
+}
+
+// updatehooks 复制已注册钩子的当前快照，以fn修改副本后发布为新快照。fn 只
+// 能追加或替换副本的成员，不能修改其切片的元素。
+func updatehooks(fn func(h *hooksT)) {
+	regMu.Lock()
+	defer regMu.Unlock()
+	h := *loadhooks()
+	fn(&h)
+	hooks.Store(&h)
+}
+
+// Use 注册SQL改写中间件mw。与MapStruct相同，可以在任何时候调用（配置中的
+// InitOnly为true时除外），只影响此后开始执行的语句。
+func Use(mw ...Middleware) error {
+	if conf().InitOnly && !isinit() {
+		return fmt.Errorf("Use: must be called in init()")
+	}
+	for i, m := range mw {
+		if m == nil {
+			return fmt.Errorf("Use: mw[%d] is nil", i)
+		}
+	}
+	updatehooks(func(h *hooksT) {
+		n := len(h.middleware)
+		h.middleware = append(h.middleware[:n:n], mw...)
+	})
+	return nil
+}
+
+// rewrite 依次用中间件链改写query和args。
+func rewrite(ctx context.Context, query string,
+	args []interface{}) (string, []interface{}, error) {
+	var err error
+	for _, m := range loadhooks().middleware {
+		if query, args, err = m(ctx, query, args); err != nil {
+			return "", nil, err
+		}
+	}
+	return query, args, nil
+}
+
+// RouteOp 为语句的操作类型，供数据库路由函数选择读写句柄。
+type RouteOp int
+
+// 语句操作类型：读（SELECT），写（INSERT、UPDATE、DELETE等）。
+const (
+	RouteRead RouteOp = iota
+	RouteWrite
+)
+
+// SetRouter 设置数据库路由函数r，使sqlaux的执行函数在调用者传入的db为nil时，
+// 按操作类型op和所操作的结构名stru自动选择数据库句柄，如读操作走只读副本，
+// 写操作走主库。有结构参数的函数（如SaveHistory、AssignSeq）以其结构名为
+// stru，QueryContext 以唯一的dest的结构名为stru；其它函数（如ExecContext、
+// WithTx、WithAdvisoryLock、NextVal、Guard）以RouteStruct在ctx中携带的结构名
+// 为stru，没有时为""。与MapStruct相同，可以在任何时候调用（配置中的
+// InitOnly为true时除外）。
+func SetRouter(r func(ctx context.Context, op RouteOp,
+	stru string) *sql.DB) error {
+	if conf().InitOnly && !isinit() {
+		return fmt.Errorf("SetRouter: must be called in init()")
+	}
+	updatehooks(func(h *hooksT) { h.router = r })
+	return nil
+}
+
+// routeKey 为context中路由结构名的键，见RouteStruct。
+type routeKey struct{}
+
+// RouteStruct 返回携带路由结构名的ctx副本，以之调用没有结构参数的执行函数时，
+// 路由函数收到的stru为结构stru的名字（见SetRouter），如：
+//
+//	ctx = sqlaux.RouteStruct(ctx, Order{})
+//	err := sqlaux.WithTx(ctx, nil, nil, fn) // routed as "Order"
+//
+// stru 以变量值的形式作参数，可以取零值，也可以是其指针或切片。
+func RouteStruct(ctx context.Context, stru interface{}) context.Context {
+	if stru == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, routeKey{},
+		structof(reflect.TypeOf(stru)).Name())
+}
+
+// route 返回db，db为nil时用路由函数选择。stru 为""时取ctx中RouteStruct携
+// 带的结构名。
+func route(ctx context.Context, db Querier, op RouteOp,
+	stru string) (Querier, error) {
+	if db != nil {
+		return db, nil
+	}
+	if stru == "" {
+		stru, _ = ctx.Value(routeKey{}).(string)
+	}
+	if router := loadhooks().router; router != nil {
+		if s, ok := ctx.Value(rywKey{}).(*rywT); ok { // see ReadYourWrites
+			op = s.routeop(ctx, router, op, stru)
+		}
+		if r := router(ctx, op, stru); r != nil {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("no database to %s %q", [...]string{"read",
+		"write"}[op], stru)
+}
+
+// ExecContext 经中间件链改写后，在db上执行不返回结果集的语句query。db 为nil
+// 时由路由函数选择（见SetRouter），路由的结构名取自ctx（见RouteStruct），没
+// 有时为""。
+func ExecContext(ctx context.Context, db Querier, query string,
+	args ...interface{}) (sql.Result, error) {
+	return execContext(ctx, conf(), db, query, args)
+}
+
+// execContext 为ExecContext的实现，c为配置。
+func execContext(ctx context.Context, c *Config, db Querier, query string,
+	args []interface{}) (r sql.Result, err error) {
+	if len(loadhooks().observers) > 0 {
+		n := 0
+		defer func(start time.Time) {
+			if r != nil {
+				m, _ := r.RowsAffected()
+				n = int(m)
+			}
+			observe("exec", nil, start, &n, &err)
+		}(time.Now())
+	}
+	db, err = route(ctx, db, RouteWrite, "")
+	if err != nil {
+		return nil, fmt.Errorf("ExecContext: %v", err)
+	}
+	query, args, err = rewrite(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("ExecContext: %v", err)
+	}
+	if c.Logger != nil {
+		c.Logger(ctx, query, args)
+	}
+	if r, err = db.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("ExecContext: %v", err)
+	}
+	return r, nil
+}
+
+// QueryContext 经中间件链改写后，在db上执行查询语句query，以ScanContext将结
+// 果接收到dest，最后关闭结果集，即合并了常用的查询、接收、关闭三步，如：
+//
+//	var users []*User
+//	err := sqlaux.QueryContext(ctx, db, "SELECT * FROM user WHERE age>?",
+//		[]interface{}{18}, &users)
+//
+// db 为nil时由路由函数按只读操作选择，dest 只有一个时以其结构名为路由参数
+// stru。ctx 被取消时停止接收，返回包含ctx.Err()的错误。
+func QueryContext(ctx context.Context, db Querier, query string,
+	args []interface{}, dest ...interface{}) error {
+	return queryContext(ctx, conf(), db, query, args, dest)
+}
+
+// Get 在db上按data的主键查询该行（见BuildSelect）并接收到data，lock 同
+// BuildSelect，如在事务中锁定并读取一行：
+//
+//	job := &Job{ID: id}
+//	err := sqlaux.Get(ctx, tx, sqlaux.PostgreSQL, job,
+//		sqlaux.ForUpdate(sqlaux.PostgreSQL, sqlaux.LockNoWait))
+//
+// 没有该行时返回包含sql.ErrNoRows的错误（可用errors.Is判别），data 不变。db
+// 为nil时由路由函数以data的结构名选择：lock 为""时选择读句柄，否则选择写句
+// 柄。
+//
+// 约定：data的类型形如*struct，结构须已登记表名。
+func Get(ctx context.Context, db Querier, d Dialect, data interface{},
+	lock string) error {
+	if !single(data) {
+		return fmt.Errorf("Get: data not like *struct")
+	}
+	query, args, err := BuildSelect(d, data, Fragment{}, lock)
+	if err != nil {
+		return fmt.Errorf("Get: %v", err)
+	}
+	op := RouteRead
+	if lock != "" {
+		op = RouteWrite
+	}
+	db, err = route(ctx, db, op, reflect.TypeOf(data).Elem().Name())
+	if err != nil {
+		return fmt.Errorf("Get: %v", err)
+	}
+	if err = queryContext(ctx, conf(), db, query, args,
+		[]interface{}{data}); err != nil {
+		return fmt.Errorf("Get: %w", err)
+	}
+	return nil
+}
+
+// queryContext 为QueryContext的实现，c为配置。
+func queryContext(ctx context.Context, c *Config, db Querier, query string,
+	args []interface{}, dest []interface{}) error {
+	stru := ""
+	if len(dest) == 1 && dest[0] != nil {
+		stru = structof(reflect.TypeOf(dest[0])).Name()
+	}
+	db, err := route(ctx, db, RouteRead, stru)
+	if err != nil {
+		return fmt.Errorf("QueryContext: %v", err)
+	}
+	query, args, err = rewrite(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("QueryContext: %v", err)
+	}
+	if c.Logger != nil {
+		c.Logger(ctx, query, args)
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("QueryContext: %w", err)
+	}
+	defer rows.Close()
+	o := &scanOpts{mp: loadmap(), cfg: c, ctx: ctx}
+	if err = scan(rows, o, dest); err != nil {
+		return fmt.Errorf("QueryContext: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHooksAtRuntime(t *testing.T) {
+	old := loadhooks()
+	defer hooks.Store(old)
+	ctx := context.Background()
+
+	// registrations race with statements under way
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ExecContext(ctx, tdb, "UPDATE hook_t SET a=1")
+			}
+		}()
+	}
+	var observed, began int32
+	err := Use(func(ctx context.Context, q string,
+		args []interface{}) (string, []interface{}, error) {
+		return "/* hooked */ " + q, args, nil
+	})
+	if err == nil {
+		err = SetRouter(func(ctx context.Context, op RouteOp,
+			stru string) *sql.DB {
+			return tdb
+		})
+	}
+	if err == nil {
+		err = Observe(func(l Labels, rows int, d time.Duration, err error) {
+			atomic.AddInt32(&observed, 1)
+		})
+	}
+	if err == nil {
+		err = OnTx(func(ctx context.Context, tx *sql.Tx) error {
+			atomic.AddInt32(&began, 1)
+			return nil
+		})
+	}
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	takeExecs()
+	atomic.StoreInt32(&observed, 0)
+	if _, err = ExecContext(ctx, nil, "DELETE FROM hook_t"); err != nil {
+		t.Fatal(err)
+	}
+	err = WithTx(ctx, tdb, nil, func(*sql.Tx) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := takeExecs()
+	if len(got) != 1 || got[0] != "/* hooked */ DELETE FROM hook_t" {
+		t.Errorf("got %q", got)
+	}
+	if observed != 1 || began != 1 {
+		t.Errorf("observed %d, began %d", observed, began)
+	}
+	if err = Use(nil); err == nil {
+		t.Error("nil middleware accepted")
+	}
+
+	c := GetConfig()
+	defer SetConfig(c)
+	c.InitOnly = true
+	SetConfig(c)
+	if err = Observe(func(Labels, int, time.Duration, error) {}); err == nil ||
+		!strings.Contains(err.Error(), "init()") {
+		t.Errorf("InitOnly: %v", err)
+	}
+}
+
+type RouteOrder struct {
+	ID int64 `db:"pk seq=route_order_seq"`
+}
+
+func TestMiddleware(t *testing.T) {
+	old := loadhooks()
+	defer hooks.Store(old)
+	ctx := context.Background()
+	err := Use(func(ctx context.Context, q string,
+		args []interface{}) (string, []interface{}, error) {
+		if strings.HasPrefix(q, "DROP") {
+			return "", nil, errors.New("DROP refused")
+		}
+		return q + " AND tenant=?", append(args, 7), nil
+	}, func(ctx context.Context, q string,
+		args []interface{}) (string, []interface{}, error) {
+		return "/* second */ " + q, args, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged []interface{}
+	c := With(WithLogger(func(ctx context.Context, q string,
+		args []interface{}) {
+		logged = args
+	}))
+	takeExecs()
+	_, err = c.ExecContext(ctx, tdb, "DELETE FROM mw_t WHERE id=?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "/* second */ DELETE FROM mw_t WHERE id=? AND tenant=?"
+	if got := takeExecs(); len(got) != 1 || got[0] != want ||
+		!reflect.DeepEqual(logged, []interface{}{1, 7}) {
+		t.Errorf("got %q %v, want %q", got, logged, want)
+	}
+	_, err = ExecContext(ctx, tdb, "DROP TABLE mw_t")
+	if err == nil || !strings.Contains(err.Error(), "DROP refused") ||
+		len(takeExecs()) != 0 {
+		t.Errorf("refused statement: %v", err)
+	}
+}
+
+func TestRouteStruct(t *testing.T) {
+	old := loadhooks()
+	defer hooks.Store(old)
+	var got []string
+	SetRouter(func(ctx context.Context, op RouteOp, stru string) *sql.DB {
+		got = append(got, stru)
+		return tdb
+	})
+
+	ctx := context.Background()
+	rctx := RouteStruct(ctx, []*RouteOrder{})
+	ExecContext(ctx, nil, "DELETE FROM route_order")
+	ExecContext(rctx, nil, "DELETE FROM route_order")
+	WithTx(rctx, nil, nil, func(*sql.Tx) error { return nil })
+	tresult("SELECT nextval($1)", []string{"nextval"},
+		[]driver.Value{int64(7)})
+	o := &RouteOrder{}
+	if err := AssignSeq(ctx, nil, PostgreSQL, o); err != nil || o.ID != 7 {
+		t.Fatal(o, err)
+	}
+	tresult("SELECT id FROM route_order", []string{"id"})
+	var d []*RouteOrder
+	if err := QueryContext(ctx, nil, "SELECT id FROM route_order", nil,
+		&d); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"", "RouteOrder", "RouteOrder", "RouteOrder",
+		"RouteOrder"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRouteOp(t *testing.T) {
+	old := loadhooks()
+	defer hooks.Store(old)
+	ctx := context.Background()
+	tresult("SELECT id FROM route_order", []string{"id"})
+	var d []*RouteOrder
+	if err := QueryContext(ctx, nil, "SELECT id FROM route_order", nil,
+		&d); err == nil {
+		t.Error("nil db without a router accepted")
+	}
+
+	var ops []RouteOp
+	SetRouter(func(ctx context.Context, op RouteOp, stru string) *sql.DB {
+		ops = append(ops, op)
+		if stru == "" {
+			return nil
+		}
+		return tdb
+	})
+	QueryContext(ctx, nil, "SELECT id FROM route_order", nil, &d)
+	ExecContext(RouteStruct(ctx, RouteOrder{}), nil, "DELETE FROM x")
+	_, err := ExecContext(ctx, nil, "DELETE FROM x")
+	if want := []RouteOp{RouteRead, RouteWrite, RouteWrite}; err == nil ||
+		!reflect.DeepEqual(ops, want) {
+		t.Errorf("ops %v, want %v; %v", ops, want, err)
+	}
+	takeExecs()
+}
+
+type QueryUser struct {
+	ID   int
+	Name string
+}
+
+func TestQueryContext(t *testing.T) {
+	ctx := context.Background()
+	tresult("SELECT id,name FROM query_user WHERE id>?", []string{"id",
+		"name"}, []driver.Value{int64(1), "a"}, []driver.Value{int64(2), "b"})
+	var logged []interface{}
+	c := With(WithLogger(func(ctx context.Context, q string,
+		args []interface{}) {
+		logged = append(logged, q, args)
+	}))
+	var d []*QueryUser
+	err := c.QueryContext(ctx, tdb, "SELECT id,name FROM query_user "+
+		"WHERE id>?", []interface{}{0}, &d)
+	if err != nil || len(d) != 2 || *d[1] != (QueryUser{2, "b"}) {
+		t.Fatalf("QueryContext: %v %v", d, err)
+	}
+	if want := []interface{}{"SELECT id,name FROM query_user WHERE id>?",
+		[]interface{}{0}}; !reflect.DeepEqual(logged, want) {
+		t.Errorf("logged %v, want %v", logged, want)
+	}
+	if n := tdb.Stats().InUse; n != 0 {
+		t.Errorf("%d connections in use, rows not closed", n)
+	}
+
+	tresult("SELECT id FROM query_user", []string{"id"},
+		[]driver.Value{"x"})
+	err = QueryContext(ctx, tdb, "SELECT id FROM query_user", nil, &d)
+	if err == nil || tdb.Stats().InUse != 0 {
+		t.Errorf("bad value: %v, %d in use", err, tdb.Stats().InUse)
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = QueryContext(cctx, tdb, "SELECT id,name FROM query_user "+
+		"WHERE id>?", []interface{}{0}, &d)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package sqlaux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldNames 返回stru的所有映射字段名，即Buildstr等的field参数的有效取值，嵌
+// 套结构成员为全名，如"Inner.Field"。stru 以变量值的形式作参数，可以取零值。
+func FieldNames(stru interface{}) ([]string, error) {
+	t, err := structarg(stru)
+	if err != nil {
+		return nil, fmt.Errorf("FieldNames: %v", err)
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return nil, fmt.Errorf("FieldNames: %v", err)
+	}
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return nil, fmt.Errorf("FieldNames: %q has no mapping", t)
+	}
+	return append([]string(nil), e.name.([]string)...), nil
+}
+
+// nofield 返回mp中结构stru没有字段n的错误，并提示最接近的有效字段名。
+func nofield(mp map[string]entryT, stru, n string) error {
+	if s := closest(n, mp[stru].name.([]string)); s != "" {
+		return fmt.Errorf("%q has no field %q, did you mean %q?", stru, n, s)
+	}
+	return fmt.Errorf("%q has no field %q", stru, n)
+}
+
+// nocolumn 返回mp中结构stru没有列c的错误，并提示最接近的有效列名。
+func nocolumn(mp map[string]entryT, stru, c string) error {
+	fs := mp[stru].name.([]string)
+	cs := make([]string, len(fs))
+	for i, f := range fs {
+		cs[i] = mp["0."+stru+"."+f].name.(string)
+	}
+	if s := closest(c, cs); s != "" {
+		return fmt.Errorf("%q has no column %q, did you mean %q?", stru, c, s)
+	}
+	return fmt.Errorf("%q has no column %q", stru, c)
+}
+
+// closest 返回names中与s编辑距离（不区分大小写）最小的名字，距离超过s长度的
+// 一半时认为不相近，返回""。s为嵌套结构成员的短名时直接返回其全名。
+func closest(s string, names []string) string {
+	best, min := "", len(s)/2+1
+	for _, n := range names {
+		if strings.HasSuffix(strings.ToLower(n), "."+strings.ToLower(s)) {
+			return n
+		}
+		if d := distance(strings.ToLower(s), strings.ToLower(n)); d < min {
+			best, min = n, d
+		}
+	}
+	return best
+}
+
+// distance 返回a、b之间的Levenshtein编辑距离。
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([]int, len(rb)+1)
+	for j := range d {
+		d[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		prev := d[0] // d[i-1][j-1]
+		d[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cur := d[j]
+			if ra[i-1] == rb[j-1] {
+				d[j] = prev
+			} else {
+				d[j] = 1 + minInt(prev, d[j], d[j-1])
+			}
+			prev = cur
+		}
+	}
+	return d[len(rb)]
+}
+
+func minInt(x ...int) int {
+	m := x[0]
+	for _, v := range x[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
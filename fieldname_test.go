@@ -0,0 +1,49 @@
+package sqlaux
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type FnAddr struct {
+	City string
+}
+
+type FnUser struct {
+	ID   int `db:"col=uid"`
+	Name string
+	Addr FnAddr
+}
+
+func TestFieldNames(t *testing.T) {
+	fs, err := FieldNames(&FnUser{})
+	if want := []string{"ID", "Name", "Addr.City"}; err != nil ||
+		!reflect.DeepEqual(fs, want) {
+		t.Errorf("FieldNames: got %q %v, want %q", fs, err, want)
+	}
+	u := &FnUser{Addr: FnAddr{"x"}}
+	if s, err := Buildstr(u, "Addr.City"); err != nil || s != `SET city="x"` {
+		t.Errorf("Buildstr of a nested field: %s %v", s, err)
+	}
+	if _, err = FieldNames(nil); err == nil {
+		t.Error("FieldNames(nil) accepted")
+	}
+
+	for _, c := range []struct {
+		err  error
+		want string
+	}{
+		{second(Buildstr(&FnUser{}, "Nmae")), `did you mean "Name"?`},
+		{second(Buildstr(&FnUser{}, "City")), `did you mean "Addr.City"?`},
+		{second(Buildcol(&FnUser{}, "ui")), `did you mean "uid"?`},
+		{second(Selectstr(FnUser{}, "Zzzzz")), `no field "Zzzzz"`},
+	} {
+		if c.err == nil || !strings.HasSuffix(c.err.Error(), c.want) {
+			t.Errorf("got %v, want ...%s", c.err, c.want)
+		}
+	}
+}
+
+// second 返回两个返回值中的第二个。
+func second(_ string, err error) error { return err }
@@ -0,0 +1,270 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Signature 返回stru各结构全部映射字段的列名（含计算列），结构间以""分隔，即
+// 逐表罗列全部映射列、表间用空列分隔的SELECT语句的结果列。stru 可以是结构
+// 值、结构指针，或Scan的dest参数（如&[]*T）。
+func Signature(stru ...interface{}) ([]string, error) {
+	ts, err := structsof(stru)
+	if err != nil {
+		return nil, fmt.Errorf("Signature: %v", err)
+	}
+	return signature(ts), nil
+}
+
+// CheckColumns 在Scan之前检查rows的结果列与stru的Signature是否一致（空列分
+// 隔可选，列名可带表名前缀），不一致时返回详细的差异：缺少的列、多余的列、顺
+// 序错位的列，以及因两表“交界”处重名而会被Scan接收到错误结构中的列。tag 中
+// 有"optional"的字段的列可以缺少，有"alias="的字段的列可以是其别名。
+func CheckColumns(rows *sql.Rows, stru ...interface{}) error {
+	ts, err := structsof(stru)
+	if err != nil {
+		return fmt.Errorf("CheckColumns: %v", err)
+	}
+	mapping := loadmap() // after structsof automaps
+	col, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("CheckColumns: %v", err)
+	}
+
+	// expected and actual columns without delimiters, owner is struct index
+	var want, got []string
+	var owner []int
+	act := make([]string, len(col))
+	has := make(map[string]bool, len(col))
+	for i, c := range col {
+		act[i] = canonical(mapping, ts,
+			strings.ToLower(c[strings.LastIndex(c, ".")+1:]))
+		if act[i] != "" {
+			got = append(got, act[i])
+			has[act[i]] = true
+		}
+	}
+	for i, t := range ts {
+		for _, c := range signature([]reflect.Type{t}) {
+			e := mapping["1."+t.Name()+"."+c]
+			if e.info != nil && e.info.optional && !has[c] {
+				continue // absent optional column
+			}
+			want = append(want, c)
+			owner = append(owner, i)
+		}
+	}
+
+	var diff []string
+	if s := minus(want, got); len(s) > 0 {
+		diff = append(diff, fmt.Sprintf("missing %v", s))
+	}
+	if s := minus(got, want); len(s) > 0 {
+		diff = append(diff, fmt.Sprintf("unexpected %v", s))
+	}
+	if len(diff) == 0 && len(got) != len(want) { // eg. a shared column once
+		done := make(map[string]bool)
+		for _, c := range want {
+			if n, m := count(got, c), count(want, c); n != m && !done[c] {
+				diff = append(diff, fmt.Sprintf("column %q %d times, want "+
+					"%d", c, n, m))
+				done[c] = true
+			}
+		}
+	}
+	if len(diff) == 0 {
+		for i := range want {
+			if got[i] != want[i] {
+				diff = append(diff, fmt.Sprintf("column %d is %q, want %q",
+					i, got[i], want[i]))
+				break
+			}
+		}
+	}
+	if len(diff) == 0 { // same columns, check junctions
+		ref, err := scanField(mapping, act, ts, false)
+		if err != nil {
+			return fmt.Errorf("CheckColumns: %v", err)
+		}
+		k := 0
+		for i := range act {
+			if act[i] == "" {
+				continue
+			}
+			if j := ref[i].name.(int); j != owner[k] {
+				diff = append(diff, fmt.Sprintf("column %q would be "+
+					"scanned into %q not %q, separate them with ''", act[i],
+					ts[j].Name(), ts[owner[k]].Name()))
+			}
+			k++
+		}
+	}
+	if len(diff) > 0 {
+		return fmt.Errorf("CheckColumns: %s", strings.Join(diff, "; "))
+	}
+	return nil
+}
+
+// CheckSelect 在执行之前检查SELECT语句query的选择列能否按Scan约定被dest的
+// 各结构接收：以空列分隔的列组数不多于dest，各列在当前或紧接着的结构中有映
+// 射。不能接收时逐列给出准确的诊断，如列属于前面的结构（分隔符位置错误）、
+// 属于更后面的结构（缺少分隔符），或在哪个结构中都没有映射（提示最接近的
+// 列名），而不是Scan时笼统的"has no mapping"。dest 可以是结构值、结构指针，
+// 或Scan的dest参数（如&[]*T）。选择列含有*时无法检查，报错。
+func CheckSelect(query string, dest ...interface{}) error {
+	ts, err := structsof(dest)
+	if err != nil {
+		return fmt.Errorf("CheckSelect: %v", err)
+	}
+	mapping := loadmap() // after structsof automaps
+	cols, err := selectcols(query)
+	if err != nil {
+		return fmt.Errorf("CheckSelect: %v", err)
+	}
+
+	var diag []string
+	groups := 1
+	for _, c := range cols {
+		if c == "*" {
+			return fmt.Errorf("CheckSelect: cannot check wildcard column")
+		}
+		if c == "" {
+			groups++
+		}
+	}
+	if groups > len(ts) {
+		diag = append(diag, fmt.Sprintf("%d column groups but %d dest, "+
+			"too many ''", groups, len(ts)))
+	}
+	owner := func(c string) int { // the first struct mapping c, or -1
+		for k, t := range ts {
+			if _, ok := mapping["1."+t.Name()+"."+c]; ok {
+				return k
+			}
+		}
+		return -1
+	}
+	j := 0
+	for i, c := range cols {
+		if c == "" {
+			j++
+			continue
+		}
+		if j >= len(ts) {
+			break
+		}
+		if _, ok := mapping["1."+ts[j].Name()+"."+c]; ok {
+			continue
+		}
+		if j+1 < len(ts) { // Scan moves to the successive struct
+			if _, ok := mapping["1."+ts[j+1].Name()+"."+c]; ok {
+				j++
+				continue
+			}
+		}
+		switch k := owner(c); {
+		case k == -1:
+			err := nocolumn(mapping, ts[j].Name(), c)
+			if j+1 < len(ts) && !strings.Contains(err.Error(), "mean") {
+				err = nocolumn(mapping, ts[j+1].Name(), c) // maybe a typo here
+			}
+			diag = append(diag, fmt.Sprintf("column %d: %v", i, err))
+		case k < j:
+			diag = append(diag, fmt.Sprintf("column %d %q belongs to %q "+
+				"but is scanned after %q, misplaced ''", i, c, ts[k].Name(),
+				ts[j].Name()))
+		default:
+			diag = append(diag, fmt.Sprintf("column %d %q belongs to %q "+
+				"but follows %q, missing '' before it", i, c, ts[k].Name(),
+				ts[j].Name()))
+		}
+	}
+	if len(diag) > 0 {
+		return fmt.Errorf("CheckSelect: %s", strings.Join(diag, "; "))
+	}
+	return nil
+}
+
+// structsof 返回stru中各参数的结构类型，没有映射时自动映射（见automap）。
+func structsof(stru []interface{}) ([]reflect.Type, error) {
+	mapping := loadmap()
+	if len(stru) == 0 {
+		return nil, fmt.Errorf("no stru argument")
+	}
+	ts := make([]reflect.Type, len(stru))
+	for i, s := range stru {
+		var err error
+		if ts[i], err = structarg(s); err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i, err)
+		}
+		if mapping, err = automap(mapping, ts[i]); err != nil {
+			return nil, err
+		}
+		if _, ok := mapping[ts[i].Name()]; !ok {
+			return nil, fmt.Errorf("%q has no mapping", ts[i])
+		}
+	}
+	return ts, nil
+}
+
+// signature 为Signature的实现。
+func signature(ts []reflect.Type) []string {
+	mapping := loadmap()
+	var col []string
+	for i, t := range ts {
+		if i > 0 {
+			col = append(col, "")
+		}
+		for _, f := range mapping[t.Name()].name.([]string) {
+			col = append(col, mapping["0."+t.Name()+"."+f].name.(string))
+		}
+	}
+	return col
+}
+
+// canonical 返回ts中以c为别名（tag "alias="）的字段的列名，没有时返回c。
+func canonical(mp map[string]entryT, ts []reflect.Type, c string) string {
+	for _, t := range ts {
+		e, ok := mp["1."+t.Name()+"."+c]
+		if !ok || e.info == nil || e.info.alias == "" {
+			continue
+		}
+		for _, f := range mp[t.Name()].name.([]string) {
+			if m := mp["0."+t.Name()+"."+f]; m.same(e) {
+				return m.name.(string)
+			}
+		}
+	}
+	return c
+}
+
+// count 返回ss中s的个数。
+func count(ss []string, s string) int {
+	n := 0
+	for _, x := range ss {
+		if x == s {
+			n++
+		}
+	}
+	return n
+}
+
+// minus 返回a中不在b中的元素。
+func minus(a, b []string) []string {
+	var r []string
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r = append(r, x)
+		}
+	}
+	return r
+}
@@ -0,0 +1,113 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type FpP1 struct {
+	ID   int
+	Name string
+}
+
+type FpP3 struct {
+	ID int
+}
+
+func init() {
+	if err := MapStruct(FpP1{}, FpP3{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	s, err := Signature(FpP1{}, &[]*FpP3{})
+	if want := []string{"id", "name", "", "id"}; err != nil ||
+		!reflect.DeepEqual(s, want) {
+		t.Errorf("Signature: got %q %v, want %q", s, err, want)
+	}
+	if _, err = Signature(FpP1{}, nil); err == nil {
+		t.Error("nil stru accepted")
+	}
+}
+
+func TestCheckColumnsSharedOnce(t *testing.T) {
+	rows := testRows(t, []string{"id", "name"}, []driver.Value{1, "a"})
+	defer rows.Close()
+	err := CheckColumns(rows, FpP1{}, FpP3{})
+	if err == nil || !strings.Contains(err.Error(), `"id" 1 times, want 2`) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestCheckColumnsDiagnostics(t *testing.T) {
+	for _, c := range []struct {
+		cols []string
+		want string
+	}{
+		{[]string{"id", "name", "", "id"}, ""},
+		{[]string{"p.id", "p.name", "id"}, `column "id" would be scanned ` +
+			`into "FpP1" not "FpP3"`},
+		{[]string{"id", "", "id"}, `missing [name]`},
+		{[]string{"id", "name", "bogus", "", "id"}, `unexpected [bogus]`},
+		{[]string{"name", "id", "", "id"}, `column 0 is "name", want "id"`},
+	} {
+		vals := make([]driver.Value, len(c.cols))
+		rows := testRows(t, c.cols, vals)
+		err := CheckColumns(rows, FpP1{}, FpP3{})
+		rows.Close()
+		if c.want == "" && err != nil || c.want != "" &&
+			(err == nil || !strings.Contains(err.Error(), c.want)) {
+			t.Errorf("%v: got %v, want %q", c.cols, err, c.want)
+		}
+	}
+}
+
+func TestCheckSelectDiagnostics(t *testing.T) {
+	for _, c := range []struct {
+		query, want string
+	}{
+		{"SELECT id,name,'',id FROM p,q", ""},
+		{"SELECT id,name,id FROM p,q", ""},
+		{"SELECT id,nmae FROM p", `did you mean "name"`},
+		{"SELECT id,'',name FROM p,q", `"name"`},
+		{"SELECT * FROM p", "wildcard"},
+	} {
+		err := CheckSelect(c.query, FpP1{}, FpP3{})
+		if c.want == "" && err != nil || c.want != "" &&
+			(err == nil || !strings.Contains(err.Error(), c.want)) {
+			t.Errorf("%s: got %v, want %q", c.query, err, c.want)
+		}
+	}
+}
+
+type FpTag struct {
+	Tag string
+}
+
+func TestCheckSelectGroups(t *testing.T) {
+	for _, c := range []struct {
+		query, want string
+	}{
+		{"SELECT id,name,'',id,'',tag FROM p,q,r", ""},
+		{"SELECT id,'','',id,'',tag FROM p", "4 column groups but 3 dest"},
+		{"SELECT id,tag FROM p,r", `"tag" belongs to "FpTag" but follows ` +
+			`"FpP1", missing ''`},
+		{"SELECT p.id,'',q.id,name FROM p,q", `"name" belongs to "FpP1" ` +
+			`but is scanned after "FpP3", misplaced ''`},
+	} {
+		err := CheckSelect(c.query, FpP1{}, &[]*FpP3{}, (*FpTag)(nil))
+		if c.want == "" && err != nil || c.want != "" &&
+			(err == nil || !strings.Contains(err.Error(), c.want)) {
+			t.Errorf("%s: got %v, want %q", c.query, err, c.want)
+		}
+	}
+	if err := CheckSelect("SELECT id FROM p"); err == nil {
+		t.Error("no dest accepted")
+	}
+	if err := CheckSelect("DELETE FROM p", FpP1{}); err == nil {
+		t.Error("non SELECT statement accepted")
+	}
+}
@@ -0,0 +1,207 @@
+package sqlaux
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// geomT 为geo字段的等价类型，字段值为WKT文本，如"POINT(1 2)"。Scan时接收
+// MySQL内部格式（4字节SRID+WKB）、PostGIS的十六进制EWKB、标准WKB或WKT文本，
+// 统一转换为WKT；数据库NULL转换为""。
+type geomT string
+
+// geomType 为geomT类型，initmap据此替换geo字段的类型。
+var geomType = reflect.TypeOf(geomT(""))
+
+func (g *geomT) Scan(src interface{}) error {
+	var b []byte
+	switch s := src.(type) {
+	case nil:
+		*g = ""
+		return nil
+	case string:
+		b = []byte(s)
+	case []byte:
+		b = s
+	default:
+		return fmt.Errorf("cannot scan %T into geometry", src)
+	}
+	if h, err := hex.DecodeString(string(b)); err == nil && len(h) > 0 {
+		b = h // PostGIS hex EWKB
+	}
+	if len(b) > 4 && (b[4] == 0 || b[4] == 1) { // MySQL: SRID + WKB
+		if s, err := wkt(b[4:]); err == nil {
+			*g = geomT(s)
+			return nil
+		}
+	}
+	if len(b) > 0 && (b[0] == 0 || b[0] == 1) {
+		s, err := wkt(b)
+		if err != nil {
+			return fmt.Errorf("bad WKB: %v", err)
+		}
+		*g = geomT(s)
+		return nil
+	}
+	*g = geomT(b) // already WKT
+	return nil
+}
+
+// geomtext 返回geo字段值g的SQL表达式：按d生成ST_GeomFromText('WKT'[,srid])
+// （SQLite为SpatiaLite的GeomFromText），g为""时返回NULL。
+func geomtext(d Dialect, g string, srid int) (string, error) {
+	if g == "" {
+		return "NULL", nil
+	}
+	var b strings.Builder
+	fn := "ST_GeomFromText("
+	if d != nil && d.Name() == "sqlite" {
+		fn = "GeomFromText("
+	}
+	b.WriteString(fn)
+	if err := literal(&b, d, conf(), g); err != nil {
+		return "", err
+	}
+	if srid != 0 {
+		b.WriteString("," + strconv.Itoa(srid))
+	}
+	b.WriteString(")")
+	return b.String(), nil
+}
+
+// wkbNames 为WKB几何类型码对应的WKT名称。
+var wkbNames = [...]string{1: "POINT", 2: "LINESTRING", 3: "POLYGON",
+	4: "MULTIPOINT", 5: "MULTILINESTRING", 6: "MULTIPOLYGON",
+	7: "GEOMETRYCOLLECTION"}
+
+// wkt 将完整的WKB/EWKB数据b转换为WKT文本，b必须恰好被完全解析。
+func wkt(b []byte) (string, error) {
+	var w strings.Builder
+	n, err := wkbgeom(&w, b, true)
+	if err == nil && n != len(b) {
+		err = fmt.Errorf("%d trailing bytes", len(b)-n)
+	}
+	return w.String(), err
+}
+
+// wkbgeom 从b中解析一个几何对象，向w写入其WKT（named为false时不写类型名），
+// 返回所消耗的字节数。
+func wkbgeom(w *strings.Builder, b []byte, named bool) (int, error) {
+	if len(b) < 5 || b[0] > 1 {
+		return 0, fmt.Errorf("bad header")
+	}
+	var bo binary.ByteOrder = binary.BigEndian
+	if b[0] == 1 {
+		bo = binary.LittleEndian
+	}
+	typ, p := bo.Uint32(b[1:]), 5
+	dims := 2
+	if typ&0x80000000 != 0 { // EWKB Z
+		dims++
+	}
+	if typ&0x40000000 != 0 { // EWKB M
+		dims++
+	}
+	if typ&0x20000000 != 0 { // EWKB SRID
+		p += 4
+	}
+	typ &= 0x0fffffff
+	dims += int(typ / 1000) // ISO Z(1000), M(2000), ZM(3000)
+	if typ >= 3000 {
+		dims--
+	}
+	typ %= 1000
+	if typ == 0 || typ >= uint32(len(wkbNames)) {
+		return 0, fmt.Errorf("unknown geometry type %d", typ)
+	}
+	if named {
+		w.WriteString(wkbNames[typ])
+	}
+
+	// count reads an uint32 at b[p]
+	count := func() (int, error) {
+		if len(b) < p+4 {
+			return 0, fmt.Errorf("short data")
+		}
+		n := int(bo.Uint32(b[p:]))
+		p += 4
+		return n, nil
+	}
+	// points writes n points at b[p] like "1 2,3 4"
+	points := func(n int) error {
+		if n < 0 || len(b) < p+n*dims*8 {
+			return fmt.Errorf("short data")
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			for j := 0; j < dims; j++ {
+				if j > 0 {
+					w.WriteString(" ")
+				}
+				f := math.Float64frombits(bo.Uint64(b[p:]))
+				w.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+				p += 8
+			}
+		}
+		return nil
+	}
+	// rings writes n point lists like "(1 2,3 4),(5 6,7 8)"
+	rings := func(n int) error {
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			m, err := count()
+			if err != nil {
+				return err
+			}
+			w.WriteString("(")
+			if err = points(m); err != nil {
+				return err
+			}
+			w.WriteString(")")
+		}
+		return nil
+	}
+
+	if typ == 1 { // POINT
+		w.WriteString("(")
+		if err := points(1); err != nil {
+			return 0, err
+		}
+		w.WriteString(")")
+		return p, nil
+	}
+	n, err := count()
+	if err != nil {
+		return 0, err
+	}
+	w.WriteString("(")
+	switch typ {
+	case 2: // LINESTRING
+		err = points(n)
+	case 3: // POLYGON
+		err = rings(n)
+	default: // MULTI* and GEOMETRYCOLLECTION
+		for i := 0; i < n && err == nil; i++ {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			var m int
+			m, err = wkbgeom(w, b[p:], typ == 7)
+			p += m
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	w.WriteString(")")
+	return p, nil
+}
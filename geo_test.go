@@ -0,0 +1,63 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type GeoPlace struct {
+	ID  int
+	Loc string `db:"geo=4326"`
+}
+
+func TestGeo(t *testing.T) {
+	// POINT(1 2) as little endian WKB
+	wkb := []byte{1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		0, 0, 0, 0, 0, 0, 0, 0x40}
+	my := append([]byte{0xe6, 0x10, 0, 0}, wkb...) // SRID prefixed
+	ewkb := "0101000020E6100000000000000000F03F0000000000000040"
+	// LINESTRING(1 2,3 4) as big endian WKB
+	line := []byte{0, 0, 0, 0, 2, 0, 0, 0, 2,
+		0x3f, 0xf0, 0, 0, 0, 0, 0, 0, 0x40, 0, 0, 0, 0, 0, 0, 0,
+		0x40, 0x08, 0, 0, 0, 0, 0, 0, 0x40, 0x10, 0, 0, 0, 0, 0, 0}
+	rows := testRows(t, []string{"id", "loc"},
+		[]driver.Value{int64(1), wkb}, []driver.Value{int64(2), my},
+		[]driver.Value{int64(3), ewkb}, []driver.Value{int64(4), nil},
+		[]driver.Value{int64(5), "POINT(3 4)"},
+		[]driver.Value{int64(6), line})
+	defer rows.Close()
+	var d []*GeoPlace
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"POINT(1 2)", "POINT(1 2)", "POINT(1 2)", "",
+		"POINT(3 4)", "LINESTRING(1 2,3 4)"}
+	for i, p := range d {
+		if p.Loc != want[i] {
+			t.Errorf("row %d: got %q, want %q", i, p.Loc, want[i])
+		}
+	}
+
+	var b strings.Builder
+	err := DumpInsertScript(&b, d[3:5], ScriptOpts{Table: "t",
+		Dialect: PostgreSQL})
+	if err != nil || !strings.Contains(b.String(),
+		"VALUES (4,NULL),(5,ST_GeomFromText('POINT(3 4)',4326));") {
+		t.Errorf("PostgreSQL: %s %v", b.String(), err)
+	}
+	b.Reset()
+	err = DumpInsertScript(&b, d[4], ScriptOpts{Table: "t",
+		Dialect: SQLite})
+	if err != nil || !strings.Contains(b.String(),
+		"VALUES (5,GeomFromText('POINT(3 4)',4326));") {
+		t.Errorf("SQLite: %s %v", b.String(), err)
+	}
+
+	bad := testRows(t, []string{"id", "loc"},
+		[]driver.Value{int64(1), wkb[:10]})
+	defer bad.Close()
+	if err = Scan(bad, &d); err == nil {
+		t.Error("truncated WKB accepted")
+	}
+}
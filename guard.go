@@ -0,0 +1,72 @@
+package sqlaux
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MaxRows 大于0时，Scan接收的结果行数超过该值即停止接收，返回*RowLimitError，
+// 以防意外地将整表读入内存。
+//
+// Deprecated: 以SetConfig或Config.Scan等设置Config.MaxRows；本变量只在首次
+// 使用sqlaux时读取一次。
+var MaxRows = 0
+
+// RowLimitError 表示查询结果行数超过了限制Max。
+type RowLimitError struct {
+	Max int
+}
+
+func (e *RowLimitError) Error() string {
+	return fmt.Sprintf("result exceeds %d rows", e.Max)
+}
+
+// Guard 在执行SELECT语句query之前，用"LIMIT max+1"探测其结果行数，超过max时
+// 返回*RowLimitError。探测只计数不传输数据，但仍需执行一次查询，适合代价不确
+// 定的即席查询。query 末尾不能含有LIMIT子句。db 为nil时由路由函数选择，路由
+// 的结构名同ExecContext。
+func Guard(ctx context.Context, db Querier, max int, query string,
+	args ...interface{}) error {
+	if max <= 0 {
+		return fmt.Errorf("Guard: bad max %d", max)
+	}
+	db, err := route(ctx, db, RouteRead, "")
+	if err != nil {
+		return fmt.Errorf("Guard: %v", err)
+	}
+	query = fmt.Sprintf("SELECT COUNT(*) FROM (%s LIMIT %d) AS sqlaux_guard",
+		strings.TrimSuffix(strings.TrimSpace(query), ";"), max+1)
+	query, args, err = rewrite(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("Guard: %v", err)
+	}
+	var n int
+	if err = queryRow(ctx, db, query, args, &n); err != nil {
+		return fmt.Errorf("Guard: %v", err)
+	}
+	if n > max {
+		return &RowLimitError{max}
+	}
+	return nil
+}
+
+// queryRow 执行查询query，将其第一行结果接收到dest。
+func queryRow(ctx context.Context, db Querier, query string,
+	args []interface{}, dest ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("no rows in result")
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return err
+	}
+	return rows.Close()
+}
@@ -0,0 +1,50 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+type GuardRow struct {
+	ID int
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	q := "SELECT COUNT(*) FROM (SELECT id FROM g LIMIT 3) AS sqlaux_guard"
+	tresult(q, []string{"c"}, []driver.Value{int64(3)})
+	var e *RowLimitError
+	err := Guard(ctx, tdb, 2, "SELECT id FROM g;")
+	if !errors.As(err, &e) || e.Max != 2 {
+		t.Errorf("Guard over max: %v", err)
+	}
+	tresult(q, []string{"c"}, []driver.Value{int64(2)})
+	if err = Guard(ctx, tdb, 2, "SELECT id FROM g"); err != nil {
+		t.Errorf("Guard within max: %v", err)
+	}
+	if err = Guard(ctx, tdb, 0, "SELECT id FROM g"); err == nil {
+		t.Error("Guard max 0 accepted")
+	}
+}
+
+func TestMaxRows(t *testing.T) {
+	c := GetConfig()
+	c.MaxRows = 2
+	cols := []string{"id"}
+	rows := testRows(t, cols, []driver.Value{int64(1)},
+		[]driver.Value{int64(2)}, []driver.Value{int64(3)})
+	defer rows.Close()
+	var d []*GuardRow
+	var e *RowLimitError
+	if err := c.Scan(rows, &d); !errors.As(err, &e) || e.Max != 2 {
+		t.Errorf("Scan over MaxRows: %v", err)
+	}
+	rows = testRows(t, cols, []driver.Value{int64(1)},
+		[]driver.Value{int64(2)})
+	defer rows.Close()
+	if err := c.Scan(rows, &d); err != nil || len(d) != 2 {
+		t.Errorf("Scan within MaxRows: %d %v", len(d), err)
+	}
+}
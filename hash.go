@@ -0,0 +1,59 @@
+package sqlaux
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Hash 返回data的field字段的SHA-256校验和（十六进制串），其输入为各字段的
+// “列名=值”串，值的拼接与Buildstr相同，因而与字段的Go类型无关而只与其数据
+// 库值有关，可用于变更检测、ETL去重、比较源表与目标表的同步作业等。field缺
+// 省时为所有映射字段（计算列除外）；field相同时，其顺序影响结果。值按固定的
+// 配置hashConf拼接，不受全局配置影响：time.Time值转为UTC、精确到微秒，零值
+// 原样参与计算，因而同一数据在不同进程、不同时区得到相同的结果。
+//
+// 约定：data的类型形如*struct，field的写法同Buildstr。
+func Hash(data interface{}, field ...string) (string, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct ||
+		v.IsNil() {
+		return "", fmt.Errorf("Hash: data not like *struct")
+	}
+	stru := v.Type().Elem().Name()
+	mapping, err := automap(loadmap(), v.Type().Elem())
+	if err != nil {
+		return "", fmt.Errorf("Hash: %v", err)
+	}
+	if e, ok := mapping[stru]; ok {
+		if len(field) == 0 { // default all mapped fields
+			field = e.name.([]string)
+			if w, ok := mapping["w."+stru]; ok { // except computed ones
+				field = w.name.([]string)
+			}
+		}
+	} else {
+		return "", fmt.Errorf("Hash: %q has no mapping", stru)
+	}
+
+	h := sha256.New()
+	for _, n := range field {
+		m, ok := mapping["0."+stru+"."+n]
+		if !ok {
+			return "", fmt.Errorf("Hash: %v", nofield(mapping, stru, n))
+		}
+		ptr := reflect.NewAt(m.typ, m.addr(v.UnsafePointer()))
+		err := m.value(h, nil, hashConf, m.name.(string)+"=", ptr)
+		if err != nil {
+			return "", fmt.Errorf("Hash: %v", err)
+		}
+		io.WriteString(h, "\x00") // avoid ambiguous concatenation
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashConf 为Hash拼接值串的配置：Go语法的字面值，time.Time值转为UTC，键值按
+// 键排序（见Deterministic）。
+var hashConf = &Config{Deterministic: true}
@@ -0,0 +1,69 @@
+package sqlaux
+
+import (
+	"testing"
+	"time"
+)
+
+type HashEv struct {
+	ID int
+	At time.Time
+}
+
+func TestHashIndependentOfConfig(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.UTC)
+	want, err := Hash(&HashEv{1, at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	est := time.FixedZone("X", -5*3600)
+	if h, _ := Hash(&HashEv{1, at.In(est)}); h != want {
+		t.Error("hash depends on the time zone of the value")
+	}
+	zero, _ := Hash(&HashEv{ID: 1})
+
+	old := GetConfig()
+	defer SetConfig(old)
+	c := old
+	c.TimeZone, c.TimeLayout = time.FixedZone("Y", 8*3600), "2006-01-02"
+	c.ZeroTime = ZeroTimeNull
+	SetConfig(c)
+	if h, _ := Hash(&HashEv{1, at}); h != want {
+		t.Error("hash depends on TimeZone or TimeLayout")
+	}
+	if h, _ := Hash(&HashEv{ID: 1}); h != zero {
+		t.Error("hash depends on ZeroTime")
+	}
+}
+
+type HashEv64 struct {
+	ID int64
+	At time.Time
+}
+
+func TestHash(t *testing.T) {
+	at := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	h, err := Hash(&HashEv{1, at})
+	if err != nil || len(h) != 64 {
+		t.Fatalf("Hash: %s %v", h, err)
+	}
+	if h2, _ := Hash(&HashEv64{1, at}); h2 != h {
+		t.Error("hash depends on the Go type of the field")
+	}
+	if h2, _ := Hash(&HashEv{2, at}); h2 == h {
+		t.Error("hash ignores a changed value")
+	}
+	id, _ := Hash(&HashEv{1, at}, "ID")
+	if h2, _ := Hash(&HashEv{1, at.Add(time.Hour)}, "ID"); h2 != id {
+		t.Error("hash depends on a field not selected")
+	}
+	if h2, _ := Hash(&HashEv{1, at}, "At", "ID"); h2 == h {
+		t.Error("hash ignores the order of the fields")
+	}
+	if _, err = Hash(&HashEv{}, "Id"); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err = Hash(HashEv{}); err == nil {
+		t.Error("Hash of a struct value accepted")
+	}
+}
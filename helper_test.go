@@ -0,0 +1,132 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// tdriver 为测试用的数据库驱动：查询返回以tresult登记的结果，执行语句记录到
+// texecs。
+type tdriver struct{}
+
+type tresultT struct {
+	cols  []string
+	types []string // 各列的数据库类型名，可以为空
+	rows  [][]driver.Value
+}
+
+var (
+	tmu      sync.Mutex
+	tresults = map[string]tresultT{}
+	texecs   []string
+	tseq     int
+	tdb      *sql.DB
+)
+
+func init() {
+	sql.Register("sqlaux-test", tdriver{})
+	tdb, _ = sql.Open("sqlaux-test", "")
+}
+
+// tresult 登记查询query的结果。
+func tresult(query string, cols []string, rows ...[]driver.Value) {
+	tmu.Lock()
+	defer tmu.Unlock()
+	tresults[query] = tresultT{cols: cols, rows: rows}
+}
+
+// testRows 返回列为cols、各行为rows的结果集。
+func testRows(t *testing.T, cols []string, rows ...[]driver.Value) *sql.Rows {
+	t.Helper()
+	return typedRows(t, cols, nil, rows...)
+}
+
+// typedRows 同testRows，但结果集各列的数据库类型名为types。
+func typedRows(t *testing.T, cols, types []string,
+	rows ...[]driver.Value) *sql.Rows {
+	t.Helper()
+	tmu.Lock()
+	tseq++
+	q := fmt.Sprintf("test query %d", tseq)
+	tresults[q] = tresultT{cols, types, rows}
+	tmu.Unlock()
+	rs, err := tdb.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rs
+}
+
+// takeExecs 返回并清空已记录的执行语句。
+func takeExecs() []string {
+	tmu.Lock()
+	defer tmu.Unlock()
+	s := texecs
+	texecs = nil
+	return s
+}
+
+func (tdriver) Open(string) (driver.Conn, error) { return tconn{}, nil }
+
+type tconn struct{}
+
+func (tconn) Prepare(q string) (driver.Stmt, error) { return tstmt{q}, nil }
+func (tconn) Close() error                          { return nil }
+func (tconn) Begin() (driver.Tx, error)             { return tconn{}, nil }
+func (tconn) Commit() error                         { return nil }
+func (tconn) Rollback() error                       { return nil }
+
+func (tconn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return tconn{}, nil
+}
+
+type tstmt struct{ q string }
+
+func (tstmt) Close() error  { return nil }
+func (tstmt) NumInput() int { return -1 }
+
+func (s tstmt) Exec([]driver.Value) (driver.Result, error) {
+	tmu.Lock()
+	defer tmu.Unlock()
+	texecs = append(texecs, s.q)
+	return driver.RowsAffected(1), nil
+}
+
+func (s tstmt) Query([]driver.Value) (driver.Rows, error) {
+	tmu.Lock()
+	defer tmu.Unlock()
+	r, ok := tresults[s.q]
+	if !ok {
+		return nil, fmt.Errorf("no result for %q", s.q)
+	}
+	return &trows{r: r}, nil
+}
+
+type trows struct {
+	r tresultT
+	i int
+}
+
+func (r *trows) Columns() []string { return r.r.cols }
+func (r *trows) Close() error      { return nil }
+
+func (r *trows) ColumnTypeDatabaseTypeName(i int) string {
+	if i < len(r.r.types) {
+		return r.r.types[i]
+	}
+	return ""
+}
+
+func (r *trows) Next(dest []driver.Value) error {
+	if r.i >= len(r.r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.r.rows[r.i])
+	r.i++
+	return nil
+}
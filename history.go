@@ -0,0 +1,196 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// historyT 为结构所登记的表及其同构历史表，即映射项"h.struct名"的name。
+type historyT struct {
+	table, history string
+}
+
+// MapHistory 为已映射结构stru登记其对应的表table，及与之同构（列定义相同，
+// 见包说明）的历史表history，供SaveHistory、ExecHistory在修改、删除之前保
+// 存旧行。与MapStruct相同，可以在任何时候调用（配置中的InitOnly为true时除
+// 外）。
+// stru 以变量值的形式作参数，可以取零值。
+func MapHistory(stru interface{}, table, history string) error {
+	if conf().InitOnly && !isinit() {
+		return fmt.Errorf("MapHistory: must be called in init()")
+	}
+	t, err := structarg(stru)
+	if err != nil {
+		return fmt.Errorf("MapHistory: %v", err)
+	}
+	if table == "" || history == "" || table == history {
+		return fmt.Errorf("MapHistory: bad table %q or history %q", table,
+			history)
+	}
+	err = update(func(mapping map[string]entryT) error {
+		if _, ok := mapping[t.Name()]; !ok {
+			return fmt.Errorf("%q has no mapping", t)
+		}
+		if tb := tablename(mapping, t.Name()); tb != "" && tb != table {
+			return fmt.Errorf("%q already has table %q", t, tb)
+		}
+		mapping["h."+t.Name()] = entryT{name: historyT{table, history}}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("MapHistory: %v", err)
+	}
+	return nil
+}
+
+// historyof 返回mp中名为s的结构所登记的历史表，没有时ok为false。
+func historyof(mp map[string]entryT, s string) (h historyT, ok bool) {
+	e, ok := mp["h."+s]
+	if ok {
+		h = e.name.(historyT)
+	}
+	return h, ok
+}
+
+// SaveHistory 在db上将stru所登记表中满足条件where的行复制到其历史表：
+// "INSERT INTO 历史表 (列1,列2,...) SELECT 列1,列2,... FROM 表 WHERE where"，
+// 列为stru的可写映射列，args为where中的参数，返回复制的行数。db 通常为事务，
+// 使旧行与随后的UPDATE、DELETE一同提交或回滚；为nil时由路由函数选择。
+// stru 以变量值的形式作参数，可以取零值，须已用MapHistory登记。
+func SaveHistory(ctx context.Context, db Querier, stru interface{},
+	where string, args ...interface{}) (int64, error) {
+	mapping := loadmap()
+	t, err := structarg(stru)
+	if err != nil {
+		return 0, fmt.Errorf("SaveHistory: %v", err)
+	}
+	h, ok := historyof(mapping, t.Name())
+	if !ok {
+		return 0, fmt.Errorf("SaveHistory: %q has no history table", t)
+	}
+	if strings.TrimSpace(where) == "" {
+		return 0, fmt.Errorf("SaveHistory: empty where")
+	}
+	db, err = route(ctx, db, RouteWrite, t.Name())
+	if err != nil {
+		return 0, fmt.Errorf("SaveHistory: %v", err)
+	}
+
+	e := mapping[t.Name()]
+	if w, ok := mapping["w."+t.Name()]; ok { // except computed columns
+		e = w
+	}
+	var cols strings.Builder
+	for i, n := range e.name.([]string) {
+		if i > 0 {
+			cols.WriteString(",")
+		}
+		cols.WriteString(mapping["0."+t.Name()+"."+n].name.(string))
+	}
+	query := "INSERT INTO " + h.history + " (" + cols.String() + ") SELECT " +
+		cols.String() + " FROM " + h.table + " WHERE " + where
+	query, args, err = rewrite(ctx, query, args)
+	if err != nil {
+		return 0, fmt.Errorf("SaveHistory: %v", err)
+	}
+	r, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("SaveHistory: %v", err)
+	}
+	n, _ := r.RowsAffected()
+	return n, nil
+}
+
+// ExecHistory 用WithTx在db上开启事务，先用SaveHistory将满足条件where（参数
+// wargs）的旧行复制到历史表，再执行修改语句query（参数args，通常是条件相同
+// 的UPDATE或DELETE），然后提交；任一步出错时回滚。db 为nil时由路由函数选择。
+// stru 以变量值的形式作参数，可以取零值，须已用MapHistory登记。
+func ExecHistory(ctx context.Context, db *sql.DB, stru interface{},
+	where string, wargs []interface{}, query string,
+	args ...interface{}) (sql.Result, error) {
+	var r sql.Result
+	err := WithTx(RouteStruct(ctx, stru), db, nil, func(tx *sql.Tx) error {
+		if _, err := SaveHistory(ctx, tx, stru, where, wargs...); err != nil {
+			return err
+		}
+		query, args, err := rewrite(ctx, query, args)
+		if err != nil {
+			return err
+		}
+		r, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ExecHistory: %v", err)
+	}
+	return r, nil
+}
+
+// Truncate 在db上清空stru所登记（见Tablename）的表："TRUNCATE TABLE 表"，
+// SQLite为"DELETE FROM 表"。表名来自登记而不是调用处的字符串，不会误清空其
+// 他表；历史表不受影响。db 为nil时由路由函数选择。
+// stru 以变量值的形式作参数，可以取零值，须已登记表名。
+func Truncate(ctx context.Context, db Querier, stru interface{}) error {
+	t, err := structarg(stru)
+	if err != nil {
+		return fmt.Errorf("Truncate: %v", err)
+	}
+	table := tablename(loadmap(), t.Name())
+	if table == "" {
+		return fmt.Errorf("Truncate: %q has no registered table", t)
+	}
+	if db, err = route(ctx, db, RouteWrite, t.Name()); err != nil {
+		return fmt.Errorf("Truncate: %v", err)
+	}
+	query := "TRUNCATE TABLE " + table
+	if d := conf().Dialect; d != nil && d.Name() == "sqlite" {
+		query = "DELETE FROM " + table
+	}
+	if _, err := execContext(ctx, conf(), db, query, nil); err != nil {
+		return fmt.Errorf("Truncate: %v", err)
+	}
+	return nil
+}
+
+// ArchiveThenDelete 用WithTx在db上开启事务，将stru所登记表中满足条件where（
+// 参数args）的行复制到历史表（见SaveHistory），再从表中删除之，然后提交，返
+// 回移动的行数。复制与删除的行数不同（如其间有并发的修改）时回滚，保证每一
+// 行要么仍在表中，要么已在历史表中。db 为nil时由路由函数选择。
+// stru 以变量值的形式作参数，可以取零值，须已用MapHistory登记。
+func ArchiveThenDelete(ctx context.Context, db *sql.DB, stru interface{},
+	where string, args ...interface{}) (int64, error) {
+	t, err := structarg(stru)
+	if err != nil {
+		return 0, fmt.Errorf("ArchiveThenDelete: %v", err)
+	}
+	h, ok := historyof(loadmap(), t.Name())
+	if !ok {
+		return 0, fmt.Errorf("ArchiveThenDelete: %q has no history table", t)
+	}
+	var n int64
+	err = WithTx(RouteStruct(ctx, stru), db, nil, func(tx *sql.Tx) error {
+		var err error
+		if n, err = SaveHistory(ctx, tx, stru, where, args...); err != nil {
+			return err
+		}
+		query, args, err := rewrite(ctx, "DELETE FROM "+h.table+" WHERE "+
+			where, args)
+		if err != nil {
+			return err
+		}
+		r, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if m, err := r.RowsAffected(); err == nil && m != n {
+			return fmt.Errorf("archived %d rows but deleted %d", n, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ArchiveThenDelete: %v", err)
+	}
+	return n, nil
+}
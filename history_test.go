@@ -0,0 +1,173 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type HistOrder struct {
+	ID    int `db:"pk"`
+	State string
+	Total int `db:"expr='price * qty'"`
+}
+
+func TestMapHistoryAtRuntime(t *testing.T) {
+	if err := MapStruct(HistOrder{}); err != nil {
+		t.Fatal(err)
+	}
+	// readers race with the registration
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				BuildDelete(nil, HistOrder{}, Fragment{SQL: "id=1"})
+			}
+		}()
+	}
+	err := MapHistory(HistOrder{}, "orders", "orders_history")
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tb := Tablename(HistOrder{}); tb != "orders" {
+		t.Errorf("Tablename %q", tb)
+	}
+	if err = MapHistory(HistOrder{}, "other", "other_history"); err == nil {
+		t.Error("conflicting table accepted")
+	}
+
+	takeExecs()
+	n, err := SaveHistory(context.Background(), tdb, HistOrder{}, "id=1")
+	if err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+	want := []string{"INSERT INTO orders_history (id,state) SELECT id,state " +
+		"FROM orders WHERE id=1"}
+	if got := takeExecs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q", got)
+	}
+}
+
+type HistItem struct {
+	ID    int `db:"pk"`
+	State string
+}
+
+func TestExecHistory(t *testing.T) {
+	if _, err := Signature(HistItem{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	if err := MapHistory(HistItem{}, "items", "items_history"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	takeExecs()
+	_, err := ExecHistory(ctx, tdb, &HistItem{}, "id=?", []interface{}{1},
+		"DELETE FROM items WHERE id=?", 1)
+	want := []string{"INSERT INTO items_history (id,state) SELECT id,state " +
+		"FROM items WHERE id=?", "DELETE FROM items WHERE id=?"}
+	if got := takeExecs(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ExecHistory: got %q %v", got, err)
+	}
+
+	old := loadhooks()
+	defer hooks.Store(old)
+	var routed string
+	SetRouter(func(ctx context.Context, op RouteOp, stru string) *sql.DB {
+		routed = stru
+		return tdb
+	})
+	_, err = ExecHistory(ctx, nil, HistItem{}, "id=1", nil,
+		"DELETE FROM items WHERE id=1")
+	if takeExecs(); err != nil || routed != "HistItem" {
+		t.Errorf("routed %q: %v", routed, err)
+	}
+
+	type unregistered struct{ ID int }
+	for _, stru := range []interface{}{nil, unregistered{}} {
+		if _, err = ExecHistory(ctx, tdb, stru, "id=1", nil,
+			"DELETE FROM items WHERE id=1"); err == nil {
+			t.Errorf("ExecHistory(%#v) accepted", stru)
+		}
+	}
+	if _, err = SaveHistory(ctx, tdb, HistItem{}, " "); err == nil {
+		t.Error("empty where accepted")
+	}
+	if got := takeExecs(); got != nil {
+		t.Errorf("executed %q", got)
+	}
+	if err = MapHistory(nil, "a", "b"); err == nil {
+		t.Error("MapHistory(nil) accepted")
+	}
+	if err = MapHistory(HistItem{}, "items", "items"); err == nil {
+		t.Error("history same as table accepted")
+	}
+}
+
+type HistLog struct {
+	ID  int `db:"pk"`
+	Msg string
+}
+
+func TestArchiveThenDelete(t *testing.T) {
+	if _, err := Signature(HistLog{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	if err := MapHistory(HistLog{}, "logs", "logs_archive"); err != nil {
+		t.Fatal(err)
+	}
+	old := loadhooks()
+	defer hooks.Store(old)
+	var routed []string
+	SetRouter(func(ctx context.Context, op RouteOp, stru string) *sql.DB {
+		routed = append(routed, stru)
+		return tdb
+	})
+	ctx := context.Background()
+	takeExecs()
+	n, err := ArchiveThenDelete(ctx, nil, &HistLog{}, "id<?", 9)
+	want := []string{"INSERT INTO logs_archive (id,msg) SELECT id,msg " +
+		"FROM logs WHERE id<?", "DELETE FROM logs WHERE id<?"}
+	if got := takeExecs(); err != nil || n != 1 ||
+		!reflect.DeepEqual(got, want) {
+		t.Errorf("ArchiveThenDelete: got %q %d %v", got, n, err)
+	}
+	if err = Truncate(ctx, nil, []HistLog{}); err != nil {
+		t.Fatal(err)
+	}
+	c := GetConfig()
+	defer SetConfig(c)
+	SetConfig(With(WithDialect(SQLite)))
+	if err = Truncate(ctx, tdb, HistLog{}); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"TRUNCATE TABLE logs", "DELETE FROM logs"}
+	if got := takeExecs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Truncate: got %q", got)
+	}
+	if want := []string{"HistLog", "HistLog"}; !reflect.DeepEqual(routed,
+		want) {
+		t.Errorf("routed %q, want %q", routed, want)
+	}
+
+	type unregistered struct{ ID int }
+	for _, stru := range []interface{}{nil, unregistered{}} {
+		if err = Truncate(ctx, tdb, stru); err == nil {
+			t.Errorf("Truncate(%#v) accepted", stru)
+		}
+		if _, err = ArchiveThenDelete(ctx, tdb, stru, "id=1"); err == nil {
+			t.Errorf("ArchiveThenDelete(%#v) accepted", stru)
+		}
+	}
+	if _, err = ArchiveThenDelete(ctx, tdb, HistLog{}, ""); err == nil {
+		t.Error("empty where accepted")
+	}
+	if got := takeExecs(); got != nil {
+		t.Errorf("executed %q", got)
+	}
+}
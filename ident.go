@@ -0,0 +1,88 @@
+package sqlaux
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CheckDialect 不为nil时，MapStruct按其规范检查各映射列名（见CheckIdent），
+// 使不合规的列名在初始化时即报错，而不是等到第一条语句执行时才失败。
+//
+// Deprecated: 以SetConfig设置Config.CheckDialect；本变量只在首次使用sqlaux
+// 时读取一次。
+var CheckDialect Dialect
+
+// CheckIdent 按d规范检查标识符id（表名、列名等）：长度不能超过限制（
+// PostgreSQL 63字节，MySQL 64字符，SQLite 无限制），不能为保留字（sqlaux
+// 拼接的列名不加引号）。d 为nil时不检查。
+func CheckIdent(d Dialect, id string) error {
+	if d == nil {
+		return nil
+	}
+	switch d.Name() {
+	case "postgres":
+		if len(id) > 63 {
+			return fmt.Errorf("identifier %q longer than 63 bytes", id)
+		}
+	case "mysql":
+		if utf8.RuneCountInString(id) > 64 {
+			return fmt.Errorf("identifier %q longer than 64 characters", id)
+		}
+	}
+	if reserved[d.Name()][strings.ToLower(id)] {
+		return fmt.Errorf("identifier %q is a %s reserved word", id, d.Name())
+	}
+	return nil
+}
+
+// reserved 为各数据库中不能直接用作列名的常见保留字。
+var reserved = map[string]map[string]bool{
+	"mysql": words(`accessible add all alter analyze and as asc between both
+		by call cascade case change check collate column condition constraint
+		continue convert create cross cube cume_dist current_date current_time
+		current_timestamp current_user cursor database databases default
+		delayed delete dense_rank desc describe distinct div drop dual each
+		else elseif empty enclosed escaped except exists exit explain false
+		fetch first_value for force foreign from fulltext function generated
+		get grant group grouping groups having if ignore in index infile
+		inner insert interval into is iterate join key keys kill lag
+		last_value lateral lead leading leave left like limit linear lines
+		load lock long loop match mod modifies natural not nth_value ntile null
+		of on optimize option optionally or order out outer outfile over
+		partition percent_rank primary procedure purge range rank read reads
+		recursive references regexp release rename repeat replace require
+		resignal restrict return revoke right rlike row row_number rows schema
+		schemas select separator set show signal spatial sql sqlexception
+		sqlstate sqlwarning starting stored straight_join system table
+		terminated then to trailing trigger true undo union unique unlock
+		unsigned update usage use using values varying virtual when where
+		while window with write xor zerofill`),
+	"postgres": words(`all analyse analyze and any array as asc asymmetric
+		authorization binary both case cast check collate collation column
+		concurrently constraint create cross current_catalog current_date
+		current_role current_schema current_time current_timestamp
+		current_user default deferrable desc distinct do else end except false
+		fetch for foreign freeze from full grant group having ilike in
+		initially inner intersect into is isnull join lateral leading left
+		like limit localtime localtimestamp natural not notnull null offset on
+		only or order outer overlaps placing primary references returning
+		right select session_user similar some symmetric system_user table
+		tablesample then to trailing true union unique user using variadic
+		verbose when where window with`),
+	"sqlite": words(`add all alter and as autoincrement between check collate
+		commit constraint create default deferrable delete distinct drop else
+		escape except exists foreign from group having in index insert
+		intersect into is isnull join limit not notnull null on or order
+		primary references select set table then to transaction union unique
+		update using values when where`),
+}
+
+// words 将以空白分隔的单词列表转换为集合。
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
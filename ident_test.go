@@ -0,0 +1,48 @@
+package sqlaux
+
+import (
+	"strings"
+	"testing"
+)
+
+type IdentOrder struct {
+	ID    int
+	Order int
+}
+
+func TestCheckIdent(t *testing.T) {
+	long := strings.Repeat("a", 64)
+	tests := []struct {
+		d  Dialect
+		id string
+		ok bool
+	}{
+		{PostgreSQL, long[:63], true},
+		{PostgreSQL, long, false},
+		{MySQL, long, true},
+		{MySQL, long + "b", false},
+		{MySQL, strings.Repeat("列", 64), true},
+		{SQLite, long + long, true},
+		{PostgreSQL, "Select", false},
+		{MySQL, "order", false},
+		{SQLite, "name", true},
+		{nil, "select", true},
+	}
+	for _, tt := range tests {
+		if err := CheckIdent(tt.d, tt.id); (err == nil) != tt.ok {
+			t.Errorf("CheckIdent(%v, %q): %v", tt.d, tt.id, err)
+		}
+	}
+}
+
+func TestCheckDialect(t *testing.T) {
+	old := GetConfig()
+	defer SetConfig(old)
+	c := old
+	c.CheckDialect = PostgreSQL
+	SetConfig(c)
+	err := MapStruct(IdentOrder{})
+	if err == nil || !strings.Contains(err.Error(), `"order"`) {
+		t.Errorf("MapStruct: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// ScanInto 与Scan相同，但将rows的结果依次接收到调用者预先分配的buf中，直到
+// buf已满或没有更多的行，返回接收的行数。buf 已满时rows中余下的行不被读取，
+// 再次调用ScanInto可以继续接收，从而以固定的内存流式处理任意多的结果，sqlaux
+// 不为每行分配内存。接收每行前buf中对应的元素被置为零值；没有接收行的元素不
+// 变。返回的行数小于len(buf)时结果已接收完毕，rows 随之自动关闭，不应再调用
+// ScanInto。
+//
+// 约定：buf的类型形如[]struct。
+func ScanInto(rows *sql.Rows, buf interface{}) (int, error) {
+	v := reflect.ValueOf(buf)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Struct {
+		return 0, fmt.Errorf("ScanInto: buf is %T, want []struct", buf)
+	}
+	t := v.Type().Elem()
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return 0, fmt.Errorf("ScanInto: %v", err)
+	}
+	if _, ok := mapping[t.Name()]; !ok {
+		return 0, fmt.Errorf("ScanInto: %q has no mapping", t)
+	}
+
+	n := 0
+	o := &scanOpts{mp: mapping, into: func(tmp []reflect.Value) bool {
+		if n == v.Len() {
+			return false
+		}
+		tmp[0] = v.Index(n).Addr() // zeroed after rows.Next
+		return true
+	}}
+	err = scanEach(rows, []reflect.Type{t}, o, func([]reflect.Value) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, fmt.Errorf("ScanInto: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,67 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+type IntoRow struct {
+	ID   int
+	Name string
+}
+
+func init() {
+	if err := MapStruct(IntoRow{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestScanIntoKeepsUnused(t *testing.T) {
+	rows := testRows(t, []string{"id"}, []driver.Value{int64(7)},
+		[]driver.Value{int64(8)})
+	defer rows.Close()
+	buf := []IntoRow{{1, "a"}}
+	n, err := ScanInto(rows, buf)
+	if err != nil || n != 1 || buf[0] != (IntoRow{ID: 7}) {
+		t.Fatalf("%d %v %+v", n, err, buf)
+	}
+	buf = []IntoRow{{2, "b"}, {3, "c"}}
+	n, err = ScanInto(rows, buf)
+	if err != nil || n != 1 || buf[0] != (IntoRow{ID: 8}) {
+		t.Fatalf("%d %v %+v", n, err, buf)
+	}
+	if buf[1] != (IntoRow{3, "c"}) {
+		t.Errorf("unused element changed: %+v", buf[1])
+	}
+}
+
+func TestScanInto(t *testing.T) {
+	var vals [][]driver.Value
+	for i := 1; i <= 5; i++ {
+		vals = append(vals, []driver.Value{int64(i), fmt.Sprint(i)})
+	}
+	rows := testRows(t, []string{"id", "name"}, vals...)
+	defer rows.Close()
+	if n, err := ScanInto(rows, []IntoRow{}); err != nil || n != 0 {
+		t.Fatalf("empty buf: %d %v", n, err)
+	}
+	buf := make([]IntoRow, 2)
+	var got []IntoRow
+	for n := len(buf); n == len(buf); {
+		var err error
+		if n, err = ScanInto(rows, buf); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if len(got) != 5 || got[0] != (IntoRow{1, "1"}) ||
+		got[4] != (IntoRow{5, "5"}) {
+		t.Errorf("got %+v", got)
+	}
+	for _, bad := range []interface{}{nil, []*IntoRow{}, &buf, []int{}} {
+		if _, err := ScanInto(rows, bad); err == nil {
+			t.Errorf("ScanInto(%T) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build go1.23
+
+package sqlaux
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Rows 为Iter返回的逐行结果序列，用于range-over-func（Go 1.23）。
+type Rows[T any] func(yield func(*T, error) bool)
+
+// errBreak 为range循环提前结束时终止接收的内部错误。
+var errBreak = errors.New("break")
+
+// Iter 返回rows结果的逐行序列，每行接收到新的*T（T为结构）中，如：
+//
+//	for u, err := range sqlaux.Iter[User](rows) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// 与Scan不同，Iter不保留结果切片，按range的进度逐行接收，break 时余下的行不
+// 被读取。接收出错时，最后一次迭代的值为nil和错误。接收后Iter不主动关闭rows。
+func Iter[T any](rows *sql.Rows) Rows[T] {
+	return func(yield func(*T, error) bool) {
+		t := reflect.TypeOf((*T)(nil)).Elem()
+		if t.Kind() != reflect.Struct {
+			yield(nil, fmt.Errorf("Iter: %v is not a struct", t))
+			return
+		}
+		mapping, err := automap(loadmap(), t)
+		if err != nil {
+			yield(nil, fmt.Errorf("Iter: %v", err))
+			return
+		}
+		err = scanEach(rows, []reflect.Type{t}, &scanOpts{mp: mapping},
+			func(tmp []reflect.Value) error {
+				if !yield(tmp[0].Interface().(*T), nil) {
+					return errBreak
+				}
+				return nil
+			})
+		if err != nil && err != errBreak {
+			yield(nil, fmt.Errorf("Iter: %w", err))
+		}
+	}
+}
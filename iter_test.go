@@ -0,0 +1,59 @@
+//go:build go1.23
+
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type IterRow struct {
+	ID int
+}
+
+func TestIter(t *testing.T) {
+	rows := testRows(t, []string{"id"}, []driver.Value{int64(1)},
+		[]driver.Value{int64(2)}, []driver.Value{int64(3)})
+	defer rows.Close()
+	var got []int
+	for r, err := range Iter[IterRow](rows) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got = append(got, r.ID); len(got) == 2 {
+			break
+		}
+	}
+	var id int
+	if len(got) != 2 || got[1] != 2 || !rows.Next() || rows.Scan(&id) != nil ||
+		id != 3 {
+		t.Errorf("got %v, then row %d", got, id)
+	}
+
+	bad := testRows(t, []string{"id"}, []driver.Value{int64(1)},
+		[]driver.Value{"x"}, []driver.Value{int64(3)})
+	defer bad.Close()
+	n, errs := 0, 0
+	for r, err := range Iter[IterRow](bad) {
+		if err != nil {
+			errs++
+			if r != nil {
+				t.Errorf("value %v with error", r)
+			}
+			continue
+		}
+		n++
+	}
+	if n != 1 || errs != 1 {
+		t.Errorf("%d rows, %d errors", n, errs)
+	}
+	errs = 0
+	for _, err := range Iter[int](bad) {
+		if err != nil {
+			errs++
+		}
+	}
+	if errs != 1 {
+		t.Error("non-struct accepted")
+	}
+}
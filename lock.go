@@ -0,0 +1,106 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+)
+
+// LockWait 为行锁等待方式。
+type LockWait int
+
+// 行锁等待方式：等待；不等待，有冲突时立即报错；跳过已被锁定的行。
+const (
+	LockWaiting LockWait = iota
+	LockNoWait
+	LockSkipLocked
+)
+
+// ForUpdate 返回按d规范的排它行锁子句，如" FOR UPDATE SKIP LOCKED"，供调用者
+// 附加在SELECT语句末尾（或作为BuildSelect、Get的参数lock），用于工作队列等
+// 场合。SQLite 没有行锁，返回""；d 为nil时按标准语法（同PostgreSQL）生成，
+// 以免遗漏方言时静默地不加锁。
+func ForUpdate(d Dialect, wait LockWait) string {
+	return lockstr(d, " FOR UPDATE", wait)
+}
+
+// ForShare 返回按d规范的共享行锁子句，如" FOR SHARE NOWAIT"，用法同ForUpdate。
+func ForShare(d Dialect, wait LockWait) string {
+	return lockstr(d, " FOR SHARE", wait)
+}
+
+// lockstr 为ForUpdate、ForShare的公共实现，s为锁定子句。
+func lockstr(d Dialect, s string, wait LockWait) string {
+	if d != nil && d.Name() == "sqlite" {
+		return ""
+	}
+	switch wait {
+	case LockNoWait:
+		s += " NOWAIT"
+	case LockSkipLocked:
+		s += " SKIP LOCKED"
+	}
+	return s
+}
+
+// WithAdvisoryLock 在持有名为key的应用级咨询锁期间，用WithTx在db上的事务中
+// 执行fn，用于协调批处理作业等并发写入。锁按d的规范实现：
+//
+//	● PostgreSQL 为事务级锁pg_advisory_xact_lock，key 经FNV-1a散列为bigint，
+//	  事务结束时自动释放；
+//	● MySQL 为会话级锁GET_LOCK，在同一连接上开启事务，提交或回滚后
+//	  RELEASE_LOCK。
+//
+// 锁被占用时一直等待，直到获得锁或ctx结束。其他数据库不支持，报错。
+// db 为nil时由路由函数选择写句柄，路由的结构名同ExecContext。fn 返回的错误
+// 原样返回。
+func WithAdvisoryLock(ctx context.Context, db *sql.DB, d Dialect, key string,
+	fn func(tx *sql.Tx) error) error {
+	if d == nil || d.Name() != "postgres" && d.Name() != "mysql" {
+		return fmt.Errorf("WithAdvisoryLock: unsupported dialect")
+	}
+	if db == nil {
+		q, err := route(ctx, nil, RouteWrite, "")
+		if err != nil {
+			return fmt.Errorf("WithAdvisoryLock: %v", err)
+		}
+		db = q.(*sql.DB)
+	}
+
+	if d.Name() == "postgres" {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		id := int64(h.Sum64())
+		locked := false
+		err := withTx(ctx, db, nil, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx,
+				"SELECT pg_advisory_xact_lock($1)", id); err != nil {
+				return err
+			}
+			locked = true
+			return fn(tx)
+		})
+		if !locked && err != nil {
+			return fmt.Errorf("WithAdvisoryLock: %v", err)
+		}
+		return err
+	}
+
+	// GET_LOCK is held by the session, so stay on one connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("WithAdvisoryLock: %v", err)
+	}
+	defer conn.Close()
+	var got sql.NullInt64
+	if err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?,-1)",
+		key).Scan(&got); err != nil {
+		return fmt.Errorf("WithAdvisoryLock: %v", err)
+	}
+	if got.Int64 != 1 {
+		return fmt.Errorf("WithAdvisoryLock: can't get lock %q", key)
+	}
+	defer conn.ExecContext(context.Background(), "DO RELEASE_LOCK(?)", key)
+	return withTx(ctx, conn, nil, fn)
+}
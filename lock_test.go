@@ -0,0 +1,110 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type LockJob struct {
+	_     struct{} `db:"table=jobs"`
+	ID    int      `db:"pk"`
+	State string
+}
+
+func TestLockClause(t *testing.T) {
+	for _, c := range []struct {
+		d    Dialect
+		wait LockWait
+		want string
+	}{
+		{PostgreSQL, LockSkipLocked, " FOR UPDATE SKIP LOCKED"},
+		{MySQL, LockNoWait, " FOR UPDATE NOWAIT"},
+		{nil, LockWaiting, " FOR UPDATE"},
+		{SQLite, LockSkipLocked, ""},
+	} {
+		if got := ForUpdate(c.d, c.wait); got != c.want {
+			t.Errorf("%v %v: got %q, want %q", c.d, c.wait, got, c.want)
+		}
+	}
+	if got := ForShare(nil, LockNoWait); got != " FOR SHARE NOWAIT" {
+		t.Errorf("ForShare: got %q", got)
+	}
+}
+
+func TestBuildSelectLock(t *testing.T) {
+	q, args, err := BuildSelect(PostgreSQL, LockJob{},
+		Fragment{SQL: "state=$1 ORDER BY id LIMIT 10", Args: []interface{}{
+			"new"}}, ForUpdate(PostgreSQL, LockSkipLocked))
+	if err != nil || q != "SELECT id,state FROM jobs WHERE state=$1 "+
+		"ORDER BY id LIMIT 10 FOR UPDATE SKIP LOCKED" || len(args) != 1 {
+		t.Errorf("got %q %v %v", q, args, err)
+	}
+	if _, _, err = BuildSelect(nil, LockJob{}, Fragment{}, ""); err == nil {
+		t.Error("no where with a struct value accepted")
+	}
+
+	q = "SELECT id,state FROM jobs WHERE id=$1 FOR UPDATE NOWAIT"
+	tresult(q, []string{"id", "state"}, []driver.Value{int64(3), "new"})
+	j := &LockJob{ID: 3}
+	err = Get(context.Background(), tdb, PostgreSQL, j,
+		ForUpdate(PostgreSQL, LockNoWait))
+	if err != nil || j.State != "new" {
+		t.Errorf("Get: %+v %v", j, err)
+	}
+	tresult(q, []string{"id", "state"})
+	j = &LockJob{ID: 3}
+	err = Get(context.Background(), tdb, PostgreSQL, j,
+		ForUpdate(PostgreSQL, LockNoWait))
+	if !errors.Is(err, sql.ErrNoRows) || j.State != "" {
+		t.Errorf("Get of no row: %+v %v", j, err)
+	}
+}
+
+func TestWithAdvisoryLock(t *testing.T) {
+	ctx := context.Background()
+	update := func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE jobs SET state='x'")
+		return err
+	}
+	takeExecs()
+	err := WithAdvisoryLock(ctx, tdb, PostgreSQL, "job", update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"SELECT pg_advisory_xact_lock($1)",
+		"UPDATE jobs SET state='x'"}
+	if got := takeExecs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("PostgreSQL: got %q", got)
+	}
+
+	tresult("SELECT GET_LOCK(?,-1)", []string{"l"}, []driver.Value{int64(1)})
+	if err = WithAdvisoryLock(ctx, tdb, MySQL, "job", update); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"UPDATE jobs SET state='x'", "DO RELEASE_LOCK(?)"}
+	if got := takeExecs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MySQL: got %q", got)
+	}
+	tresult("SELECT GET_LOCK(?,-1)", []string{"l"}, []driver.Value{nil})
+	if err = WithAdvisoryLock(ctx, tdb, MySQL, "job", update); err == nil {
+		t.Error("failed GET_LOCK accepted")
+	}
+	if got := takeExecs(); got != nil {
+		t.Errorf("executed %q without the lock", got)
+	}
+
+	errFn := errors.New("fn")
+	err = WithAdvisoryLock(ctx, tdb, PostgreSQL, "job", func(*sql.Tx) error {
+		return errFn
+	})
+	if err != errFn {
+		t.Errorf("fn error: %v", err)
+	}
+	if err = WithAdvisoryLock(ctx, tdb, SQLite, "job", update); err == nil {
+		t.Error("SQLite accepted")
+	}
+}
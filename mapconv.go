@@ -0,0 +1,121 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// FromMap 将以列名为键的m中的值，按映射转换后存入dest的对应字段，适用于数据
+// 来自CSV、消息队列等动态来源的场合。值的接收与Scan相同（sql.Scanner、json、
+// scan_default等），其余按Decode的规则转换；json字段的值不是文本时按其JSON
+// 编码接收。列名大小写不敏感，可带表名前缀；m 中有未映射的列时报错。
+//
+// 约定：dest的类型形如*struct。
+func FromMap(dest interface{}, m map[string]interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct ||
+		v.IsNil() {
+		return fmt.Errorf("FromMap: dest not like *struct")
+	}
+	stru := v.Type().Elem().Name()
+	mapping, err := automap(loadmap(), v.Type().Elem())
+	if err != nil {
+		return fmt.Errorf("FromMap: %v", err)
+	}
+	if _, ok := mapping[stru]; !ok {
+		return fmt.Errorf("FromMap: %q has no mapping", stru)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // deterministic error
+	for _, k := range keys {
+		c := strings.ToLower(k[strings.LastIndex(k, ".")+1:])
+		e, ok := mapping["1."+stru+"."+c]
+		if !ok {
+			return fmt.Errorf("FromMap: %v", nocolumn(mapping, stru, c))
+		}
+		if err := e.assign(e.addrw(v.UnsafePointer()), m[k]); err != nil {
+			return fmt.Errorf("FromMap: column %q %v", c, err)
+		}
+	}
+	return nil
+}
+
+// assign 按Scan的规则将值val存入映射项e所对应字段（地址为p）。
+func (e entryT) assign(p unsafe.Pointer, val interface{}) error {
+	if e.info != nil && e.info.json && val != nil {
+		switch val.(type) {
+		case string, []byte:
+		default:
+			b, err := json.Marshal(val)
+			if err != nil {
+				return err
+			}
+			val = b
+		}
+	}
+	if s, ok := e.receiver(p, conf()).(sql.Scanner); ok {
+		return s.Scan(val)
+	}
+	return convert(reflect.NewAt(e.typ, p).Elem(), val)
+}
+
+// ToMap 将data的field字段按映射转换为以列名为键的driver.Value值，值的处理与
+// Buildstr相同（driver.Valuer、json、零值时间策略等），geo字段为WKT文本，适用
+// 于将数据直接交给驱动、消息总线或JSON编码器的场合。field缺省时为所有映射字
+// 段（计算列除外）。
+//
+// 约定：data的类型形如*struct，field的写法同Buildstr。
+func ToMap(data interface{}, field ...string) (m map[string]interface{},
+	err error) {
+	c := conf()
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct ||
+		v.IsNil() {
+		return nil, fmt.Errorf("ToMap: data not like *struct")
+	}
+	stru := v.Type().Elem().Name()
+	mapping, err := automap(loadmap(), v.Type().Elem())
+	if err != nil {
+		return nil, fmt.Errorf("ToMap: %v", err)
+	}
+	if e, ok := mapping[stru]; ok {
+		if len(field) == 0 { // default all mapped fields
+			field = e.name.([]string)
+			if w, ok := mapping["w."+stru]; ok { // except computed ones
+				field = w.name.([]string)
+			}
+		}
+	} else {
+		return nil, fmt.Errorf("ToMap: %q has no mapping", stru)
+	}
+
+	col := ""
+	defer recovered(&err, "ToMap", &stru, &col)
+	m = make(map[string]interface{}, len(field))
+	for _, n := range field {
+		e, ok := mapping["0."+stru+"."+n]
+		if !ok {
+			return nil, fmt.Errorf("ToMap: %v", nofield(mapping, stru, n))
+		}
+		col = e.name.(string)
+		ptr := reflect.NewAt(e.typ, e.addr(v.UnsafePointer()))
+		if c.ZeroTime == ZeroTimeSkip && e.zerotime(ptr) {
+			continue
+		}
+		val, err := e.arg(c, ptr)
+		if err != nil {
+			return nil, fmt.Errorf("ToMap: %v", err)
+		}
+		m[e.name.(string)] = val
+	}
+	return m, nil
+}
@@ -0,0 +1,59 @@
+package sqlaux
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type MapItem struct {
+	ID   int
+	Name string
+	Tags []string `db:"json"`
+	Addr TagAddr  `db:"prefix=addr_"`
+}
+
+func TestFromMap(t *testing.T) {
+	var d MapItem
+	err := FromMap(&d, map[string]interface{}{"ID": "12",
+		"t.name": []byte("x"), "tags": []interface{}{"a", "b"},
+		"addr_city": "c"})
+	want := MapItem{12, "x", []string{"a", "b"}, TagAddr{City: "c"}}
+	if err != nil || !reflect.DeepEqual(d, want) {
+		t.Errorf("FromMap: got %+v %v, want %+v", d, err, want)
+	}
+	err = FromMap(&d, map[string]interface{}{"nam": 1})
+	if err == nil || !strings.Contains(err.Error(), `did you mean "name"?`) {
+		t.Errorf("unknown column: %v", err)
+	}
+	if err = FromMap(&d, map[string]interface{}{"id": "x"}); err == nil {
+		t.Error("bad value accepted")
+	}
+	for _, dest := range []interface{}{nil, d, (*MapItem)(nil)} {
+		if err = FromMap(dest, nil); err == nil {
+			t.Errorf("FromMap(%#v) accepted", dest)
+		}
+	}
+}
+
+func TestToMap(t *testing.T) {
+	d := &MapItem{3, "x", []string{"a"}, TagAddr{"s", "c"}}
+	m, err := ToMap(d)
+	want := map[string]interface{}{"id": int64(3), "name": "x",
+		"tags": `["a"]`, "addr_street": "s", "addr_city": "c"}
+	if err != nil || !reflect.DeepEqual(m, want) {
+		t.Errorf("ToMap:\n got %v %v\nwant %v", m, err, want)
+	}
+	m, err = ToMap(d, "Name", "Addr.City")
+	want = map[string]interface{}{"name": "x", "addr_city": "c"}
+	if err != nil || !reflect.DeepEqual(m, want) {
+		t.Errorf("ToMap fields:\n got %v %v\nwant %v", m, err, want)
+	}
+	_, err = ToMap(d, "Nme")
+	if err == nil || !strings.Contains(err.Error(), `did you mean "Name"?`) {
+		t.Errorf("unknown field: %v", err)
+	}
+	if _, err = ToMap(*d); err == nil {
+		t.Error("ToMap on a struct value accepted")
+	}
+}
@@ -0,0 +1,63 @@
+package sqlaux
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Labels 为度量的标签，取值有限，适合直接用作Prometheus等的标签。
+type Labels struct {
+	Op     string // 操作：scan、build、buildargs、exec
+	Struct string // 所操作的结构名，多个时以","连接，未知时为""
+	Table  string // 结构所登记的表名（见Tablename），未知时为""
+}
+
+// Observer 为度量钩子，在每次受观察的操作结束后被调用：l 为其标签，rows 为
+// 行数，d 为耗时，err 为操作的结果。Observer 在操作所在的goroutine中被同步
+// 调用，应尽快返回。
+type Observer func(l Labels, rows int, d time.Duration, err error)
+
+// Observe 注册度量钩子o，如按结构、操作记录计数器和延迟直方图，标签由映射
+// 自动得出，无需逐处埋点。与MapStruct相同，可以在任何时候调用（配置中的
+// InitOnly为true时除外）。
+func Observe(o ...Observer) error {
+	if conf().InitOnly && !isinit() {
+		return fmt.Errorf("Observe: must be called in init()")
+	}
+	for i, f := range o {
+		if f == nil {
+			return fmt.Errorf("Observe: o[%d] is nil", i)
+		}
+	}
+	updatehooks(func(h *hooksT) {
+		n := len(h.observers)
+		h.observers = append(h.observers[:n:n], o...)
+	})
+	return nil
+}
+
+// observe 以操作op、结构ts的标签调用各度量钩子，start为操作开始时间，*rows
+// 为行数，*err 为操作结果。用于defer，故取指针。
+func observe(op string, ts []reflect.Type, start time.Time, rows *int,
+	err *error) {
+	l := Labels{Op: op}
+	names := make([]string, 0, len(ts))
+	tables := make([]string, 0, len(ts))
+	mapping := loadmap()
+	for _, t := range ts {
+		names = append(names, t.Name())
+		if tb := tablename(mapping, t.Name()); tb != "" {
+			tables = append(tables, tb)
+		}
+	}
+	l.Struct = strings.Join(names, ",")
+	if len(tables) == len(ts) {
+		l.Table = strings.Join(tables, ",")
+	}
+	d := time.Since(start)
+	for _, o := range loadhooks().observers {
+		o(l, *rows, d, *err)
+	}
+}
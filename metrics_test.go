@@ -0,0 +1,70 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type MetItem struct {
+	ID int
+}
+
+type MetNote struct {
+	Text string
+}
+
+type metCall struct {
+	l    Labels
+	rows int
+	err  bool
+}
+
+func TestObserve(t *testing.T) {
+	old := loadhooks()
+	defer hooks.Store(old)
+	if _, err := Signature(MetItem{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	if err := MapTable(MetItem{}, "met_items"); err != nil {
+		t.Fatal(err)
+	}
+	var got []metCall
+	if err := Observe(func(l Labels, rows int, d time.Duration,
+		err error) {
+		got = append(got, metCall{l, rows, err != nil})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Observe(nil); err == nil {
+		t.Error("nil observer accepted")
+	}
+
+	rows := testRows(t, []string{"id", "", "text"},
+		[]driver.Value{int64(1), nil, "a"}, []driver.Value{int64(2), nil,
+			"b"})
+	defer rows.Close()
+	var d []*MetItem
+	var n []*MetNote
+	Scan(rows, &d, &n)
+	Buildstr(d)
+	Buildargs(nil, d[0], "ID")
+	ExecContext(context.Background(), tdb, "DELETE FROM met_items")
+	takeExecs()
+	bad := testRows(t, []string{"id"}, []driver.Value{"x"})
+	defer bad.Close()
+	Scan(bad, &d)
+
+	want := []metCall{
+		{Labels{"scan", "MetItem,MetNote", ""}, 2, false},
+		{Labels{"build", "MetItem", "met_items"}, 2, false},
+		{Labels{"buildargs", "MetItem", "met_items"}, 1, false},
+		{Labels{"exec", "", ""}, 1, false},
+		{Labels{"scan", "MetItem", "met_items"}, 0, true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
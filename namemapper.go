@@ -0,0 +1,35 @@
+package sqlaux
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SnakeCase 将驼峰式的字段名转为小写下划线式，连续的大写字母视为一个缩写词：
+// CreatedAt为created_at，UserID为user_id，HTTPServer为http_server。用作
+// Config.NameMapper。
+func SnakeCase(field string) string {
+	rs := []rune(field)
+	var b strings.Builder
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := rs[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				// the last upper of an acronym begins a new word
+				unicode.IsUpper(prev) && i+1 < len(rs) &&
+					unicode.IsLower(rs[i+1]) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// colname 返回字段名field按配置c的缺省列名。
+func colname(c *Config, field string) string {
+	if c.NameMapper != nil {
+		return c.NameMapper(field)
+	}
+	return strings.ToLower(field)
+}
@@ -0,0 +1,74 @@
+package sqlaux
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"ID", "id"},
+		{"Name", "name"},
+		{"CreatedAt", "created_at"},
+		{"UserID", "user_id"},
+		{"HTTPServer", "http_server"},
+		{"Addr2Line", "addr2_line"},
+		{"ÄrgerZähler", "ärger_zähler"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if s := SnakeCase(tt.in); s != tt.want {
+			t.Errorf("SnakeCase(%q): got %q, want %q", tt.in, s, tt.want)
+		}
+	}
+}
+
+type NameOrder struct {
+	ID         int
+	CreatedAt  string
+	UserID     int
+	HTTPServer string `db:"col=srv"`
+}
+
+type NameLate struct {
+	CreatedAt string
+}
+
+type NameBad struct {
+	CreatedAt string
+}
+
+func TestNameMapper(t *testing.T) {
+	if _, err := Signature(NameLate{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	old := GetConfig()
+	defer SetConfig(old)
+	c := GetConfig()
+	c.NameMapper = SnakeCase
+	SetConfig(c)
+
+	s, err := Signature(NameOrder{})
+	if want := []string{"id", "created_at", "user_id", "srv"}; err != nil ||
+		!reflect.DeepEqual(s, want) {
+		t.Errorf("Signature: got %q %v, want %q", s, err, want)
+	}
+	q, err := Buildstr(&NameOrder{1, "x", 2, "h"})
+	if want := `SET id=1,created_at="x",user_id=2,srv="h"`; err != nil ||
+		q != want {
+		t.Errorf("Buildstr:\n got %s %v\nwant %s", q, err, want)
+	}
+	if s, _ = Signature(NameLate{}); !reflect.DeepEqual(s,
+		[]string{"createdat"}) {
+		t.Errorf("mapped before NameMapper: %q", s)
+	}
+
+	c.NameMapper = strings.ToUpper
+	SetConfig(c)
+	_, err = Signature(NameBad{})
+	if err == nil || !strings.Contains(err.Error(),
+		`NameBad.CreatedAt bad NameMapper result "CREATEDAT"`) {
+		t.Errorf("upper case result: %v", err)
+	}
+}
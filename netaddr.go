@@ -0,0 +1,145 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+)
+
+// 以下为net.IP、net.IPNet、net.HardwareAddr字段的等价类型，数据库中为其文本
+// 形式，对应PostgreSQL的INET、CIDR、MACADDR类型，其它数据库的字符串列。nil
+// （零值）与数据库NULL相互对应。
+type (
+	ipT    net.IP
+	ipnetT net.IPNet
+	macT   net.HardwareAddr
+)
+
+var (
+	ipType    = reflect.TypeOf(net.IP(nil))
+	ipnetType = reflect.TypeOf(net.IPNet{})
+	macType   = reflect.TypeOf(net.HardwareAddr(nil))
+	netTypes  = map[reflect.Type]reflect.Type{
+		ipType:    reflect.TypeOf(ipT(nil)),
+		ipnetType: reflect.TypeOf(ipnetT{}),
+		macType:   reflect.TypeOf(macT(nil)),
+	}
+)
+
+// nettext 返回网络类型列src的文本形式，NULL返回""。
+func nettext(src interface{}) (string, error) {
+	switch v := src.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	return "", fmt.Errorf("cannot scan %T into network address", src)
+}
+
+func (p *ipT) Scan(src interface{}) error {
+	s, err := nettext(src)
+	if err != nil || s == "" {
+		*p = nil
+		return err
+	}
+	if i := strings.IndexByte(s, '/'); i != -1 { // INET with netmask
+		s = s[:i]
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		if b, ok := src.([]byte); ok && (len(b) == 4 || len(b) == 16) {
+			ip = append(net.IP(nil), b...) // binary, eg. INET6_ATON()
+		} else {
+			return fmt.Errorf("bad IP address %q", s)
+		}
+	}
+	*p = ipT(ip)
+	return nil
+}
+
+func (p ipT) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return net.IP(p).String(), nil
+}
+
+func (p *ipnetT) Scan(src interface{}) error {
+	s, err := nettext(src)
+	if err != nil || s == "" {
+		*p = ipnetT{}
+		return err
+	}
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return err
+	}
+	*p = ipnetT(*n)
+	return nil
+}
+
+func (p ipnetT) Value() (driver.Value, error) {
+	if p.IP == nil {
+		return nil, nil
+	}
+	n := net.IPNet(p)
+	return n.String(), nil
+}
+
+func (p *macT) Scan(src interface{}) error {
+	s, err := nettext(src)
+	if err != nil || s == "" {
+		*p = nil
+		return err
+	}
+	m, err := net.ParseMAC(s)
+	*p = macT(m)
+	return err
+}
+
+func (p macT) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return net.HardwareAddr(p).String(), nil
+}
+
+// NetWithin 返回“IP地址列col位于网络n中”的条件表达式。PostgreSQL 使用<<=运
+// 算符，MySQL 对INET6_ATON(col)作范围比较，其它数据库不支持。
+func NetWithin(d Dialect, col string, n *net.IPNet) (string, error) {
+	if d == nil || n == nil {
+		return "", fmt.Errorf("NetWithin: no dialect or network argument")
+	}
+	switch d.Name() {
+	case "postgres":
+		return col + " <<= " + d.EscapeString(n.String()), nil
+	case "mysql":
+		lo, hi := n.IP.Mask(n.Mask), make(net.IP, len(n.Mask))
+		if ip4 := lo.To4(); ip4 != nil && len(n.Mask) == net.IPv4len {
+			lo = ip4
+		}
+		for i := range hi {
+			hi[i] = lo[i] | ^n.Mask[i]
+		}
+		return fmt.Sprintf("INET6_ATON(%s) BETWEEN X'%x' AND X'%x'",
+			col, []byte(lo), []byte(hi)), nil
+	}
+	return "", fmt.Errorf("NetWithin: %q not supported", d.Name())
+}
+
+// NetContains 返回“网络列col包含IP地址ip”的条件表达式，仅PostgreSQL支持，使
+// 用>>=运算符。
+func NetContains(d Dialect, col string, ip net.IP) (string, error) {
+	if d == nil || ip == nil {
+		return "", fmt.Errorf("NetContains: no dialect or IP argument")
+	}
+	if d.Name() != "postgres" {
+		return "", fmt.Errorf("NetContains: %q not supported", d.Name())
+	}
+	return col + " >>= " + d.EscapeString(ip.String()), nil
+}
@@ -0,0 +1,79 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"net"
+	"strings"
+	"testing"
+)
+
+type NetHost struct {
+	ID  int
+	IP  net.IP
+	Net net.IPNet
+	Mac net.HardwareAddr
+}
+
+func TestNetScan(t *testing.T) {
+	rows := testRows(t, []string{"id", "ip", "net", "mac"},
+		[]driver.Value{int64(1), "10.1.2.3/24", []byte("10.0.0.0/8"),
+			"08:00:2b:01:02:03"},
+		[]driver.Value{int64(2), nil, nil, nil})
+	defer rows.Close()
+	var d []*NetHost
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	h := d[0]
+	if !h.IP.Equal(net.ParseIP("10.1.2.3")) ||
+		h.Net.String() != "10.0.0.0/8" ||
+		h.Mac.String() != "08:00:2b:01:02:03" {
+		t.Errorf("scanned %v %v %v", h.IP, h.Net.String(), h.Mac)
+	}
+	if d[1].IP != nil || d[1].Net.IP != nil || d[1].Mac != nil {
+		t.Errorf("NULL scanned as %v", *d[1])
+	}
+	s, err := Buildstr(d)
+	want := `(id,ip,net,mac) VALUES (1,"10.1.2.3","10.0.0.0/8",` +
+		`"08:00:2b:01:02:03"),(2,NULL,NULL,NULL)`
+	if err != nil || s != want {
+		t.Errorf("Buildstr:\n got %s %v\nwant %s", s, err, want)
+	}
+	s, err = CreateTableSQL(NetHost{}, "t", PostgreSQL)
+	if err != nil || !strings.Contains(s, `"ip" inet,`) ||
+		!strings.Contains(s, `"net" cidr,`) ||
+		!strings.Contains(s, `"mac" macaddr`) {
+		t.Errorf("CreateTableSQL: %s %v", s, err)
+	}
+
+	bad := testRows(t, []string{"ip"}, []driver.Value{"10.1.2"})
+	defer bad.Close()
+	if err = Scan(bad, &d); err == nil {
+		t.Error("bad IP accepted")
+	}
+}
+
+func TestNetWithin(t *testing.T) {
+	_, n, _ := net.ParseCIDR("10.1.0.0/16")
+	tests := []struct {
+		d    Dialect
+		want string
+	}{
+		{MySQL, "INET6_ATON(ip) BETWEEN X'0a010000' AND X'0a01ffff'"},
+		{PostgreSQL, "ip <<= '10.1.0.0/16'"},
+		{SQLite, ""},
+	}
+	for _, tt := range tests {
+		s, err := NetWithin(tt.d, "ip", n)
+		if s != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("NetWithin(%s): %s %v", tt.d.Name(), s, err)
+		}
+	}
+	s, err := NetContains(PostgreSQL, "net", net.ParseIP("10.1.1.1"))
+	if err != nil || s != "net >>= '10.1.1.1'" {
+		t.Errorf("NetContains: %s %v", s, err)
+	}
+	if _, err = NetContains(MySQL, "net", net.ParseIP("10.1.1.1")); err == nil {
+		t.Error("NetContains for MySQL accepted")
+	}
+}
@@ -0,0 +1,64 @@
+package sqlaux
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CheckOrphans 在db上检查stru所登记（见Tablename）的表中的孤儿行：外键字段
+// （tag中有"ref=表.列"）不为NULL，但所引用的表中没有对应行。这适用于禁用了
+// 外键约束（或从未建立之）的数据库，如定期的数据巡检：
+//
+//	ParentID int64 `db:"ref=orders.id"`
+//
+// 生成：SELECT COUNT(*) FROM 表 o WHERE o.parent_id IS NOT NULL AND NOT
+// EXISTS (SELECT 1 FROM orders r WHERE r.id=o.parent_id)。没有孤儿行时返回
+// nil，否则返回的错误中列出各外键的孤儿行数。db 为nil时由路由函数选择。
+// stru 以变量值的形式作参数，可以取零值，须已登记表名。
+func CheckOrphans(ctx context.Context, db Querier, stru interface{}) error {
+	t, err := structarg(stru)
+	if err != nil {
+		return fmt.Errorf("CheckOrphans: %v", err)
+	}
+	mapping := loadmap()
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return fmt.Errorf("CheckOrphans: %q has no mapping", t)
+	}
+	table := tablename(mapping, t.Name())
+	if table == "" {
+		return fmt.Errorf("CheckOrphans: %q has no registered table", t)
+	}
+	if db, err = route(ctx, db, RouteRead, t.Name()); err != nil {
+		return fmt.Errorf("CheckOrphans: %v", err)
+	}
+
+	var diag []string
+	for _, n := range e.name.([]string) {
+		m := mapping["0."+t.Name()+"."+n]
+		if m.info == nil || m.info.ref == "" {
+			continue
+		}
+		col, p := m.name.(string), strings.Split(m.info.ref, ".")
+		query := "SELECT COUNT(*) FROM " + table + " o WHERE o." + col +
+			" IS NOT NULL AND NOT EXISTS (SELECT 1 FROM " + p[0] +
+			" r WHERE r." + p[1] + "=o." + col + ")"
+		query, args, err := rewrite(ctx, query, nil)
+		if err != nil {
+			return fmt.Errorf("CheckOrphans: %v", err)
+		}
+		var cnt int64
+		if err = queryRow(ctx, db, query, args, &cnt); err != nil {
+			return fmt.Errorf("CheckOrphans: %s.%s %v", t.Name(), n, err)
+		}
+		if cnt > 0 {
+			diag = append(diag, fmt.Sprintf("%d rows of %s.%s reference "+
+				"missing %s", cnt, table, col, m.info.ref))
+		}
+	}
+	if len(diag) > 0 {
+		return fmt.Errorf("CheckOrphans: %s", strings.Join(diag, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type OrphanLine struct {
+	ID      int64  `db:"pk"`
+	OrderID int64  `db:"col=order_id ref=orders.id"`
+	SKU     string `db:"ref=products.sku"`
+}
+
+type OrphanBadRef struct {
+	A int `db:"ref=orders"`
+}
+
+func TestCheckOrphans(t *testing.T) {
+	if _, err := Signature(OrphanLine{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	if err := MapTable(OrphanLine{}, "order_lines"); err != nil {
+		t.Fatal(err)
+	}
+	s, err := CreateTableSQL(OrphanLine{}, "order_lines", PostgreSQL)
+	if err != nil || !strings.Contains(s, `FOREIGN KEY ("order_id") `+
+		`REFERENCES "orders" ("id"),`) || !strings.Contains(s,
+		`FOREIGN KEY ("sku") REFERENCES "products" ("sku")`) {
+		t.Errorf("CreateTableSQL: %s %v", s, err)
+	}
+
+	orders := "SELECT COUNT(*) FROM order_lines o WHERE o.order_id IS NOT " +
+		"NULL AND NOT EXISTS (SELECT 1 FROM orders r WHERE " +
+		"r.id=o.order_id)"
+	products := "SELECT COUNT(*) FROM order_lines o WHERE o.sku IS NOT " +
+		"NULL AND NOT EXISTS (SELECT 1 FROM products r WHERE r.sku=o.sku)"
+	ctx := context.Background()
+	tresult(orders, []string{"count"}, []driver.Value{int64(0)})
+	tresult(products, []string{"count"}, []driver.Value{int64(0)})
+	if err = CheckOrphans(ctx, tdb, &OrphanLine{}); err != nil {
+		t.Errorf("no orphans: %v", err)
+	}
+	tresult(products, []string{"count"}, []driver.Value{int64(3)})
+	err = CheckOrphans(ctx, tdb, OrphanLine{})
+	if want := "CheckOrphans: 3 rows of order_lines.sku reference missing " +
+		"products.sku"; err == nil || err.Error() != want {
+		t.Errorf("orphans:\n got %v\nwant %s", err, want)
+	}
+
+	for _, stru := range []interface{}{nil, OrphanBadRef{}, CheckUser{}} {
+		if err = CheckOrphans(ctx, tdb, stru); err == nil {
+			t.Errorf("CheckOrphans(%#v) accepted", stru)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError 为接收或拼接数据时捕获的panic，通常源于错误的映射或调用者类型的
+// Scan、Value等方法。Scan、Buildstr、Buildargs等导出函数将其转换为错误返回，
+// 使一处错误的数据不致使整个服务崩溃；返回的错误可用errors.As取得
+// *PanicError。
+type PanicError struct {
+	Struct string      // 发生panic时正处理的结构名，未知时为""
+	Column string      // 正处理的字段所映射的列名，未知时为""
+	Value  interface{} // recover()的返回值
+	Stack  []byte      // 发生panic时的调用栈
+}
+
+func (e *PanicError) Error() string {
+	at := e.Struct
+	if e.Column != "" {
+		at += "." + e.Column
+	}
+	if at == "" {
+		return fmt.Sprintf("panic: %v", e.Value)
+	}
+	return fmt.Sprintf("panic at %s: %v", at, e.Value)
+}
+
+// recovered 将panic转换为*PanicError存入*err，fn 不为""时以其为错误前缀。
+// stru、col 指向调用者随处理进度更新的结构名和列名。须直接defer调用：
+//
+//	defer recovered(&err, "", &stru, &col)
+func recovered(err *error, fn string, stru, col *string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if fn == "" {
+		*err = topanic(r, *stru, *col)
+	} else {
+		*err = fmt.Errorf("%s: %w", fn, topanic(r, *stru, *col))
+	}
+}
+
+// topanic 返回处理结构stru的列col时发生的panic r的*PanicError。
+func topanic(r interface{}, stru, col string) *PanicError {
+	return &PanicError{Struct: stru, Column: col, Value: r,
+		Stack: debug.Stack()}
+}
+
+// guardT 包装接收列值的sql.Scanner，将其Scan方法中的panic转换为错误返回。
+// panic 不能越过rows.Scan：它持有的锁将不被释放，之后关闭rows时死锁。
+type guardT struct {
+	s         sql.Scanner
+	stru, col string
+}
+
+func (g *guardT) Scan(src interface{}) (err error) {
+	defer recovered(&err, "", &g.stru, &g.col)
+	return g.s.Scan(src)
+}
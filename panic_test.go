@@ -0,0 +1,47 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// panicky 在Scan、Value时panic。
+type panicky struct{}
+
+func (*panicky) Scan(v interface{}) error { panic("scan boom") }
+
+func (panicky) Value() (driver.Value, error) { panic("value boom") }
+
+type PanicRow struct {
+	ID  int
+	Bad panicky `db:"col=bad_col"`
+}
+
+func TestPanicRecovery(t *testing.T) {
+	check := func(name string, err error, v string) {
+		t.Helper()
+		var p *PanicError
+		if !errors.As(err, &p) || p.Struct != "PanicRow" ||
+			p.Column != "bad_col" || p.Value != v || len(p.Stack) == 0 {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	rows := testRows(t, []string{"id", "bad_col"},
+		[]driver.Value{int64(1), "x"})
+	defer rows.Close()
+	var d []*PanicRow
+	check("Scan", Scan(rows, &d), "scan boom")
+	if err := rows.Close(); err != nil { // not deadlocked
+		t.Error(err)
+	}
+
+	_, err := Buildstr(&PanicRow{})
+	check("Buildstr", err, "value boom")
+	_, err = Buildargs(nil, &PanicRow{})
+	check("Buildargs", err, "value boom")
+	want := "Buildargs: panic at PanicRow.bad_col: value boom"
+	if err == nil || err.Error() != want {
+		t.Errorf("Buildargs:\n got %v\nwant %s", err, want)
+	}
+}
@@ -0,0 +1,157 @@
+package sqlaux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// splitTop 按sep（单字节）切分s的顶层部分，忽略括号和引号内的sep。
+func splitTop(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// keyword 返回s中第一个位于顶层（括号、引号外）的关键字kw（不区分大小写）的
+// 位置，没有时返回-1。
+func keyword(s, kw string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && len(s)-i >= len(kw) &&
+			strings.EqualFold(s[i:i+len(kw)], kw) &&
+			(i == 0 || !isword(s[i-1])) &&
+			(i+len(kw) == len(s) || !isword(s[i+len(kw)])):
+			return i
+		}
+	}
+	return -1
+}
+
+// isword 报告c是否可以是SQL标识符的组成字符。
+func isword(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' ||
+		unicode.IsLetter(rune(c)) || c >= 0x80
+}
+
+// SelectColumns 解析一条SELECT语句的选择列，返回各列的结果列名（小写），供静
+// 态检查等工具使用。空列分隔符的列名为""，通配为"*"，表达式列须有别名。
+func SelectColumns(query string) ([]string, error) {
+	return selectcols(query)
+}
+
+// selectcols 解析一条SELECT语句的选择列，返回各列的结果列名（小写）。空列分
+// 隔符（见Scan）的列名为""，通配为"*"。无法确定列名的表达式（没有别名）报错。
+func selectcols(q string) ([]string, error) {
+	i := keyword(q, "SELECT")
+	if i == -1 {
+		return nil, fmt.Errorf("no SELECT in %q", q)
+	}
+	q = q[i+len("SELECT"):]
+	if j := keyword(q, "FROM"); j != -1 {
+		q = q[:j]
+	}
+	q = strings.TrimSpace(q)
+	for _, kw := range []string{"DISTINCT", "ALL"} {
+		if keyword(q, kw) == 0 {
+			q = q[len(kw):]
+		}
+	}
+
+	items := splitTop(q, ',')
+	cols := make([]string, len(items))
+	for k, it := range items {
+		it = strings.TrimSpace(it)
+		if it == "''" || it == `""` {
+			continue // delimiter
+		}
+		if j := keyword(it, "AS"); j != -1 {
+			it = strings.TrimSpace(it[j+2:])
+		} else if f := strings.Fields(it); len(f) > 1 &&
+			isident(strings.ToLower(strings.Trim(f[len(f)-1], "`\""))) &&
+			!strings.HasSuffix(f[len(f)-2], ".") {
+			it = f[len(f)-1] // implicit alias
+		} else if d := strings.LastIndex(it, "."); d != -1 &&
+			!strings.ContainsAny(it, "()") {
+			it = it[d+1:] // qualified column
+		}
+		it = strings.ToLower(strings.Trim(it, "`\""))
+		if it != "*" && (it == "" || !isident(it)) {
+			return nil, fmt.Errorf("cannot name select item %q", items[k])
+		}
+		cols[k] = it
+	}
+	return cols, nil
+}
+
+// rebase 将s中（引号外）的PostgreSQL风格占位符$n改写为$(n+off)。
+func rebase(s string, off int) string {
+	if off == 0 {
+		return s
+	}
+	return renumber(s, func(n int) int { return n + off })
+}
+
+// renumber 将s中（引号外）的PostgreSQL风格占位符$n改写为$fn(n)。
+func renumber(s string, fn func(n int) int) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '$' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i + 1
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.Atoi(s[i+1 : j])
+			b.WriteString("$" + strconv.Itoa(fn(n)))
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package sqlaux
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// PartitionResolver 为分区解析函数，按数据行row（类型形如*struct）返回分区表
+// table中该行所属分区的表名，供写入时直接定位分区，在分区较多的数据库上可以
+// 省去路由开销。
+type PartitionResolver func(table string, row interface{}) (string, error)
+
+// MapPartition 为已映射结构stru登记分区解析函数r，即映射项"p.struct名"。与
+// MapStruct相同，可以在任何时候调用（配置中的InitOnly为true时除外）。
+// stru 以变量值的形式作参数，可以取零值。
+func MapPartition(stru interface{}, r PartitionResolver) error {
+	if conf().InitOnly && !isinit() {
+		return fmt.Errorf("MapPartition: must be called in init()")
+	}
+	t, err := structarg(stru)
+	if err != nil {
+		return fmt.Errorf("MapPartition: %v", err)
+	}
+	if r == nil {
+		return fmt.Errorf("MapPartition: nil resolver")
+	}
+	err = update(func(mapping map[string]entryT) error {
+		if _, ok := mapping[t.Name()]; !ok {
+			return fmt.Errorf("%q has no mapping", t)
+		}
+		mapping["p."+t.Name()] = entryT{name: r}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("MapPartition: %v", err)
+	}
+	return nil
+}
+
+// Partition 返回数据行row所属的分区表名，row 的结构没有登记分区解析函数时返
+// 回table。
+//
+// 约定：row的类型形如*struct。
+func Partition(table string, row interface{}) (string, error) {
+	v := reflect.ValueOf(row)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct ||
+		v.IsNil() {
+		return "", fmt.Errorf("Partition: row not like *struct")
+	}
+	e, ok := loadmap()["p."+v.Type().Elem().Name()]
+	if !ok {
+		return table, nil
+	}
+	p, err := e.name.(PartitionResolver)(table, row)
+	if err != nil {
+		return "", fmt.Errorf("Partition: %v", err)
+	}
+	return p, nil
+}
+
+// Partitions 将data中的行按所属分区分组，返回分区表名到该分区各行的映射，
+// 各组的类型与data相同、行序不变，可分别用Buildstr等生成各分区的INSERT语句。
+//
+// 约定：data的类型形如[]*struct。
+func Partitions(table string, data interface{}) (map[string]interface{},
+	error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Ptr ||
+		v.Type().Elem().Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Partitions: data not like []*struct")
+	}
+	groups := make(map[string]reflect.Value)
+	for i := 0; i < v.Len(); i++ {
+		p, err := Partition(table, v.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("Partitions: %v", err)
+		}
+		g, ok := groups[p]
+		if !ok {
+			g = reflect.MakeSlice(v.Type(), 0, 1)
+		}
+		groups[p] = reflect.Append(g, v.Index(i))
+	}
+	r := make(map[string]interface{}, len(groups))
+	for p, g := range groups {
+		r[p] = g.Interface()
+	}
+	return r, nil
+}
+
+// ByTime 返回按时间范围分区的解析函数：分区表名为"table_"加上行中field字段
+// （time.Time类型，嵌套结构成员为全名）按layout格式化的值，如layout为
+// "200601"时按月分区，2021年3月的行属于"orders_202103"。
+func ByTime(field, layout string) PartitionResolver {
+	return func(table string, row interface{}) (string, error) {
+		f := fieldOf(reflect.ValueOf(row).Elem(), field)
+		if !f.IsValid() {
+			return "", fmt.Errorf("no field %q", field)
+		}
+		t, ok := f.Interface().(time.Time)
+		if !ok {
+			return "", fmt.Errorf("field %q not time.Time", field)
+		}
+		return table + "_" + t.Format(layout), nil
+	}
+}
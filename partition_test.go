@@ -0,0 +1,109 @@
+package sqlaux
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type PartEvent struct {
+	ID int `db:"pk"`
+	At time.Time
+}
+
+func TestMapPartitionAtRuntime(t *testing.T) {
+	if err := MapStruct(PartEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	mar := time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)
+	apr := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	rows := []*PartEvent{{1, mar}, {2, apr}, {3, mar}}
+
+	// readers race with the registration
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				p, err := Partition("events", rows[0])
+				if err != nil || p != "events" && p != "events_202103" {
+					t.Errorf("got %q %v", p, err)
+					return
+				}
+			}
+		}()
+	}
+	err := MapPartition(PartEvent{}, ByTime("At", "200601"))
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := Partitions("events", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, a := g["events_202103"].([]*PartEvent), g["events_202104"].([]*PartEvent)
+	if len(g) != 2 || len(m) != 2 || m[0].ID != 1 || m[1].ID != 3 ||
+		len(a) != 1 || a[0].ID != 2 {
+		t.Errorf("got %v", g)
+	}
+
+	type PartUnmapped struct{ ID int }
+	if err = MapPartition(PartUnmapped{}, ByTime("At", "2006")); err == nil {
+		t.Error("unmapped struct accepted")
+	}
+}
+
+type PartLog struct {
+	ID   int
+	Day  time.Time
+	Kind string
+}
+
+func TestPartition(t *testing.T) {
+	day := time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)
+	if p, err := Partition("logs", &PartLog{Day: day}); err != nil ||
+		p != "logs" {
+		t.Errorf("without resolver: %q %v", p, err)
+	}
+	if _, err := Signature(PartLog{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	err := MapPartition(&PartLog{}, func(table string,
+		row interface{}) (string, error) {
+		if k := row.(*PartLog).Kind; k != "" {
+			return table + "_" + k, nil
+		}
+		return ByTime("Day", "2006")(table, row)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []*PartLog{{1, day, ""}, {2, day, "x"}}
+	g, err := Partitions("logs", rows)
+	a, b := g["logs_2021"].([]*PartLog), g["logs_x"].([]*PartLog)
+	if err != nil || len(g) != 2 || len(a) != 1 || a[0].ID != 1 ||
+		len(b) != 1 {
+		t.Errorf("Partitions: %v %v", g, err)
+	}
+	if _, err = Partitions("logs", rows[0]); err == nil {
+		t.Error("Partitions of *struct accepted")
+	}
+	if _, err = Partition("logs", PartLog{}); err == nil {
+		t.Error("Partition of a struct value accepted")
+	}
+
+	for _, field := range []string{"Dy", "Kind"} {
+		if _, err = ByTime(field, "2006")("logs", rows[0]); err == nil {
+			t.Errorf("ByTime(%q) accepted", field)
+		}
+	}
+	if err = MapPartition(nil, ByTime("Day", "2006")); err == nil {
+		t.Error("nil stru accepted")
+	}
+	if err = MapPartition(PartLog{}, nil); err == nil {
+		t.Error("nil resolver accepted")
+	}
+}
@@ -0,0 +1,132 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// ScanPivot 将“键/值”形状的结果集（如EAV表、配置表）透视为每个实体一个结构
+// 变量，追加到dest。结果集中除键列、值列外的其它列为实体列，须有映射，且结
+// 构须有主键（tag中有"pk"），主键相同的行属于同一实体；键列的值为列名，值列
+// 的值按FromMap的规则存入该列名所映射的字段，没有映射的键忽略。键列、值列由
+// 结构中的空白字段声明，如：
+//
+//	type Setting struct {
+//		_      struct{} `db:"pivot=name:value"`
+//		UserID int      `db:"pk col=user_id"`
+//		Theme  string
+//		Lang   string
+//	}
+//
+// 对应"SELECT user_id,name,value FROM settings ORDER BY user_id"。接收后
+// ScanPivot不主动关闭rows。
+//
+// 约定：dest的类型形如*[]*struct。
+func ScanPivot(rows *sql.Rows, dest interface{}) error {
+	t, err := destof([]interface{}{dest}, 0)
+	if err != nil {
+		return fmt.Errorf("ScanPivot: %v", err)
+	}
+	st := t.Elem().Elem().Elem()
+	stru := st.Name()
+	mapping, err := automap(loadmap(), st)
+	if err != nil {
+		return fmt.Errorf("ScanPivot: %v", err)
+	}
+	if _, ok := mapping[stru]; !ok {
+		return fmt.Errorf("ScanPivot: %q has no mapping", stru)
+	}
+	key, val, err := pivotcols(st)
+	if err != nil {
+		return fmt.Errorf("ScanPivot: %v", err)
+	}
+	pks := pkeys(mapping, stru)
+	if len(pks) == 0 {
+		return fmt.Errorf("ScanPivot: %q has no pk", stru)
+	}
+
+	// entity columns are received as Scan does, key and value as is
+	col, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("ScanPivot: %v", err)
+	}
+	ref := make([]entryT, len(col))
+	ki, vi := -1, -1
+	for i, c := range col {
+		c = strings.ToLower(c[strings.LastIndex(c, ".")+1:])
+		switch c {
+		case key:
+			ki = i
+		case val:
+			vi = i
+		default:
+			e, ok := mapping["1."+stru+"."+c]
+			if !ok {
+				return fmt.Errorf("ScanPivot: column %q has no mapping", c)
+			}
+			ref[i] = e
+		}
+	}
+	if ki == -1 || vi == -1 {
+		return fmt.Errorf("ScanPivot: no column %q or %q", key, val)
+	}
+
+	rs := reflect.ValueOf(dest).Elem()
+	index := make(map[string]unsafe.Pointer) // primary key --> entity address
+	var k sql.NullString
+	var v interface{}
+	ptr := make([]interface{}, len(col))
+	ptr[ki], ptr[vi] = &k, &v
+	for rows.Next() {
+		tmp := reflect.New(st)
+		for i := range ref {
+			if i != ki && i != vi {
+				ptr[i] = ref[i].receiver(ref[i].addrw(tmp.UnsafePointer()),
+					conf())
+			}
+		}
+		if err = rows.Scan(ptr...); err != nil {
+			return fmt.Errorf("ScanPivot: %v", err)
+		}
+		p, ok := index[pkey(tmp.UnsafePointer(), pks)]
+		if !ok {
+			p = tmp.UnsafePointer()
+			index[pkey(p, pks)] = p
+			rs.Set(reflect.Append(rs, tmp))
+		}
+		e, ok := mapping["1."+stru+"."+strings.ToLower(k.String)]
+		if !ok || !k.Valid {
+			continue // unknown attribute
+		}
+		if err = e.assign(e.addrw(p), v); err != nil {
+			return fmt.Errorf("ScanPivot: key %q %v", k.String, err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("ScanPivot: %v", err)
+	}
+	return nil
+}
+
+// pivotcols 返回结构t中空白字段tag所声明的透视键列名和值列名。
+func pivotcols(t reflect.Type) (string, string, error) {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Name == "_" {
+			kv, err := parsetag(f.Tag.Get(conf().Tag))
+			if err != nil {
+				return "", "", err
+			}
+			if s, ok := kv["pivot"]; ok {
+				p := strings.Index(s, ":")
+				if p <= 0 || p == len(s)-1 {
+					return "", "", fmt.Errorf("bad tagged 'pivot' %q", s)
+				}
+				return strings.ToLower(s[:p]), strings.ToLower(s[p+1:]), nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("%q has no tagged 'pivot'", t.Name())
+}
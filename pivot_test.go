@@ -0,0 +1,46 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type PivotSetting struct {
+	_      struct{} `db:"pivot=name:value"`
+	UserID int      `db:"pk col=user_id"`
+	Theme  string
+	Size   int
+	Lang   *string
+}
+
+func TestScanPivot(t *testing.T) {
+	rows := testRows(t, []string{"user_id", "name", "value"},
+		[]driver.Value{int64(1), "theme", "dark"},
+		[]driver.Value{int64(1), "size", "12"},
+		[]driver.Value{int64(1), "unknown", "x"},
+		[]driver.Value{int64(2), "LANG", []byte("en")},
+		[]driver.Value{int64(2), nil, "x"})
+	defer rows.Close()
+	d := []*PivotSetting{{UserID: 9}}
+	if err := ScanPivot(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	en := "en"
+	want := []*PivotSetting{{UserID: 9},
+		{UserID: 1, Theme: "dark", Size: 12}, {UserID: 2, Lang: &en}}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("got %v %v %v", *d[0], *d[1], *d[2])
+	}
+
+	bad := testRows(t, []string{"user_id", "name", "value"},
+		[]driver.Value{int64(1), "size", "big"})
+	defer bad.Close()
+	if err := ScanPivot(bad, &d); err == nil {
+		t.Error("bad value accepted")
+	}
+	var u []*UniqLine
+	if err := ScanPivot(bad, &u); err == nil {
+		t.Error("struct without pivot accepted")
+	}
+}
@@ -0,0 +1,132 @@
+package sqlaux
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// pkeys 返回mp中结构stru的主键（tag中有"pk"）字段映射项，按字段定义顺序。
+func pkeys(mp map[string]entryT, stru string) []entryT {
+	var pks []entryT
+	for _, f := range mp[stru].name.([]string) {
+		if e := mp["0."+stru+"."+f]; e.info != nil && e.info.pk {
+			pks = append(pks, e)
+		}
+	}
+	return pks
+}
+
+// nonpkeys 返回mp中结构stru除主键外的所有可写字段名，按字段定义顺序。
+func nonpkeys(mp map[string]entryT, stru string) []string {
+	all := mp[stru].name.([]string)
+	if w, ok := mp["w."+stru]; ok { // except computed ones
+		all = w.name.([]string)
+	}
+	var fs []string
+	for _, f := range all {
+		if e := mp["0."+stru+"."+f]; e.info == nil || !e.info.pk {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+// pkey 返回基址为p的结构变量的主键pks的值串，用作去重等的键。
+func pkey(p unsafe.Pointer, pks []entryT) string {
+	var k strings.Builder
+	for _, e := range pks {
+		v := reflect.NewAt(e.typ, e.addr(p)).Elem()
+		fmt.Fprintf(&k, "%#v\x00", v.Interface())
+	}
+	return k.String()
+}
+
+// BuildUpdateByPK 与Buildstr对*struct相同，但在值串之后拼接按主键定位该行的
+// 条件："SET 列名1=值1,... WHERE 主键列1=值1 AND ..."，用于
+// "UPDATE 表 "+...。field 缺省时为除主键外的所有可写字段。
+//
+// 约定：data的类型形如*struct，结构须有主键（tag中有"pk"），field的写法同
+// Buildstr。
+func BuildUpdateByPK(data interface{}, field ...string) (string, error) {
+	c := conf()
+	v, mapping, pks, err := pkdata(data)
+	if err != nil {
+		return "", fmt.Errorf("BuildUpdateByPK: %v", err)
+	}
+	if len(field) == 0 { // default writable fields except primary keys
+		field = nonpkeys(mapping, v.Type().Elem().Name())
+		if len(field) == 0 { // setbuild would default to all fields
+			return "", fmt.Errorf("BuildUpdateByPK: no field to set")
+		}
+	}
+	var sql strings.Builder
+	if err = setbuild(&sql, c.Dialect, c, mapping, v, field...); err != nil {
+		return "", fmt.Errorf("BuildUpdateByPK: %v", err)
+	}
+	sql.WriteString(" ")
+	if err = pkwhere(&sql, c.Dialect, c, v, pks); err != nil {
+		return "", fmt.Errorf("BuildUpdateByPK: %v", err)
+	}
+	return sql.String(), nil
+}
+
+// BuildDeleteByPK 返回按主键定位data所对应行的条件：
+// "WHERE 主键列1=值1 AND ..."，用于"DELETE FROM 表 "+...。
+//
+// 约定：data的类型形如*struct，结构须有主键（tag中有"pk"）。
+func BuildDeleteByPK(data interface{}) (string, error) {
+	c := conf()
+	v, _, pks, err := pkdata(data)
+	if err != nil {
+		return "", fmt.Errorf("BuildDeleteByPK: %v", err)
+	}
+	var sql strings.Builder
+	if err = pkwhere(&sql, c.Dialect, c, v, pks); err != nil {
+		return "", fmt.Errorf("BuildDeleteByPK: %v", err)
+	}
+	return sql.String(), nil
+}
+
+// pkdata 检查data形如*struct且不为nil、其结构有主键，返回其值、映射和主键
+// 字段映射项。
+func pkdata(data interface{}) (reflect.Value, map[string]entryT, []entryT,
+	error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		return v, nil, nil, fmt.Errorf("argument 'data' bad type %T", data)
+	}
+	t := v.Elem().Type()
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return v, nil, nil, err
+	}
+	pks := pkeys(mapping, t.Name())
+	if len(pks) == 0 {
+		return v, nil, nil, fmt.Errorf("%q has no primary key", t)
+	}
+	return v, mapping, pks, nil
+}
+
+// pkwhere 向w写入按主键pks定位v（*struct）的条件："WHERE 主键列1=值1 AND
+// ..."。主键值为NULL（nil指针）时报错：条件永远不成立。
+func pkwhere(w io.Writer, d Dialect, c *Config, v reflect.Value,
+	pks []entryT) error {
+	io.WriteString(w, "WHERE ")
+	for i, e := range pks {
+		if i > 0 {
+			io.WriteString(w, " AND ")
+		}
+		ptr := reflect.NewAt(e.typ, e.addr(v.UnsafePointer()))
+		if e.typ.Kind() == reflect.Ptr && ptr.Elem().IsNil() {
+			return fmt.Errorf("primary key %q is NULL", e.name)
+		}
+		if err := e.value(w, d, c, e.name.(string)+"=", ptr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package sqlaux
+
+import "testing"
+
+type PkLine struct {
+	OrderID int `db:"pk col=order_id"`
+	Seq     int `db:"pk"`
+	Qty     int
+	Note    *string
+	Total   int `db:"expr='qty * 2'"`
+}
+
+type PkOnly struct {
+	ID int `db:"pk"`
+}
+
+type PkRef struct {
+	ID   *int `db:"pk"`
+	Name string
+}
+
+type PkNone struct {
+	ID int
+}
+
+func TestBuildByPK(t *testing.T) {
+	l := &PkLine{OrderID: 7, Seq: 2, Qty: 3}
+	s, err := BuildUpdateByPK(l)
+	if want := "SET qty=3,note=NULL WHERE order_id=7 AND seq=2"; err != nil ||
+		s != want {
+		t.Errorf("BuildUpdateByPK:\n got %s %v\nwant %s", s, err, want)
+	}
+	c := GetConfig()
+	defer SetConfig(c)
+	SetConfig(With(WithDialect(PostgreSQL)))
+	l.Note = new(string)
+	s, err = BuildUpdateByPK(l, "Note")
+	if want := "SET note='' WHERE order_id=7 AND seq=2"; err != nil ||
+		s != want {
+		t.Errorf("PostgreSQL:\n got %s %v\nwant %s", s, err, want)
+	}
+	s, err = BuildDeleteByPK(l)
+	if want := "WHERE order_id=7 AND seq=2"; err != nil || s != want {
+		t.Errorf("BuildDeleteByPK:\n got %s %v\nwant %s", s, err, want)
+	}
+	if s, err = BuildUpdateByPK(&PkOnly{1}); err == nil {
+		t.Errorf("primary key only: %s", s)
+	}
+	if _, err = BuildUpdateByPK(l, "Totl"); err == nil {
+		t.Error("unknown field accepted")
+	}
+	for _, data := range []interface{}{PkLine{}, (*PkLine)(nil),
+		&PkNone{}, &PkRef{}} {
+		if _, err = BuildDeleteByPK(data); err == nil {
+			t.Errorf("BuildDeleteByPK(%#v) accepted", data)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package sqlaux
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Join 用sep连接各片段part，合并其参数。各part使用PostgreSQL风格的$n占位符
+// （各自从$1开始）时，按其前各part的参数个数自动重新编号，因而可以独立地生
+// 成各片段再组合，如WHERE子句的各个条件。
+func Join(sep string, part ...Fragment) Fragment {
+	var r Fragment
+	var sql strings.Builder
+	for i, p := range part {
+		if i > 0 {
+			sql.WriteString(sep)
+		}
+		sql.WriteString(rebase(p.SQL, len(r.Args)))
+		r.Args = append(r.Args, p.Args...)
+	}
+	r.SQL = sql.String()
+	return r
+}
+
+// Rebase 返回f的副本，其$n占位符（从$1开始）改为从$start开始编号，用于将f
+// 嵌入已有start-1个参数的语句。start 小于1时视为1。
+func (f Fragment) Rebase(start int) Fragment {
+	if start < 1 {
+		start = 1
+	}
+	return Fragment{rebase(f.SQL, start-1), f.Args}
+}
+
+// Compact 返回f的副本，其中相等的参数只保留首次出现的一个，引用它们的$n占位
+// 符改为同一个，并按保留参数的顺序重新编号，从而同一个值在语句中多次出现时只
+// 传递一次。仅比较布尔、数值、字符串、[]byte和time.Time类型的参数，其他参数
+// 总是保留。f 不含$n占位符（如使用?占位符）时原样返回。
+//
+// 注意：PostgreSQL按首次出现推断参数类型，同一参数用于类型不同的上下文时可能
+// 报错，这时应加类型转换（如$1::text）或不合并。
+func (f Fragment) Compact() Fragment {
+	if !strings.Contains(f.SQL, "$") {
+		return f
+	}
+	to := make([]int, len(f.Args)) // old index -> new $n
+	var args []interface{}
+	for i, a := range f.Args {
+		to[i] = len(args) + 1
+		for j, b := range args {
+			if sameArg(a, b) {
+				to[i] = j + 1
+				break
+			}
+		}
+		if to[i] == len(args)+1 {
+			args = append(args, a)
+		}
+	}
+	return Fragment{renumber(f.SQL, func(n int) int {
+		if n >= 1 && n <= len(to) {
+			return to[n-1]
+		}
+		return n // left for the caller to find
+	}), args}
+}
+
+// sameArg 报告参数a、b是否为相同类型的相等值，见Compact。
+func sameArg(a, b interface{}) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || a == nil {
+		return false
+	}
+	switch x := a.(type) {
+	case []byte:
+		return bytes.Equal(x, b.([]byte))
+	case time.Time:
+		return x == b.(time.Time)
+	}
+	switch reflect.TypeOf(a).Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32,
+		reflect.Float64, reflect.String:
+		return a == b
+	}
+	return false
+}
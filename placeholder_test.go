@@ -0,0 +1,46 @@
+package sqlaux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	f := Join(" AND ", Fragment{"a=$1", []interface{}{1}},
+		Fragment{"b IN ($1,$2) AND c='$1'", []interface{}{2, 3}},
+		Fragment{"d IS NULL", nil}, Fragment{"e=$1", []interface{}{4}})
+	want := Fragment{"a=$1 AND b IN ($2,$3) AND c='$1' AND d IS NULL AND " +
+		"e=$4", []interface{}{1, 2, 3, 4}}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("Join:\n got %v\nwant %v", f, want)
+	}
+	if f = Join(",", Fragment{"?", []interface{}{1}},
+		Fragment{"?", []interface{}{2}}); f.SQL != "?,?" {
+		t.Errorf("Join ?: %v", f)
+	}
+	if f = (Fragment{"x=$1 OR y=$2", nil}).Rebase(3); f.SQL != "x=$3 OR y=$4" {
+		t.Errorf("Rebase: %v", f)
+	}
+	if f = (Fragment{"x=$1", nil}).Rebase(0); f.SQL != "x=$1" {
+		t.Errorf("Rebase(0): %v", f)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	f := Fragment{"a=$1 OR b=$2 OR c=$3 OR d=$4 OR e=$5 OR f=$6",
+		[]interface{}{"x", 1, "x", []byte("b"), []byte("b"), int64(1)}}
+	want := Fragment{"a=$1 OR b=$2 OR c=$1 OR d=$3 OR e=$3 OR f=$4",
+		[]interface{}{"x", 1, []byte("b"), int64(1)}}
+	if got := f.Compact(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compact:\n got %v\nwant %v", got, want)
+	}
+	s := []int{1}
+	f = Fragment{"a=$1 OR b=$2 OR c=$3", []interface{}{nil, nil, s}}
+	if got := f.Compact(); len(got.Args) != 3 || got.SQL != f.SQL {
+		t.Errorf("Compact kept %v", got)
+	}
+	f = Fragment{"a=? OR b=?", []interface{}{1, 1}}
+	if got := f.Compact(); !reflect.DeepEqual(got, f) {
+		t.Errorf("Compact ?: %v", got)
+	}
+}
@@ -0,0 +1,27 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+)
+
+// Raw 为不作任何转换的驱动原始值：Scan时原样接收驱动返回的值（[]byte、
+// int64、string等，NULL为nil，[]byte 为副本），Buildstr等写入时原样作为值，
+// Buildargs、ToMap等原样作为参数交给驱动。用于表示必须逐字节保留的列，如特殊
+// 格式的数值。Raw 作为整体映射，不递归其成员。
+type Raw struct {
+	Data interface{}
+}
+
+// Scan 实现sql.Scanner接口。
+func (r *Raw) Scan(src interface{}) error {
+	if b, ok := src.([]byte); ok { // the driver may reuse it
+		src = append([]byte(nil), b...)
+	}
+	r.Data = src
+	return nil
+}
+
+// Value 实现driver.Valuer接口。
+func (r Raw) Value() (driver.Value, error) {
+	return r.Data, nil
+}
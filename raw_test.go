@@ -0,0 +1,44 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type RawNum struct {
+	ID  int
+	Val Raw
+}
+
+func TestRaw(t *testing.T) {
+	rows := testRows(t, []string{"id", "val"},
+		[]driver.Value{int64(1), []byte("0012.50")},
+		[]driver.Value{int64(2), nil}, []driver.Value{int64(3), int64(7)})
+	defer rows.Close()
+	var d []*RawNum
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	want := []*RawNum{{1, Raw{[]byte("0012.50")}}, {2, Raw{}},
+		{3, Raw{int64(7)}}}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Scan: %v %v %v", *d[0], *d[1], *d[2])
+	}
+
+	s, err := Buildstr(d)
+	w := `(id,val) VALUES (1,X'303031322e3530'),(2,NULL),(3,7)`
+	if err != nil || s != w {
+		t.Errorf("Buildstr:\n got %s %v\nwant %s", s, err, w)
+	}
+	m, err := ToMap(d[0])
+	if err != nil || !reflect.DeepEqual(m["val"], []byte("0012.50")) {
+		t.Errorf("ToMap: %v %v", m, err)
+	}
+	var r Raw
+	b := []byte("x")
+	r.Scan(b)
+	if b[0] = 'y'; string(r.Data.([]byte)) != "x" {
+		t.Error("Raw shares the driver's buffer")
+	}
+}
@@ -0,0 +1,57 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// errorType 为error接口类型。
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// ScanReduce 逐行接收rows的结果，依次调用fn将其折叠到累加器中，返回最终的累
+// 加值。与Scan不同，ScanReduce不保留结果切片，适合在大结果集上以恒定内存计算
+// 汇总、分组、Top-K等。fn 的类型形如func(A, *T) (A, error)，T为已映射的结构，
+// seed 为A的初始值（可以为nil）；fn 返回错误时终止接收并返回该错误。接收后
+// ScanReduce不主动关闭rows。
+func ScanReduce(rows *sql.Rows, seed interface{}, fn interface{}) (interface{},
+	error) {
+	f, ft := reflect.ValueOf(fn), reflect.TypeOf(fn)
+	if ft == nil || ft.Kind() != reflect.Func || f.IsNil() ||
+		ft.NumIn() != 2 || ft.NumOut() != 2 ||
+		ft.In(0) != ft.Out(0) || ft.Out(1) != errorType ||
+		ft.In(1).Kind() != reflect.Ptr ||
+		ft.In(1).Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ScanReduce: fn not like func(A, *T) (A, error)")
+	}
+	acc := reflect.New(ft.In(0)).Elem()
+	if seed != nil {
+		sv := reflect.ValueOf(seed)
+		if !sv.Type().AssignableTo(ft.In(0)) {
+			return nil, fmt.Errorf("ScanReduce: seed type %q not %q",
+				sv.Type(), ft.In(0))
+		}
+		acc.Set(sv)
+	}
+
+	t := ft.In(1).Elem()
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return nil, fmt.Errorf("ScanReduce: %v", err)
+	}
+	in := make([]reflect.Value, 2)
+	err = scanEach(rows, []reflect.Type{t}, &scanOpts{mp: mapping},
+		func(tmp []reflect.Value) error {
+			in[0], in[1] = acc, tmp[0]
+			out := f.Call(in)
+			if e := out[1].Interface(); e != nil {
+				return e.(error)
+			}
+			acc = out[0]
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("ScanReduce: %w", err)
+	}
+	return acc.Interface(), nil
+}
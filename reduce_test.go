@@ -0,0 +1,47 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type ReduceRow struct {
+	ID  int
+	Amt int
+}
+
+func TestScanReduce(t *testing.T) {
+	rows := testRows(t, []string{"id", "amt"}, []driver.Value{int64(1),
+		int64(3)}, []driver.Value{int64(2), int64(4)})
+	defer rows.Close()
+	sum, err := ScanReduce(rows, 10, func(acc int, r *ReduceRow) (int,
+		error) {
+		return acc + r.Amt, nil
+	})
+	if err != nil || sum != 17 {
+		t.Errorf("got %v %v", sum, err)
+	}
+
+	rows2 := testRows(t, []string{"id", "amt"}, []driver.Value{int64(1),
+		int64(3)}, []driver.Value{int64(2), int64(4)})
+	defer rows2.Close()
+	n := 0
+	_, err = ScanReduce(rows2, 0, func(acc int, r *ReduceRow) (int, error) {
+		n++
+		return 0, errors.New("stop")
+	})
+	if err == nil || !strings.Contains(err.Error(), "stop") || n != 1 {
+		t.Errorf("fn error: %d calls, %v", n, err)
+	}
+
+	var typed func(int, *ReduceRow) (int, error)
+	for _, fn := range []interface{}{nil, typed, 3,
+		func(int, ReduceRow) (int, error) { return 0, nil }} {
+		_, err = ScanReduce(rows, nil, fn)
+		if err == nil || !strings.Contains(err.Error(), "fn not like") {
+			t.Errorf("%T: %v", fn, err)
+		}
+	}
+}
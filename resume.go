@@ -0,0 +1,225 @@
+package sqlaux
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// ResumeToken 为键集（keyset）分页的续传标记，由已处理的最后一行的主键值编
+// 码而来，可以持久化（如写入检查点文件），使长时间的导出任务中断后从该行之
+// 后继续，而不必重新处理。零值表示从头开始。
+type ResumeToken string
+
+// TokenOf 返回row（形如*struct）的续传标记。row 的结构须有主键（tag中有
+// "pk"），且主键不能为二进制值。
+func TokenOf(row interface{}) (ResumeToken, error) {
+	v := reflect.ValueOf(row)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		return "", fmt.Errorf("TokenOf: argument 'row' bad type %T", row)
+	}
+	mapping, err := automap(loadmap(), v.Elem().Type())
+	if err != nil {
+		return "", fmt.Errorf("TokenOf: %v", err)
+	}
+	pks := pkeys(mapping, v.Elem().Type().Name())
+	if len(pks) == 0 {
+		return "", fmt.Errorf("TokenOf: %q has no primary key",
+			v.Elem().Type())
+	}
+	t, err := token(v.UnsafePointer(), pks)
+	if err != nil {
+		return "", fmt.Errorf("TokenOf: %v", err)
+	}
+	return t, nil
+}
+
+// token 返回基址为p的结构变量的主键pks的续传标记：各主键值的JSON数组按
+// base64（URL）编码。
+func token(p unsafe.Pointer, pks []entryT) (ResumeToken, error) {
+	vals := make([]interface{}, len(pks))
+	for i, e := range pks {
+		val, err := e.arg(conf(), reflect.NewAt(e.typ, e.addr(p)))
+		if err != nil {
+			return "", err
+		}
+		if _, ok := val.([]byte); ok {
+			return "", fmt.Errorf("binary primary key %q", e.name)
+		}
+		vals[i] = val
+	}
+	b, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	return ResumeToken(base64.RawURLEncoding.EncodeToString(b)), nil
+}
+
+// untoken 将续传标记t解码为主键pks的语句参数。
+func untoken(t ResumeToken, pks []entryT) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(string(t))
+	if err != nil {
+		return nil, fmt.Errorf("bad resume token %q", t)
+	}
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber() // keep big integers exact
+	var vals []interface{}
+	if err = dec.Decode(&vals); err != nil || len(vals) != len(pks) {
+		return nil, fmt.Errorf("bad resume token %q", t)
+	}
+	for i, e := range pks {
+		if n, ok := vals[i].(json.Number); ok {
+			vals[i] = string(n)
+		}
+		v := reflect.New(e.typ)
+		if err = convert(v.Elem(), vals[i]); err != nil {
+			return nil, fmt.Errorf("bad resume token %q: %v", t, err)
+		}
+		if vals[i], err = e.arg(conf(), v); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// Export 以键集分页的方式，按主键顺序读取表table的所有行：每批至多batch行，
+// 覆盖写入形如*[]*struct的dest后调用fn，next 为本批最后一行的续传标记，fn
+// 处理完本批后可将其持久化；直到读完或fn返回错误。after 为开始处的续传标记，
+// 即中断前最后持久化的next，""时从头开始。每批语句形如：
+//
+//	SELECT 列... FROM table WHERE pk1>$1 OR (pk1=$1 AND pk2>$2)
+//		ORDER BY pk1,pk2 LIMIT batch
+//
+// 结构须有主键，按d规范生成占位符和行数限制（Oracle为FETCH FIRST）。db 为
+// nil时由路由函数选择。配置中的Progress不为nil时，每批的fn之后以本次导出的
+// 进度调用之（Bytes为0）。
+func Export(ctx context.Context, db Querier, d Dialect, table string,
+	after ResumeToken, batch int, dest interface{},
+	fn func(next ResumeToken) error) error {
+	return export(ctx, conf(), db, d, table, after, batch, dest, fn)
+}
+
+// export 为Export的实现，c为配置。
+func export(ctx context.Context, c *Config, db Querier, d Dialect,
+	table string, after ResumeToken, batch int, dest interface{},
+	fn func(next ResumeToken) error) error {
+	if batch <= 0 {
+		return fmt.Errorf("Export: bad batch %d", batch)
+	}
+	t, err := destof([]interface{}{dest}, 0)
+	if err != nil {
+		return fmt.Errorf("Export: %v", err)
+	}
+	stru := t.Elem().Elem().Elem()
+	mapping, err := automap(loadmap(), stru)
+	if err != nil {
+		return fmt.Errorf("Export: %v", err)
+	}
+	pks := pkeys(mapping, stru.Name())
+	if len(pks) == 0 {
+		return fmt.Errorf("Export: %q has no primary key", stru)
+	}
+	cols, err := selectstr("", reflect.Zero(stru).Interface(), nil)
+	if err != nil {
+		return fmt.Errorf("Export: %v", err)
+	}
+	if db, err = route(ctx, db, RouteRead, stru.Name()); err != nil {
+		return fmt.Errorf("Export: %v", err)
+	}
+	order := make([]string, len(pks))
+	for i, e := range pks {
+		order[i] = e.name.(string)
+	}
+	limit := " LIMIT " + strconv.Itoa(batch)
+	if d != nil && d.Name() == "oracle" {
+		limit = " FETCH FIRST " + strconv.Itoa(batch) + " ROWS ONLY"
+	}
+	start := time.Now()
+	var rows int64
+
+	for {
+		query := "SELECT " + cols + " FROM " + table
+		var args []interface{}
+		if after != "" {
+			vals, err := untoken(after, pks)
+			if err != nil {
+				return fmt.Errorf("Export: %v", err)
+			}
+			var cond string
+			cond, args = keyset(d, order, vals)
+			query += " WHERE " + cond
+		}
+		query += " ORDER BY " + strings.Join(order, ",") + limit
+		query, args, err = rewrite(ctx, query, args)
+		if err != nil {
+			return fmt.Errorf("Export: %v", err)
+		}
+		rs, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("Export: %v", err)
+		}
+		o := &scanOpts{mp: mapping, cfg: c, ctx: ctx}
+		err = scan(rs, o, []interface{}{dest})
+		rs.Close()
+		if err != nil {
+			return fmt.Errorf("Export: %w", err)
+		}
+		v := reflect.ValueOf(dest).Elem()
+		if v.Len() == 0 {
+			return nil
+		}
+		after, err = token(v.Index(v.Len()-1).UnsafePointer(), pks)
+		if err != nil {
+			return fmt.Errorf("Export: %v", err)
+		}
+		if err = fn(after); err != nil {
+			return err
+		}
+		if rows += int64(v.Len()); c.Progress != nil {
+			c.Progress(Progress{rows, 0, time.Since(start)})
+		}
+		if v.Len() < batch {
+			return nil
+		}
+	}
+}
+
+// keyset 返回按主键列cols顺序位于主键值vals之后的条件及其参数：
+// "c1>$1 OR (c1=$1 AND c2>$2) OR ..."。编号占位符（如PostgreSQL的$n）重复
+// 使用参数，其他（?）按出现顺序重复参数。
+func keyset(d Dialect, cols []string, vals []interface{}) (string,
+	[]interface{}) {
+	numbered := d != nil && d.Placeholder(1) != "?"
+	var args []interface{}
+	ph := func(i int) string {
+		if numbered {
+			return d.Placeholder(i + 1)
+		}
+		args = append(args, vals[i])
+		return "?"
+	}
+	var cond strings.Builder
+	for i := range cols {
+		if i > 0 {
+			cond.WriteString(" OR (")
+		}
+		for j := 0; j < i; j++ {
+			cond.WriteString(cols[j] + "=" + ph(j) + " AND ")
+		}
+		cond.WriteString(cols[i] + ">" + ph(i))
+		if i > 0 {
+			cond.WriteString(")")
+		}
+	}
+	if numbered {
+		args = vals
+	}
+	return cond.String(), args
+}
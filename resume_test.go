@@ -0,0 +1,89 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type ResumeRow struct {
+	ID   int `db:"pk"`
+	Name string
+}
+
+type ResumeLine struct {
+	OrderID int `db:"pk col=order_id"`
+	Seq     int `db:"pk"`
+}
+
+func TestExport(t *testing.T) {
+	tresult("SELECT id,name FROM t ORDER BY id LIMIT 2", []string{"id",
+		"name"}, []driver.Value{int64(1), "a"}, []driver.Value{int64(2), "b"})
+	tresult("SELECT id,name FROM t WHERE id>? ORDER BY id LIMIT 2",
+		[]string{"id", "name"}, []driver.Value{int64(3), "c"})
+	ctx := context.Background()
+	var d []*ResumeRow
+	var got []int
+	var tokens []ResumeToken
+	fn := func(next ResumeToken) error {
+		for _, r := range d {
+			got = append(got, r.ID)
+		}
+		tokens = append(tokens, next)
+		return nil
+	}
+	err := Export(ctx, tdb, MySQL, "t", "", 2, &d, fn)
+	if err != nil || !reflect.DeepEqual(got, []int{1, 2, 3}) ||
+		len(tokens) != 2 {
+		t.Fatalf("Export: %v %q %v", got, tokens, err)
+	}
+	if tk, err := TokenOf(&ResumeRow{ID: 2}); err != nil || tk != tokens[0] {
+		t.Errorf("TokenOf: %q %v, want %q", tk, err, tokens[0])
+	}
+
+	// resumed after the first batch
+	got = nil
+	err = Export(ctx, tdb, MySQL, "t", tokens[0], 2, &d,
+		func(ResumeToken) error {
+			got = append(got, d[0].ID)
+			return nil
+		})
+	if err != nil || !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("resumed: %v %v", got, err)
+	}
+	if err = Export(ctx, tdb, MySQL, "t", "!bad", 2, &d,
+		func(ResumeToken) error { return nil }); err == nil {
+		t.Error("bad token accepted")
+	}
+	if err = Export(ctx, tdb, MySQL, "t", "", 0, &d,
+		func(ResumeToken) error { return nil }); err == nil {
+		t.Error("bad batch accepted")
+	}
+	if _, err = TokenOf(&CfgPlan{}); err == nil {
+		t.Error("TokenOf without primary key accepted")
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	tk, err := TokenOf(&ResumeLine{7, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, err := untoken(tk, pkeys(loadmap(), "ResumeLine"))
+	if err != nil || !reflect.DeepEqual(vals, []interface{}{int64(7),
+		int64(3)}) {
+		t.Fatalf("untoken: %v %v", vals, err)
+	}
+	cols := []string{"order_id", "seq"}
+	s, args := keyset(PostgreSQL, cols, vals)
+	if s != "order_id>$1 OR (order_id=$1 AND seq>$2)" || len(args) != 2 {
+		t.Errorf("PostgreSQL: %s %v", s, args)
+	}
+	s, args = keyset(MySQL, cols, vals)
+	if s != "order_id>? OR (order_id=? AND seq>?)" ||
+		!reflect.DeepEqual(args, []interface{}{int64(7), int64(7),
+			int64(3)}) {
+		t.Errorf("MySQL: %s %v", s, args)
+	}
+}
@@ -0,0 +1,70 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// rywT 为ReadYourWrites在上下文中记录的写操作状态。
+type rywT struct {
+	window   time.Duration
+	caughtUp func(ctx context.Context, replica *sql.DB, pos string) bool
+	mu       sync.Mutex
+	last     time.Time // the last write
+	pos      string    // replication position after the last write
+}
+
+type rywKey struct{}
+
+// ReadYourWrites 返回派生自ctx的上下文：在其上由路由函数选择数据库的写操作
+// 之后window时间内，同一上下文中的读操作也路由到写库（即以RouteWrite调用路
+// 由函数），以避免副本复制延迟造成的写后读不到刚写入的数据，如INSERT后立即
+// 按主键读取。
+//
+// caughtUp 不为nil且写操作的复制位置已知（见MarkWrite）时，窗口期内的读操作
+// 先按RouteRead选出副本，caughtUp报告该副本已应用到位置pos（MySQL GTID、
+// PostgreSQL LSN等）时即读该副本，不必等到窗口结束。
+func ReadYourWrites(ctx context.Context, window time.Duration,
+	caughtUp func(ctx context.Context, replica *sql.DB,
+		pos string) bool) context.Context {
+	return context.WithValue(ctx, rywKey{}, &rywT{window: window,
+		caughtUp: caughtUp})
+}
+
+// MarkWrite 在ctx（须派生自ReadYourWrites的返回值，否则忽略）中记录一次写
+// 操作，pos 为写操作后主库的复制位置，未知时为""。sqlaux经路由函数选择数据
+// 库的写操作自动记录（pos为""）；调用者自行执行写操作，或在写操作后取得了复
+// 制位置（如MySQL的@@gtid_executed、PostgreSQL的pg_current_wal_lsn()）时调
+// 用之。
+func MarkWrite(ctx context.Context, pos string) {
+	if s, ok := ctx.Value(rywKey{}).(*rywT); ok {
+		s.mu.Lock()
+		s.last, s.pos = time.Now(), pos // older positions are stale
+		s.mu.Unlock()
+	}
+}
+
+// routeop 返回上下文写操作状态s下，结构stru的操作op实际应使用的路由操作类
+// 型，并记录写操作。router 为当前的路由函数。
+func (s *rywT) routeop(ctx context.Context, router func(ctx context.Context,
+	op RouteOp, stru string) *sql.DB, op RouteOp, stru string) RouteOp {
+	if op == RouteWrite {
+		MarkWrite(ctx, "")
+		return op
+	}
+	s.mu.Lock()
+	last, pos := s.last, s.pos
+	s.mu.Unlock()
+	if last.IsZero() || time.Since(last) >= s.window {
+		return op
+	}
+	if s.caughtUp != nil && pos != "" {
+		if r := router(ctx, RouteRead, stru); r != nil &&
+			s.caughtUp(ctx, r, pos) {
+			return op
+		}
+	}
+	return RouteWrite // pinned to the primary
+}
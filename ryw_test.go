@@ -0,0 +1,77 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type RywItem struct {
+	ID int
+}
+
+func TestReadYourWrites(t *testing.T) {
+	old := loadhooks()
+	defer hooks.Store(old)
+	var ops []RouteOp
+	SetRouter(func(ctx context.Context, op RouteOp, stru string) *sql.DB {
+		ops = append(ops, op)
+		return tdb
+	})
+	read := func(ctx context.Context) []RouteOp {
+		ops = nil
+		tresult("SELECT id FROM ryw", []string{"id"})
+		var d []*RywItem
+		if err := QueryContext(ctx, nil, "SELECT id FROM ryw", nil,
+			&d); err != nil {
+			t.Fatal(err)
+		}
+		return ops
+	}
+	bg := context.Background()
+	defer takeExecs()
+
+	ctx := ReadYourWrites(bg, time.Hour, nil)
+	if got := read(ctx); !reflect.DeepEqual(got, []RouteOp{RouteRead}) {
+		t.Errorf("before writing: %v", got)
+	}
+	ExecContext(ctx, nil, "DELETE FROM ryw")
+	if got := read(ctx); !reflect.DeepEqual(got, []RouteOp{RouteWrite}) {
+		t.Errorf("after writing: %v", got)
+	}
+	if got := read(bg); !reflect.DeepEqual(got, []RouteOp{RouteRead}) {
+		t.Errorf("other context: %v", got)
+	}
+	ctx = ReadYourWrites(bg, 0, nil)
+	ExecContext(ctx, nil, "DELETE FROM ryw")
+	if got := read(ctx); !reflect.DeepEqual(got, []RouteOp{RouteRead}) {
+		t.Errorf("window passed: %v", got)
+	}
+
+	var probed []string
+	ctx = ReadYourWrites(bg, time.Hour, func(ctx context.Context,
+		replica *sql.DB, pos string) bool {
+		probed = append(probed, pos)
+		return replica == tdb && pos == "lsn1"
+	})
+	MarkWrite(ctx, "lsn1")
+	if got := read(ctx); !reflect.DeepEqual(got, []RouteOp{RouteRead,
+		RouteRead}) {
+		t.Errorf("caught up: %v", got)
+	}
+	MarkWrite(ctx, "lsn2")
+	if got := read(ctx); !reflect.DeepEqual(got, []RouteOp{RouteRead,
+		RouteWrite}) {
+		t.Errorf("lagging: %v", got)
+	}
+	ExecContext(ctx, nil, "DELETE FROM ryw") // position unknown
+	if got := read(ctx); !reflect.DeepEqual(got, []RouteOp{RouteWrite}) {
+		t.Errorf("unknown position: %v", got)
+	}
+	if !reflect.DeepEqual(probed, []string{"lsn1", "lsn2"}) {
+		t.Errorf("probed %q", probed)
+	}
+	MarkWrite(bg, "x") // ignored
+}
@@ -0,0 +1,44 @@
+package sqlaux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sample 返回对表table随机抽样的FROM子句项，用于数据质量检查等场合。percent
+// 为true时按百分比size抽样，否则抽取size行。PostgreSQL按百分比抽样时使用
+// TABLESAMPLE SYSTEM（按数据块抽样，速度快但不均匀），其它情况使用随机排序的
+// 子查询，如"(SELECT * FROM t ORDER BY RAND() LIMIT 100) AS t"，需全表扫描。
+// table 可形如"schema.table"，子查询以不含schema的表名作别名。d 为nil时表名
+// 不加引号，随机函数为RANDOM()。
+func Sample(d Dialect, table string, size float64, percent bool) (string,
+	error) {
+	if size <= 0 || percent && size > 100 ||
+		!percent && size != float64(int64(size)) {
+		return "", fmt.Errorf("Sample: bad size %v", size)
+	}
+	t, alias := table, table[strings.LastIndex(table, ".")+1:]
+	if d != nil {
+		t, alias = quotetable(d, table), d.QuoteIdent(alias)
+	}
+	num := strconv.FormatFloat(size, 'f', -1, 64)
+	if percent && d != nil && d.Name() == "postgres" {
+		return t + " TABLESAMPLE SYSTEM (" + num + ")", nil
+	}
+
+	rand := "RANDOM()"
+	if d != nil && d.Name() == "mysql" {
+		rand = "RAND()"
+	}
+	if percent {
+		num = strconv.FormatFloat(size/100, 'f', -1, 64)
+		if d != nil && d.Name() == "sqlite" { // RANDOM() is a 64-bit integer
+			rand = "(RANDOM()/18446744073709551616.0+0.5)"
+		}
+		return "(SELECT * FROM " + t + " WHERE " + rand + "<" + num + ") AS " +
+			alias, nil
+	}
+	return "(SELECT * FROM " + t + " ORDER BY " + rand + " LIMIT " + num +
+		") AS " + alias, nil
+}
@@ -0,0 +1,35 @@
+package sqlaux
+
+import "testing"
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		d       Dialect
+		table   string
+		size    float64
+		percent bool
+		want    string
+	}{
+		{PostgreSQL, "t", 10, true, `"t" TABLESAMPLE SYSTEM (10)`},
+		{PostgreSQL, "t", 10, false,
+			`(SELECT * FROM "t" ORDER BY RANDOM() LIMIT 10) AS "t"`},
+		{MySQL, "s.t", 2.5, true,
+			"(SELECT * FROM `s`.`t` WHERE RAND()<0.025) AS `t`"},
+		{SQLite, "t", 50, true, `(SELECT * FROM "t" WHERE ` +
+			`(RANDOM()/18446744073709551616.0+0.5)<0.5) AS "t"`},
+		{nil, "t", 3, false,
+			"(SELECT * FROM t ORDER BY RANDOM() LIMIT 3) AS t"},
+	}
+	for _, tt := range tests {
+		s, err := Sample(tt.d, tt.table, tt.size, tt.percent)
+		if err != nil || s != tt.want {
+			t.Errorf("Sample(%v, %v):\n got %s %v\nwant %s", tt.size,
+				tt.percent, s, err, tt.want)
+		}
+	}
+	for _, size := range []float64{0, -1, 3.5, 101} {
+		if _, err := Sample(MySQL, "t", size, size > 100); err == nil {
+			t.Errorf("Sample size %v accepted", size)
+		}
+	}
+}
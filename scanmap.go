@@ -0,0 +1,91 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ScanMap 将rows的各行接收为以列名为键的映射，用于结果形式不对应任何已映射结
+// 构的即席查询（如报表、管理工具）。值按结果列的数据库类型转换，而不是原样保
+// 留驱动的表示（如MySQL文本协议的[]byte）：
+//
+//	● 整数列为int64，浮点列为float64，布尔列为bool；
+//	● DECIMAL、NUMERIC列为string，以免丢失精度；
+//	● 字符串类列（含JSON、UUID等）为string；
+//	● 日期、时间列为time.Time；
+//	● 二进制列为[]byte（驱动缓冲区的副本）；
+//	● NULL为nil，其他类型的列保留驱动的值。
+//
+// 列名重复时报错。接收后ScanMap不主动关闭rows。
+func ScanMap(rows *sql.Rows) ([]map[string]interface{}, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("ScanMap: %v", err)
+	}
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("ScanMap: %v", err)
+	}
+	class := make([]string, len(cols))
+	for i, c := range cols {
+		for _, cc := range cols[:i] {
+			if cc == c {
+				return nil, fmt.Errorf("ScanMap: duplicate column %q", c)
+			}
+		}
+		class[i] = typeclass(strings.ToLower(cts[i].DatabaseTypeName()))
+	}
+
+	var ms []map[string]interface{}
+	vals := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("ScanMap: %v", err)
+		}
+		m := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			if m[c], err = mapvalue(class[i], vals[i]); err != nil {
+				return nil, fmt.Errorf("ScanMap: column %q %v", c, err)
+			}
+		}
+		ms = append(ms, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ScanMap: %v", err)
+	}
+	return ms, nil
+}
+
+// mapTypes 为各列类型大类（见typeclass）在ScanMap结果中的Go类型。
+var mapTypes = map[string]reflect.Type{
+	"int":      reflect.TypeOf(int64(0)),
+	"float":    reflect.TypeOf(float64(0)),
+	"decimal":  reflect.TypeOf(""),
+	"bool":     boolTType,
+	"string":   reflect.TypeOf(""),
+	"interval": reflect.TypeOf(""),
+	"time":     timeType,
+	"bytes":    reflect.TypeOf([]byte(nil)),
+}
+
+// mapvalue 将驱动的值src转换为列类型大类class在ScanMap结果中的类型。
+func mapvalue(class string, src interface{}) (interface{}, error) {
+	t, ok := mapTypes[class]
+	if !ok || src == nil {
+		return src, nil
+	}
+	v := reflect.New(t).Elem()
+	if err := convert(v, src); err != nil {
+		return nil, err
+	}
+	if t == boolTType {
+		return v.Bool(), nil
+	}
+	return v.Interface(), nil
+}
@@ -0,0 +1,61 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanMap(t *testing.T) {
+	cols := []string{"id", "price", "ratio", "ok", "name", "at", "raw",
+		"tag"}
+	types := []string{"BIGINT", "DECIMAL", "DOUBLE", "BOOLEAN", "VARCHAR",
+		"TIMESTAMP", "BYTEA", "XML"}
+	rows := typedRows(t, cols, types,
+		[]driver.Value{[]byte("12"), []byte("1.50"), []byte("2.5"), "t",
+			[]byte("x"), "2020-01-02 03:04:05", []byte{0, 1}, []byte("<a/>")},
+		[]driver.Value{nil, nil, nil, nil, nil, nil, nil, nil})
+	defer rows.Close()
+	ms, err := ScanMap(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{"id": int64(12), "price": "1.50", "ratio": 2.5, "ok": true,
+			"name": "x", "at": time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			"raw": []byte{0, 1}, "tag": []byte("<a/>")},
+		{"id": nil, "price": nil, "ratio": nil, "ok": nil, "name": nil,
+			"at": nil, "raw": nil, "tag": nil},
+	}
+	if !reflect.DeepEqual(ms, want) {
+		t.Errorf("ScanMap:\n got %v\nwant %v", ms, want)
+	}
+
+	untyped := testRows(t, []string{"id"}, []driver.Value{[]byte("1")})
+	defer untyped.Close()
+	ms, err = ScanMap(untyped)
+	if err != nil || !reflect.DeepEqual(ms[0]["id"], []byte("1")) {
+		t.Errorf("untyped column: %v %v", ms, err)
+	}
+	empty := testRows(t, []string{"id"})
+	defer empty.Close()
+	if ms, err = ScanMap(empty); err != nil || ms != nil {
+		t.Errorf("no rows: %v %v", ms, err)
+	}
+
+	dup := testRows(t, []string{"id", "id"}, []driver.Value{1, 2})
+	defer dup.Close()
+	_, err = ScanMap(dup)
+	if err == nil || !strings.Contains(err.Error(), `duplicate column "id"`) {
+		t.Errorf("duplicate column: %v", err)
+	}
+	bad := typedRows(t, []string{"id"}, []string{"INT"},
+		[]driver.Value{"abc"})
+	defer bad.Close()
+	_, err = ScanMap(bad)
+	if err == nil || !strings.Contains(err.Error(), `column "id"`) {
+		t.Errorf("bad integer: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build go1.18
+
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// ScanT 与Scan相同，但以类型参数T（结构）代替dest，返回接收的结果，如：
+//
+//	users, err := sqlaux.ScanT[User](rows)
+//
+// 从而在编译时确定结果类型，免去interface{}参数及其运行时的形式检查。
+func ScanT[T any](rows *sql.Rows) ([]*T, error) {
+	if t := reflect.TypeOf((*T)(nil)).Elem(); t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ScanT: %v is not a struct", t)
+	}
+	var dest []*T
+	if err := scan(rows, &scanOpts{mp: loadmap()},
+		[]interface{}{&dest}); err != nil {
+		return nil, fmt.Errorf("ScanT: %w", err)
+	}
+	return dest, nil
+}
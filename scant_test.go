@@ -0,0 +1,36 @@
+//go:build go1.18
+
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type ScanTRow struct {
+	ID   int
+	Name string
+}
+
+func TestScanT(t *testing.T) {
+	rows := testRows(t, []string{"id", "name"},
+		[]driver.Value{int64(1), "a"}, []driver.Value{int64(2), "b"})
+	defer rows.Close()
+	d, err := ScanT[ScanTRow](rows)
+	if err != nil || len(d) != 2 || *d[1] != (ScanTRow{2, "b"}) {
+		t.Errorf("ScanT: %v %v", d, err)
+	}
+	empty := testRows(t, []string{"id"})
+	defer empty.Close()
+	if d, err = ScanT[ScanTRow](empty); err != nil || len(d) != 0 {
+		t.Errorf("empty: %v %v", d, err)
+	}
+	bad := testRows(t, []string{"id"}, []driver.Value{"x"})
+	defer bad.Close()
+	if d, err = ScanT[ScanTRow](bad); err == nil || d != nil {
+		t.Errorf("bad value: %v %v", d, err)
+	}
+	if _, err = ScanT[int](bad); err == nil {
+		t.Error("non-struct accepted")
+	}
+}
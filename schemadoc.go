@@ -0,0 +1,174 @@
+package sqlaux
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DumpSchema 将全部已映射结构的数据字典以format（"markdown"或"html"）格式写
+// 入w：每个结构一节，按结构名排列，含其登记的表名（见Tablename），及逐列的字
+// 段名、按d推导的列类型（同CreateTableSQL）、可否为NULL、主键与索引、外键引
+// 用（"ref="）、约束（"check="）、说明（"comment="）和计算列表达式，最后是子
+// 表字段（"child="）。文档与映射出自同一份结构定义，因而总是与代码一致。
+func DumpSchema(w io.Writer, d Dialect, format string) error {
+	if d == nil {
+		return fmt.Errorf("DumpSchema: no dialect argument")
+	}
+	ew := &errWriter{w: w}
+	var r schemaWriter
+	switch format {
+	case "markdown":
+		r = mdWriter{ew}
+	case "html":
+		r = htmlWriter{ew}
+	default:
+		return fmt.Errorf("DumpSchema: unknown format %q", format)
+	}
+	mapping, zt := loadmap(), conf().ZeroTime
+	var ss []string
+	for k := range mapping {
+		if !strings.Contains(k, ".") {
+			ss = append(ss, k)
+		}
+	}
+	sort.Strings(ss)
+
+	r.begin()
+	head := []string{"Column", "Field", "Type", "Null", "Key", "References",
+		"Check", "Comment"}
+	for _, s := range ss {
+		r.section(s, tablename(mapping, s))
+		var rows [][]string
+		for _, f := range mapping[s].name.([]string) {
+			m := mapping["0."+s+"."+f]
+			typ, err := columntype(d, m)
+			if err != nil {
+				typ = "?"
+			}
+			null := "YES"
+			if m.notnull(zt) {
+				null = "NO"
+			}
+			var key, comment []string
+			var ref, check string
+			if m.info != nil {
+				if m.info.pk {
+					key = append(key, "PK")
+				}
+				if m.info.unique != "" {
+					key = append(key, "UNIQUE "+m.info.unique)
+				}
+				if m.info.index != "" {
+					key = append(key, "INDEX "+m.info.index)
+				}
+				ref = m.info.ref
+				if m.info.check != nil {
+					check = m.info.check.expr
+				}
+				if m.info.expr != "" {
+					comment = append(comment, "computed: "+m.info.expr)
+				}
+				if m.info.comment != "" {
+					comment = append(comment, m.info.comment)
+				}
+			}
+			rows = append(rows, []string{m.name.(string), f, typ, null,
+				strings.Join(key, ", "), ref, check,
+				strings.Join(comment, "; ")})
+		}
+		r.table(head, rows)
+		if cs, ok := mapping["c."+s]; ok {
+			rows = rows[:0]
+			for _, f := range cs.name.([]string) {
+				c := mapping["c."+s+"."+f].name.(childT)
+				rows = append(rows, []string{c.table, f, c.fk, c.col})
+			}
+			r.table([]string{"Child table", "Field", "Foreign key",
+				"Value column"}, rows)
+		}
+	}
+	r.end()
+	if ew.err != nil {
+		return fmt.Errorf("DumpSchema: %v", ew.err)
+	}
+	return nil
+}
+
+// schemaWriter 为DumpSchema的输出格式。
+type schemaWriter interface {
+	begin()
+	section(stru, table string)
+	table(head []string, rows [][]string)
+	end()
+}
+
+// mdWriter 输出Markdown格式。
+type mdWriter struct{ w io.Writer }
+
+func (m mdWriter) begin() { io.WriteString(m.w, "# Schema\n\n") }
+
+func (m mdWriter) section(stru, table string) {
+	fmt.Fprintf(m.w, "## %s\n\n", stru)
+	if table != "" {
+		fmt.Fprintf(m.w, "Table: `%s`\n\n", table)
+	}
+}
+
+func (m mdWriter) table(head []string, rows [][]string) {
+	esc := strings.NewReplacer("|", `\|`, "\n", " ")
+	line := func(cs []string) {
+		for _, c := range cs {
+			io.WriteString(m.w, "| "+esc.Replace(c)+" ")
+		}
+		io.WriteString(m.w, "|\n")
+	}
+	line(head)
+	io.WriteString(m.w, strings.Repeat("| --- ", len(head))+"|\n")
+	for _, r := range rows {
+		line(r)
+	}
+	io.WriteString(m.w, "\n")
+}
+
+func (m mdWriter) end() {}
+
+// htmlWriter 输出HTML格式。
+type htmlWriter struct{ w io.Writer }
+
+func (h htmlWriter) begin() {
+	io.WriteString(h.w, "<!DOCTYPE html>\n<html>\n<head><meta "+
+		"charset=\"utf-8\"><title>Schema</title></head>\n<body>\n")
+}
+
+func (h htmlWriter) section(stru, table string) {
+	fmt.Fprintf(h.w, "<h2 id=\"%s\">%s</h2>\n", html.EscapeString(stru),
+		html.EscapeString(stru))
+	if table != "" {
+		fmt.Fprintf(h.w, "<p>Table: <code>%s</code></p>\n",
+			html.EscapeString(table))
+	}
+}
+
+func (h htmlWriter) table(head []string, rows [][]string) {
+	line := func(tag string, cs []string) {
+		io.WriteString(h.w, "<tr>")
+		for _, c := range cs {
+			io.WriteString(h.w, "<"+tag+">"+html.EscapeString(c)+"</"+tag+
+				">")
+		}
+		io.WriteString(h.w, "</tr>\n")
+	}
+	io.WriteString(h.w, "<table>\n")
+	line("th", head)
+	for _, r := range rows {
+		line("td", r)
+	}
+	io.WriteString(h.w, "</table>\n")
+}
+
+func (h htmlWriter) end() {
+	io.WriteString(h.w, "</body>\n</html>\n")
+}
@@ -0,0 +1,66 @@
+package sqlaux
+
+import (
+	"strings"
+	"testing"
+)
+
+type DocUser struct {
+	_     struct{} `db:"table=doc_users"`
+	ID    int64    `db:"pk"`
+	Email string   `db:"unique=uq_email comment='login | mail'"`
+	Org   *int     `db:"ref=orgs.id"`
+	Age   int      `db:"check='age >= 0'"`
+	Next  int      `db:"expr='age + 1'"`
+	Tags  []string `db:"child=doc_user_tags:user_id:tag"`
+}
+
+func TestDumpSchema(t *testing.T) {
+	if _, err := Signature(DocUser{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := DumpSchema(&b, PostgreSQL, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	want := "## DocUser\n\nTable: `doc_users`\n\n" +
+		"| Column | Field | Type | Null | Key | References | Check | " +
+		"Comment |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+		"| id | ID | bigint | NO | PK |  |  |  |\n" +
+		"| email | Email | text | NO | UNIQUE uq_email |  |  | " +
+		"login \\| mail |\n" +
+		"| org | Org | bigint | YES |  | orgs.id |  |  |\n" +
+		"| age | Age | bigint | NO |  |  | age >= 0 |  |\n" +
+		"| next | Next | bigint | NO |  |  |  | computed: age + 1 |\n\n" +
+		"| Child table | Field | Foreign key | Value column |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| doc_user_tags | Tags | user_id | tag |\n\n"
+	if s := b.String(); !strings.HasPrefix(s, "# Schema\n") ||
+		!strings.Contains(s, want) {
+		t.Errorf("markdown:\n%s\nwant section\n%s", s, want)
+	}
+
+	b.Reset()
+	if err := DumpSchema(&b, PostgreSQL, "html"); err != nil {
+		t.Fatal(err)
+	}
+	s := b.String()
+	for _, want := range []string{"<!DOCTYPE html>",
+		`<h2 id="DocUser">DocUser</h2>`,
+		"<p>Table: <code>doc_users</code></p>",
+		"<td>age &gt;= 0</td>", "<td>login | mail</td>",
+		"<tr><td>doc_user_tags</td><td>Tags</td><td>user_id</td>" +
+			"<td>tag</td></tr>", "</html>\n"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("html without %s", want)
+		}
+	}
+
+	if err := DumpSchema(&b, nil, "html"); err == nil {
+		t.Error("nil dialect accepted")
+	}
+	if err := DumpSchema(&b, MySQL, "pdf"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
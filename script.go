@@ -0,0 +1,106 @@
+package sqlaux
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ScriptOpts 为DumpInsertScript的选项。
+type ScriptOpts struct {
+	Table   string   // 表名，必需，可形如"schema.table"
+	Dialect Dialect  // 数据库方言，必需
+	Batch   int      // 每条INSERT语句的最大行数，<=0时为配置中的ScriptBatch
+	Field   []string // 要导出的字段，缺省为所有映射字段，同Buildstr
+}
+
+// Progress 为长时间批量操作的进度，在每批完成后报告，用于显示进度条、输出心
+// 跳日志等。
+type Progress struct {
+	Rows    int64         // 已处理的行数
+	Bytes   int64         // 已写出的字节数，不可知时为0
+	Elapsed time.Duration // 自操作开始以来的时间
+}
+
+// DumpInsertScript 将data生成一个完整的、以事务包裹的SQL脚本写入w，供DBA离线
+// 导入。data的类型形如[]*struct或*struct，数据按opts.Batch分批生成多条INSERT
+// 语句，字面值按opts.Dialect规范转义。脚本形如：
+//
+//	BEGIN;
+//	INSERT INTO "t" (列名1,列名2,...) VALUES (值1,值2,...),...;
+//	...
+//	COMMIT;
+//
+// 配置中的Progress不为nil时，每生成一条INSERT语句后以进度调用之。
+func DumpInsertScript(w io.Writer, data interface{}, opts ScriptOpts) error {
+	return dumpInsertScript(w, conf(), data, opts)
+}
+
+// dumpInsertScript 为DumpInsertScript的实现，c 为配置。
+func dumpInsertScript(w io.Writer, c *Config, data interface{},
+	opts ScriptOpts) error {
+	if opts.Table == "" || opts.Dialect == nil {
+		return fmt.Errorf("DumpInsertScript: no table or dialect option")
+	}
+	v, err := toslice(data)
+	if err != nil {
+		return fmt.Errorf("DumpInsertScript: %v", err)
+	}
+	mapping, err := automap(loadmap(), v.Type().Elem().Elem())
+	if err != nil {
+		return fmt.Errorf("DumpInsertScript: %v", err)
+	}
+	n := opts.Batch
+	if n <= 0 {
+		n = c.ScriptBatch
+	}
+	if n <= 0 {
+		n = 500
+	}
+
+	start := time.Now()
+	bw := bufio.NewWriter(w)
+	ew := &errWriter{w: bw}
+	if opts.Dialect.Name() == "mysql" {
+		io.WriteString(ew, "START TRANSACTION;\n")
+	} else {
+		io.WriteString(ew, "BEGIN;\n")
+	}
+	table := quotetable(opts.Dialect, opts.Table)
+	for i := 0; i < v.Len() && ew.err == nil; i += n {
+		j := i + n
+		if j > v.Len() {
+			j = v.Len()
+		}
+		io.WriteString(ew, "INSERT INTO "+table+" ")
+		err = valuebuild(ew, opts.Dialect, c, mapping, v.Slice(i, j),
+			opts.Field...)
+		if err != nil {
+			return fmt.Errorf("DumpInsertScript: %v", err)
+		}
+		io.WriteString(ew, ";\n")
+		if c.Progress != nil && ew.err == nil {
+			c.Progress(Progress{int64(j), ew.n, time.Since(start)})
+		}
+	}
+	io.WriteString(ew, "COMMIT;\n")
+	if ew.err == nil {
+		ew.err = bw.Flush()
+	}
+	if ew.err != nil {
+		return fmt.Errorf("DumpInsertScript: %v", ew.err)
+	}
+	return nil
+}
+
+// quotetable 按方言d为表名table加引号，"schema.table"形式的表名各部分分别加
+// 引号。
+func quotetable(d Dialect, table string) string {
+	p := strings.Split(table, ".")
+	for i := range p {
+		p[i] = d.QuoteIdent(p[i])
+	}
+	return strings.Join(p, ".")
+}
@@ -0,0 +1,143 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type ScriptRow struct {
+	ID int
+}
+
+type ScriptNote struct {
+	ID   int
+	Text string
+}
+
+func TestDumpInsertScript(t *testing.T) {
+	data := []*ScriptNote{{1, "it's"}, {2, `a\b`}}
+	var b strings.Builder
+	err := DumpInsertScript(&b, data, ScriptOpts{Table: "note",
+		Dialect: PostgreSQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "BEGIN;\nINSERT INTO \"note\" (id,text) VALUES " +
+		"(1,'it''s'),(2,'a\\b');\nCOMMIT;\n"
+	if b.String() != want {
+		t.Errorf("PostgreSQL script:\n got %q\nwant %q", b.String(), want)
+	}
+	b.Reset()
+	err = DumpInsertScript(&b, data, ScriptOpts{Table: "note",
+		Dialect: MySQL, Field: []string{"Text"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "START TRANSACTION;\nINSERT INTO `note` (text) VALUES " +
+		"('it\\'s'),('a\\\\b');\nCOMMIT;\n"
+	if b.String() != want {
+		t.Errorf("MySQL script:\n got %q\nwant %q", b.String(), want)
+	}
+	if DumpInsertScript(&b, data, ScriptOpts{Dialect: MySQL}) == nil {
+		t.Error("no error without table")
+	}
+}
+
+func TestScriptBatch(t *testing.T) {
+	old := GetConfig()
+	defer SetConfig(old)
+	if old.ScriptBatch != 500 {
+		t.Errorf("default ScriptBatch %d", old.ScriptBatch)
+	}
+	c := old
+	c.ScriptBatch = 2
+	if err := SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	data := []*ScriptRow{{1}, {2}, {3}}
+	var b strings.Builder
+	err := DumpInsertScript(&b, data, ScriptOpts{Table: "t",
+		Dialect: PostgreSQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "INSERT INTO"); n != 2 {
+		t.Errorf("%d INSERTs with ScriptBatch 2:\n%s", n, b.String())
+	}
+	b.Reset()
+	err = DumpInsertScript(&b, data, ScriptOpts{Table: "t",
+		Dialect: PostgreSQL, Batch: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "INSERT INTO"); n != 1 {
+		t.Errorf("%d INSERTs with Batch 3:\n%s", n, b.String())
+	}
+}
+
+func TestScriptSchema(t *testing.T) {
+	var b strings.Builder
+	err := DumpInsertScript(&b, &ScriptRow{1}, ScriptOpts{Table: "s.t",
+		Dialect: PostgreSQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `INSERT INTO "s"."t" `) {
+		t.Errorf("schema-qualified table:\n%s", b.String())
+	}
+	b.Reset()
+	err = DumpInsertScript(&b, &ScriptRow{1}, ScriptOpts{Table: "s.t",
+		Dialect: MySQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "INSERT INTO `s`.`t` ") {
+		t.Errorf("schema-qualified table:\n%s", b.String())
+	}
+}
+
+type ScriptItem struct {
+	ID int `db:"pk"`
+}
+
+func TestProgress(t *testing.T) {
+	var ps []Progress
+	c := With(WithProgress(func(p Progress) { ps = append(ps, p) }))
+	data := []*ScriptRow{{1}, {2}, {3}}
+	var b strings.Builder
+	err := c.DumpInsertScript(&b, data, ScriptOpts{Table: "t",
+		Dialect: PostgreSQL, Batch: 2})
+	if err != nil || len(ps) != 2 || ps[0].Rows != 2 || ps[1].Rows != 3 {
+		t.Fatalf("DumpInsertScript: %+v %v", ps, err)
+	}
+	if n := int64(b.Len() - len("COMMIT;\n")); ps[0].Bytes <= 0 ||
+		ps[1].Bytes != n || ps[1].Elapsed < ps[0].Elapsed {
+		t.Errorf("DumpInsertScript: %+v, want %d bytes", ps, n)
+	}
+	if err = DumpInsertScript(&b, data, ScriptOpts{Table: "t",
+		Dialect: PostgreSQL}); err != nil || len(ps) != 2 {
+		t.Errorf("global config reported %+v", ps[2:])
+	}
+
+	tresult("SELECT id FROM script_item ORDER BY id LIMIT 2",
+		[]string{"id"}, []driver.Value{int64(1)}, []driver.Value{int64(2)})
+	tresult("SELECT id FROM script_item WHERE id>$1 ORDER BY id LIMIT 2",
+		[]string{"id"}, []driver.Value{int64(3)})
+	ps = nil
+	var d []*ScriptItem
+	batches := 0
+	err = c.Export(context.Background(), tdb, PostgreSQL, "script_item", "",
+		2, &d, func(ResumeToken) error {
+			if len(ps) != batches {
+				t.Errorf("batch %d reported before fn", batches)
+			}
+			batches++
+			return nil
+		})
+	if err != nil || len(ps) != 2 || ps[0].Rows != 2 || ps[1].Rows != 3 ||
+		ps[1].Bytes != 0 {
+		t.Errorf("Export: %+v %v", ps, err)
+	}
+}
@@ -0,0 +1,80 @@
+package sqlaux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selectstr 为SELECT语句生成stru的field字段所对应的选择列串："列名1,列名2,..."，
+// 使SELECT语句与结构映射保持一致。field缺省时为所有映射字段。tag中有"expr="
+// 的计算列字段（如窗口函数）生成"表达式 AS 列名"，例如：
+//
+//	Rank int `db:"expr='rank() OVER (PARTITION BY dept ORDER BY pay DESC)'"`
+//
+// 生成"rank() OVER (PARTITION BY dept ORDER BY pay DESC) AS rank"，从而可以
+// 直接用Scan接收。计算列不参与Buildstr等写入操作。
+// stru 以变量值的形式作参数，可以取零值，未映射时自动映射（见
+// Config.InitOnly）。
+func Selectstr(stru interface{}, field ...string) (string, error) {
+	s, err := selectstr("", stru, field)
+	if err != nil {
+		return "", fmt.Errorf("Selectstr: %v", err)
+	}
+	return s, nil
+}
+
+// SelectstrAs 与Selectstr相同，但各列名前加表别名前缀"alias."，用于连接查询，
+// 如：
+//
+//	a, _ := SelectstrAs("o", Order{})
+//	b, _ := SelectstrAs("i", Item{})
+//	"SELECT " + a + ",''," + b + " FROM orders o JOIN items i ON ..."
+//
+// 计算列的表达式不加前缀。alias 须为非空的标识符。
+func SelectstrAs(alias string, stru interface{}, field ...string) (string,
+	error) {
+	if alias == "" || !isident(strings.ToLower(alias)) {
+		return "", fmt.Errorf("SelectstrAs: bad alias %q", alias)
+	}
+	s, err := selectstr(alias+".", stru, field)
+	if err != nil {
+		return "", fmt.Errorf("SelectstrAs: %v", err)
+	}
+	return s, nil
+}
+
+// selectstr 为Selectstr、SelectstrAs的公共实现，pre为列名前缀。
+func selectstr(pre string, stru interface{}, field []string) (string, error) {
+	t, err := structarg(stru)
+	if err != nil {
+		return "", err
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return "", err
+	}
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return "", fmt.Errorf("%q has no mapping", t)
+	}
+	if len(field) == 0 {
+		field = e.name.([]string)
+	}
+
+	var sql strings.Builder
+	for i, n := range field {
+		m, ok := mapping["0."+t.Name()+"."+n]
+		if !ok {
+			return "", nofield(mapping, t.Name(), n)
+		}
+		if i > 0 {
+			sql.WriteString(",")
+		}
+		if m.info != nil && m.info.expr != "" {
+			sql.WriteString(m.info.expr + " AS " + m.name.(string))
+		} else {
+			sql.WriteString(pre + m.name.(string))
+		}
+	}
+	return sql.String(), nil
+}
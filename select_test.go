@@ -0,0 +1,49 @@
+package sqlaux
+
+import (
+	"strings"
+	"testing"
+)
+
+type SelPay struct {
+	ID   int
+	Pay  int
+	Rank int `db:"expr='rank() OVER (ORDER BY pay DESC)' col=rnk"`
+}
+
+func TestSelectstr(t *testing.T) {
+	s, err := Selectstr(SelPay{})
+	if want := "id,pay,rank() OVER (ORDER BY pay DESC) AS rnk"; err != nil ||
+		s != want {
+		t.Errorf("Selectstr:\n got %s %v\nwant %s", s, err, want)
+	}
+	s, err = SelectstrAs("p", &SelPay{}, "Rank", "ID")
+	if want := "rank() OVER (ORDER BY pay DESC) AS rnk,p.id"; err != nil ||
+		s != want {
+		t.Errorf("SelectstrAs:\n got %s %v\nwant %s", s, err, want)
+	}
+	if s, err = Buildstr(&SelPay{1, 2, 3}); err != nil ||
+		s != "SET id=1,pay=2" {
+		t.Errorf("Buildstr with a computed column: %s %v", s, err)
+	}
+	if _, err = Buildstr(&SelPay{}, "Rank"); err == nil ||
+		!strings.Contains(err.Error(), "computed") {
+		t.Errorf("Buildstr of a computed column: %v", err)
+	}
+	if s, _ = CreateTableSQL(SelPay{}, "t", SQLite); strings.Contains(s,
+		"rnk") {
+		t.Errorf("computed column in DDL: %s", s)
+	}
+
+	for _, bad := range []interface{}{nil, 3} {
+		if _, err = Selectstr(bad); err == nil {
+			t.Errorf("Selectstr(%#v) accepted", bad)
+		}
+	}
+	if _, err = Selectstr(SelPay{}, "Nope"); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err = SelectstrAs("p q", SelPay{}); err == nil {
+		t.Error("bad alias accepted")
+	}
+}
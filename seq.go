@@ -0,0 +1,109 @@
+package sqlaux
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// NextVal 在db上按d的规范取序列sequence的下一个值：PostgreSQL 为
+// nextval('sequence')，MySQL（MariaDB 10.3起）为NEXTVAL(sequence)，名为
+// "oracle"的自定义方言为sequence.NEXTVAL。其他数据库不支持，报错。
+// db 为nil时由路由函数选择写句柄，路由的结构名同ExecContext。
+func NextVal(ctx context.Context, db Querier, d Dialect,
+	sequence string) (int64, error) {
+	n, err := nextval(ctx, db, d, "", sequence)
+	if err != nil {
+		return 0, fmt.Errorf("NextVal: %v", err)
+	}
+	return n, nil
+}
+
+// nextval 为NextVal的实现，stru 为路由的结构名。
+func nextval(ctx context.Context, db Querier, d Dialect, stru,
+	sequence string) (int64, error) {
+	if d == nil {
+		return 0, fmt.Errorf("no dialect argument")
+	}
+	var query string
+	var args []interface{}
+	switch d.Name() {
+	case "postgres":
+		query, args = "SELECT nextval($1)", []interface{}{sequence}
+	case "mysql":
+		query = "SELECT NEXTVAL(" + sequence + ")"
+	case "oracle":
+		query = "SELECT " + sequence + ".NEXTVAL FROM dual"
+	default:
+		return 0, fmt.Errorf("unsupported dialect %q", d.Name())
+	}
+	db, err := route(ctx, db, RouteWrite, stru)
+	if err != nil {
+		return 0, err
+	}
+	query, args, err = rewrite(ctx, query, args)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = queryRow(ctx, db, query, args, &n)
+	return n, err
+}
+
+// AssignSeq 为data中tag有"seq=序列名"、值为0的整数字段，用NextVal取其序列的
+// 下一个值并赋给字段，使没有RETURNING子句的数据库（或不便使用它的场合）在
+// INSERT之前就得到主键等值，如：
+//
+//	ID int64 `db:"pk seq=users_id_seq"`
+//
+// 已有非0值的字段保持不变。出错时，data中此前的字段可能已被赋值。
+//
+// 约定：data的类型形如[]*struct或*struct，参数db、d同NextVal，但路由的结构
+// 名为data的结构名。
+func AssignSeq(ctx context.Context, db Querier, d Dialect,
+	data interface{}) error {
+	mapping := loadmap()
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr {
+		v = reflect.ValueOf([]interface{}{data})
+	}
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("AssignSeq: data not like []*struct or *struct")
+	}
+	for i := 0; i < v.Len(); i++ {
+		p := reflect.ValueOf(v.Index(i).Interface())
+		if p.Kind() != reflect.Ptr || p.Elem().Kind() != reflect.Struct ||
+			p.IsNil() {
+			return fmt.Errorf("AssignSeq: data not like []*struct or *struct")
+		}
+		stru := p.Type().Elem().Name()
+		var err error
+		if mapping, err = automap(mapping, p.Type().Elem()); err != nil {
+			return fmt.Errorf("AssignSeq: %v", err)
+		}
+		e, ok := mapping[stru]
+		if !ok {
+			return fmt.Errorf("AssignSeq: %q has no mapping", stru)
+		}
+		for _, n := range e.name.([]string) {
+			m := mapping["0."+stru+"."+n]
+			if m.info == nil || m.info.seq == "" {
+				continue
+			}
+			f := reflect.NewAt(m.typ, m.addrw(p.UnsafePointer())).Elem()
+			if !f.IsZero() {
+				continue
+			}
+			id, err := nextval(ctx, db, d, stru, m.info.seq)
+			if err != nil {
+				return fmt.Errorf("AssignSeq: %s.%s %v", stru, n, err)
+			}
+			if f.Kind() >= reflect.Uint && f.Kind() <= reflect.Uint64 {
+				f.SetUint(uint64(id))
+			} else {
+				f.SetInt(id)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+type seqOracle struct{ Dialect }
+
+func (seqOracle) Name() string { return "oracle" }
+
+type SeqUser struct {
+	ID   int64  `db:"pk seq=users_id_seq"`
+	No   uint32 `db:"seq=user_no"`
+	Name string
+}
+
+func TestNextVal(t *testing.T) {
+	ctx := context.Background()
+	tresult("SELECT nextval($1)", []string{"nextval"},
+		[]driver.Value{int64(7)})
+	tresult("SELECT NEXTVAL(s)", []string{"n"}, []driver.Value{int64(8)})
+	tresult("SELECT s.NEXTVAL FROM dual", []string{"n"},
+		[]driver.Value{int64(9)})
+	for i, d := range []Dialect{PostgreSQL, MySQL, seqOracle{MySQL}} {
+		n, err := NextVal(ctx, tdb, d, "s")
+		if err != nil || n != int64(7+i) {
+			t.Errorf("%s: %d %v", d.Name(), n, err)
+		}
+	}
+	for _, d := range []Dialect{SQLite, nil} {
+		if _, err := NextVal(ctx, tdb, d, "s"); err == nil {
+			t.Errorf("dialect %v accepted", d)
+		}
+	}
+}
+
+func TestAssignSeq(t *testing.T) {
+	ctx := context.Background()
+	tresult("SELECT nextval($1)", []string{"nextval"},
+		[]driver.Value{int64(7)})
+	d := []*SeqUser{{Name: "a"}, {ID: 3, No: 4, Name: "b"}}
+	if err := AssignSeq(ctx, tdb, PostgreSQL, d); err != nil {
+		t.Fatal(err)
+	}
+	if *d[0] != (SeqUser{7, 7, "a"}) || *d[1] != (SeqUser{3, 4, "b"}) {
+		t.Errorf("got %v %v", *d[0], *d[1])
+	}
+	u := &SeqUser{}
+	if err := AssignSeq(ctx, tdb, PostgreSQL, u); err != nil || u.ID != 7 {
+		t.Errorf("*struct: %v %v", *u, err)
+	}
+	if err := AssignSeq(ctx, tdb, SQLite, &SeqUser{}); err == nil {
+		t.Error("SQLite accepted")
+	}
+	if err := AssignSeq(ctx, tdb, PostgreSQL, &d); err == nil {
+		t.Error("*[]*struct accepted")
+	}
+}
@@ -12,7 +12,9 @@
 // sqlaux支持两种映射：
 //	1. 小写(列名) != 小写(字段名)时的名称映射。这是必需的映射。
 //		sqlaux 通过struct tag识别字段、列名对应关系，默认将所有导出字段名
-//		（含除time.Time外的嵌套结构成员）小写，作为与其对应的数据库表列名。
+//		（含除time.Time、sql.Scanner及sqlaux内置等价类型外的嵌套结构成员，嵌
+//		套结构可以是指针）小写，作为与其对应的数据库表列名；也可以由
+//		Config.NameMapper转换，如SnakeCase。
 //	2. 字段类型不能直接用于数据库读写时的类型映射。这是可选的映射。
 //		通常这时字段应使用自定义类型，并且实现sql.Scanner和/或driver.Valuer
 //		接口，这不需要作映射。但对于切片等Go原生类型，直接使用自定义类型会带
@@ -22,12 +24,18 @@
 package sqlaux
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"io"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 	"unsafe"
 )
@@ -37,17 +45,128 @@ import (
 // 接收字段地址时，name借指字段所在结构在接收结构切片中的索引；offset 表示字
 // 段相对最外层struct的全局偏移；typ为字段类型，或其等价的实现了sql.Scanner/
 // driver.Valuer接口的自定义类型。offset、typ在两个映射中是重复的。???
+// info 为字段tag中的附加选项，没有时为nil，两个映射共用。via 为字段所经由的
+// 嵌入结构指针字段，不为空时offset相对于最后一个所指的结构。
 type entryT struct {
 	name   interface{}
 	offset uintptr
 	typ    reflect.Type
+	info   *fieldT
+	via    []hopT
 }
 
-// mapping 为Go数据结构与数据库表的映射。key 分为三种情况：
-//	● "1.struct名.column名"，表示column-->field的映射，用于Scan()
+// hopT 为经由的结构指针字段：offset 为其相对于上一级结构的偏移，typ 为其所指
+// 的结构类型。
+type hopT struct {
+	offset uintptr
+	typ    reflect.Type
+}
+
+// addr 返回地址为p的最外层结构中字段e的地址，用于读取：经由的结构指针为nil
+// 时返回e.typ零值的地址。
+func (e entryT) addr(p unsafe.Pointer) unsafe.Pointer {
+	for _, h := range e.via {
+		if p = *(*unsafe.Pointer)(unsafe.Add(p, h.offset)); p == nil {
+			return reflect.New(e.typ).UnsafePointer()
+		}
+	}
+	return unsafe.Add(p, e.offset)
+}
+
+// addrw 与addr相同，但用于写入：经由的结构指针为nil时为其分配所指的结构。
+func (e entryT) addrw(p unsafe.Pointer) unsafe.Pointer {
+	for _, h := range e.via {
+		pp := (*unsafe.Pointer)(unsafe.Add(p, h.offset))
+		if *pp == nil {
+			*pp = reflect.New(h.typ).UnsafePointer()
+		}
+		p = *pp
+	}
+	return unsafe.Add(p, e.offset)
+}
+
+// same 报告e与o是否为同一结构中的同一字段。
+func (e entryT) same(o entryT) bool {
+	if e.offset != o.offset || len(e.via) != len(o.via) {
+		return false
+	}
+	for i := range e.via {
+		if e.via[i].offset != o.via[i].offset {
+			return false
+		}
+	}
+	return true
+}
+
+// 映射为Go数据结构与数据库表的映射。key 分为以下几种情况：
+//	● "1.struct名.column名"，表示column-->field的映射，用于Scan()；字段
+//	  的别名（tag "alias="）也作为column名
 //	● "0.struct名.field名"，表示field-->column的映射，用于Buildstr()
 //	● "struct名"，表示该结构的映射已建立
-var mapping = make(map[string]entryT)
+//	● "w.struct名"，仅当结构含有计算列（expr）时存在，name为可写字段名切片
+//	● "c.struct名"，仅当结构含有子表字段（child）时存在，name为其字段名切片；
+//	  "c.struct名.field名"为子表字段的映射项，name为childT
+//	● "t.struct名"，仅当结构登记了表名（见Tablename）时存在，name为表名
+//	● "h.struct名"，仅当结构登记了历史表（见MapHistory）时存在，name为
+//	  historyT
+//	● "p.struct名"，仅当结构登记了分区解析函数（见MapPartition）时存在，
+//	  name为PartitionResolver
+//
+// 映射以只读快照的形式发布：读者用loadmap取得当前快照，在一次调用中使用同一
+// 快照；写者（MapStruct、MapType、MapTable、MapHistory等）在regMu保护下复制
+// 快照、修改后再原子地发布新快照（见update），因而映射可以在任何时候安全地
+// 建立。
+var snapshot atomic.Value
+
+// regMu 串行化映射和钩子（见updatehooks）的修改，同时保护typemap。
+var regMu sync.Mutex
+
+// loadmap 返回映射的当前快照，调用者不能修改它。
+func loadmap() map[string]entryT {
+	mp, _ := snapshot.Load().(map[string]entryT)
+	return mp
+}
+
+// update 复制映射的当前快照，以fn修改副本，fn 成功时发布副本为新快照。
+func update(fn func(mp map[string]entryT) error) error {
+	regMu.Lock()
+	defer regMu.Unlock()
+	old := loadmap()
+	mp := make(map[string]entryT, len(old)+16)
+	for k, v := range old {
+		mp[k] = v
+	}
+	if err := fn(mp); err != nil {
+		return err
+	}
+	snapshot.Store(mp)
+	return nil
+}
+
+// automap 在mp中没有结构t的映射时，为其自动建立映射（同MapStruct），返回新
+// 的快照；已有映射、t 不是具名结构或配置中的InitOnly为true时返回mp。以结构
+// （或其指针、切片）为参数的导出函数都经由它取得映射，从而免去显式的
+// MapStruct，显式映射仍可用于在启动时检查tag。
+func automap(mp map[string]entryT, t reflect.Type) (map[string]entryT,
+	error) {
+	if t == nil || t.Kind() != reflect.Struct || t.Name() == "" ||
+		conf().InitOnly {
+		return mp, nil
+	}
+	if _, ok := mp[t.Name()]; ok {
+		return mp, nil
+	}
+	err := update(func(mapping map[string]entryT) error {
+		if _, ok := mapping[t.Name()]; ok { // mapped by another goroutine
+			return nil
+		}
+		return register(mapping, t.Name(), reflect.New(t).Elem())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("auto map: %v", err)
+	}
+	return loadmap(), nil
+}
 
 // isinit 检查映射初始化函数是否在init()中调用，以防止出现竞争条件。
 func isinit() bool {
@@ -67,44 +186,126 @@ func isinit() bool {
 
 // 以下三个导出变量为struct tag，用于sqlaux识别结构字段所对应的数据库列名。
 // Tag标签名；Key列名键；Op键值分隔符。如：`db:"col=xxx yyy=zzz"`
+//
+// Deprecated: 以SetConfig设置Config.Tag、Key、Op。这三个变量只在首次使用
+// sqlaux时读取一次，作为缺省配置，此后的修改被忽略。
 var (
 	Tag = "db"
 	Key = "col"
 	Op  = "="
 )
 
-// MapStruct 为Go数据结构与数据库表建立名称映射。调用者需在init()中，对每一
-// 个关联数据库的结构调用此函数进行显式映射。
+// Validate 为true时，Buildstr等在拼接值串前按字段tag中声明的约束（如size、
+// check）检查字段值，违反时报错，从而避免一次无谓的数据库往返。
+//
+// Deprecated: 以SetConfig或Config.Buildstr等设置Config.Validate；本变量只在
+// 首次使用sqlaux时读取一次。
+var Validate = false
+
+// Deterministic 为true时，Buildstr等所有生成SQL文本的函数保证相同的输入在任
+// 何运行中都生成逐字节相同的结果，以便语句缓存和golden测试：字段总是按映射
+// 顺序、多个键值总是按键排序，time.Time值一律转为UTC后再格式化（不受运行环
+// 境时区的影响），无法确定地生成字面值的类型（其Go语法表示可能含有指针地址）
+// 报错。
+//
+// Deprecated: 以SetConfig或Config.Buildstr等设置Config.Deterministic；本变
+// 量只在首次使用sqlaux时读取一次。
+var Deterministic = false
+
+// MapStruct 为Go数据结构与数据库表建立名称映射。调用者通常在init()中，对每
+// 一个关联数据库的结构调用此函数进行显式映射；也可以在任何时候并发地调用（
+// 配置中的InitOnly为true时除外）。stru 中任一结构映射失败时，都不建立映射。
 // stru为需要映射的数据结构，以变量值的形式作参数，可以取零值。
 func MapStruct(stru ...interface{}) error {
-	// check caller is init(), ensure no race condition
-	if !isinit() {
+	// strict mode: check caller is init()
+	if conf().InitOnly && !isinit() {
 		return fmt.Errorf("MapStruct: must be called in init()")
 	}
 
-	for _, d := range stru {
-		v := reflect.Indirect(reflect.ValueOf(d))
-		s := v.Type().Name()
-		if s == "" || v.Kind() != reflect.Struct {
-			return fmt.Errorf("MapStruct: invalid struct %q", v.Type())
+	err := update(func(mapping map[string]entryT) error {
+		for _, d := range stru {
+			t, err := structarg(d)
+			if err != nil {
+				return err
+			}
+			s, v := t.Name(), reflect.New(t).Elem()
+			if s == "" {
+				return fmt.Errorf("invalid struct %q", t)
+			}
+			if _, ok := mapping[s]; ok {
+				return fmt.Errorf("%q already mapped", t)
+			}
+			if err := register(mapping, s, v); err != nil {
+				return err
+			}
 		}
-		if _, ok := mapping[s]; ok {
-			return fmt.Errorf("MapStruct: %q already mapped", v.Type())
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("MapStruct: %v", err)
+	}
+	return nil
+}
+
+// cyclic 报告结构t经由结构指针字段via嵌套结构e时是否形成循环，如链表节点中
+// 指向同类节点的指针，这样的字段不递归。
+func cyclic(t reflect.Type, via []hopT, e reflect.Type) bool {
+	if e == t {
+		return true
+	}
+	for _, h := range via {
+		if h.typ == e {
+			return true
 		}
-		fs, err := initmap(s, v, 0)
+	}
+	return false
+}
+
+// register 在mp中为名为s的结构v建立映射。
+func register(mp map[string]entryT, s string, v reflect.Value) error {
+	fs, err := initmap(mp, s, v, 0, nil, nil, "")
+	if err != nil {
+		return err
+	}
+	mp[s] = entryT{name: fs} // mark this struct as initiated
+	ws := make([]string, 0, len(fs))
+	for _, f := range fs {
+		if e := mp["0."+s+"."+f]; e.info == nil || e.info.expr == "" {
+			ws = append(ws, f)
+		}
+	}
+	if len(ws) < len(fs) {
+		mp["w."+s] = entryT{name: ws}
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ { // table name on blank field
+		if t.Field(i).Name != "_" {
+			continue
+		}
+		kv, err := parsetag(t.Field(i).Tag.Get(conf().Tag))
 		if err != nil {
-			return fmt.Errorf("MapStruct: %v", err)
+			return fmt.Errorf("%s._ %v", s, err)
+		}
+		if tb, ok := kv["table"]; ok {
+			if !istable(tb) {
+				return fmt.Errorf("%s._ bad tagged 'table'", s)
+			}
+			mp["t."+s] = entryT{name: tb}
 		}
-		mapping[s] = entryT{name: fs} // mark this struct as initiated
 	}
-
 	return nil
 }
 
-// initmap 递归遍历结构v，为所有导出字段创建映射项。s为完整结构名（可能为嵌
-// 套结构），b为结构相对于最外层结构的全局偏移量，返回的切片为字段名。
-func initmap(s string, v reflect.Value, b uintptr) ([]string, error) {
+// initmap 递归遍历结构v，在mp中为所有导出字段创建映射项。s为完整结构名（可能为嵌
+// 套结构），b为结构相对于最外层结构的全局偏移量，via 不为空时为经由的结构指
+// 针字段，b相对于其最后一个所指的结构，ov为外层结构通过tag
+// "override="对v的字段列名的覆盖（字段名-->列名），pre为外层结构通过tag
+// "prefix="为v的字段列名添加的前缀（ov中的列名已含前缀），返回的切片为字段
+// 名。嵌套结构可以是指针（*struct），Scan 时为其分配结构。
+func initmap(mp map[string]entryT, s string, v reflect.Value, b uintptr,
+	via []hopT, ov map[string]string, pre string) ([]string, error) {
 	dot := strings.Index(s, ".") // for diff the most outer struct name
+	cfg := conf()
 	t := v.Type()
 	fs := make([]string, 0, t.NumField())
 	for i := 0; i < t.NumField(); i++ {
@@ -112,36 +313,117 @@ func initmap(s string, v reflect.Value, b uintptr) ([]string, error) {
 		if !unicode.IsUpper([]rune(tt.Name)[0]) { // ignore non-exported
 			continue
 		}
-		col := strings.ToLower(tt.Name) // record its default column name
+		col := colname(cfg, tt.Name)    // record its default column name
 		nt := tt.Type                   // record its type
 		if ntt, ok := typemap[nt]; ok { // use mapped type if possible
 			nt = ntt
 		}
 		got := false // record if found tagged column name
-		tags := tt.Tag.Get(Tag)
-		if tags != "" {
-			vs := strings.Fields(tags)
-			for _, v := range vs {
-				fc := strings.Split(v, Op)
-				if fc[0] == Key {
-					col = fc[1]
-					got = true
-					break
-				}
+		kv, err := parsetag(tt.Tag.Get(cfg.Tag))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s %v", s, tt.Name, err)
+		}
+		if c, ok := kv[cfg.Key]; ok {
+			col = c
+			got = true
+		}
+		if c, ok := kv["child"]; ok { // values are rows of a child table
+			if err := initchild(mp, s, tt, b, via, c); err != nil {
+				return nil, fmt.Errorf("%s.%s %v", s, tt.Name, err)
 			}
+			continue
+		}
+		col = pre + col
+		if c, ok := ov[tt.Name]; ok { // the outer struct has the final say
+			col = c
+			got = true
 		}
-		if !got && tt.Type.Kind() == reflect.Struct && // recursive struct
-			tt.Type.String() != "time.Time" { // except "time.Time"
-			ffs, err := initmap(s+"."+tt.Name, v.Field(i), b+tt.Offset)
+		// json column is never recursive
+		_, js := kv["json"]
+		st, fv, nb, nvia := tt.Type, v.Field(i), b+tt.Offset, via
+		if st.Kind() == reflect.Ptr && !cyclic(t, via, st.Elem()) {
+			st = st.Elem() // recursive through pointer, allocated when Scan
+			fv, nb = reflect.New(st).Elem(), 0
+			nvia = append(via[:len(via):len(via)], hopT{b + tt.Offset, st})
+		}
+		if !got && !js && st.Kind() == reflect.Struct && // recursive
+			st.String() != "time.Time" && // except "time.Time"
+			!reflect.PtrTo(st).Implements(scannerType) && // Scanner
+			builtin(st, nil) == st { // and builtin equivalents
+			sub, err := parseoverride(kv["override"])
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s %v", s, tt.Name, err)
+			}
+			own := len(sub)
+			for k, c := range sub { // only prefixed by outer levels
+				sub[k] = pre + c
+			}
+			for k, c := range ov { // inherit outer overrides of this struct
+				if strings.HasPrefix(k, tt.Name+".") {
+					sub[k[len(tt.Name)+1:]] = c
+				}
+			}
+			p, ok := kv["prefix"]
+			if ok && (p == "" || strings.ToLower(p) != p) {
+				return nil, fmt.Errorf("%s.%s bad tagged 'prefix'", s, tt.Name)
+			}
+			ffs, err := initmap(mp, s+"."+tt.Name, fv, nb, nvia, sub, pre+p)
 			if err != nil {
 				return nil, err
 			}
+			if own > 0 { // all overridden fields must exist
+				keys := make([]string, 0, len(sub))
+				for k := range sub {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys) // report the same one every run
+				for _, k := range keys {
+					if _, ok := mp["0."+s+"."+tt.Name+"."+k]; !ok {
+						return nil, fmt.Errorf("%s.%s override unknown "+
+							"field %q", s, tt.Name, k)
+					}
+				}
+			}
 			fs = append(fs, ffs...)
 		} else {
 			if col == "" || strings.ToLower(col) != col {
+				if !got && cfg.NameMapper != nil {
+					return nil, fmt.Errorf("%s.%s bad NameMapper result %q",
+						s, tt.Name, col)
+				}
 				return nil, fmt.Errorf("%s.%s bad tagged 'col'", s, tt.Name)
 			}
-			mapping["0."+s+"."+tt.Name] = entryT{col, b + tt.Offset, nt}
+			if err := CheckIdent(cfg.CheckDialect, col); err != nil {
+				return nil, fmt.Errorf("%s.%s %v", s, tt.Name, err)
+			}
+			info, err := fieldinfo(kv, tt.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s %v", s, tt.Name, err)
+			}
+			if nt == tt.Type { // not mapped by MapType
+				nt = builtin(nt, info)
+			}
+			if expr, ok := kv["check"]; ok {
+				if strings.TrimSpace(expr) == "" {
+					return nil, fmt.Errorf("%s.%s bad tagged 'check'", s,
+						tt.Name)
+				}
+				if info == nil {
+					info = new(fieldT)
+				}
+				info.check = parsecheck(expr, col)
+			}
+			if dv, ok := kv["scan_default"]; ok {
+				if info == nil {
+					info = new(fieldT)
+				}
+				info.def = reflect.New(nt).Elem()
+				if err = convert(info.def, dv); err != nil {
+					return nil, fmt.Errorf("%s.%s bad tagged 'scan_default'",
+						s, tt.Name)
+				}
+			}
+			mp["0."+s+"."+tt.Name] = entryT{col, b + tt.Offset, nt, info, via}
 			sss := "1." // "1.the-most-outer-struct.column"
 			if dot == -1 {
 				sss += s + "." + col
@@ -150,17 +432,38 @@ func initmap(s string, v reflect.Value, b uintptr) ([]string, error) {
 				sss += s[:dot+1] + col
 				fs = append(fs, s[dot+1:]+"."+tt.Name)
 			}
-			if _, ok := mapping[sss]; ok { // column maybe wrong duplicate
+			if _, ok := mp[sss]; ok { // column maybe wrong duplicate
 				return nil, fmt.Errorf("%q duplicate column map %q", s, col)
 			}
-			mapping[sss] = entryT{nil, b + tt.Offset, nt}
+			mp[sss] = entryT{nil, b + tt.Offset, nt, info, via}
+			if info != nil && info.alias != "" { // scanned by other names too
+				for _, a := range strings.Split(info.alias, ",") {
+					if a == "" || strings.ToLower(a) != a {
+						return nil, fmt.Errorf("%s.%s bad tagged 'alias'",
+							s, tt.Name)
+					}
+					if err := CheckIdent(cfg.CheckDialect, a); err != nil {
+						return nil, fmt.Errorf("%s.%s %v", s, tt.Name, err)
+					}
+					a = sss[:strings.LastIndex(sss, ".")+1] + pre + a
+					if _, ok := mp[a]; ok {
+						return nil, fmt.Errorf("%q duplicate column map %q",
+							s, a[strings.LastIndex(a, ".")+1:])
+					}
+					mp[a] = mp[sss]
+				}
+			}
 		}
 	}
 	return fs, nil
 }
 
+// scannerType 为sql.Scanner接口类型。实现了该接口的结构（如sql.NullString）
+// 作为一个整体映射，不再递归其成员。
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
 // typemap 为字段类型到其等价的实现了sql.Scanner/driver.Valuer接口的自定义类
-// 型的映射。这实际上是一个临时变量，初始化过程结束后，该变量就不再使用。???
+// 型的映射，仅在建立映射时使用，由regMu保护。
 var typemap = make(map[reflect.Type]reflect.Type)
 
 // MapType 为Go数据结构与数据库表建立类型映射。调用者需在init()中，针对每一
@@ -168,32 +471,43 @@ var typemap = make(map[reflect.Type]reflect.Type)
 // 数进行显式映射。orig、self分别为原生类型和自定义类型值，可以用零值。
 // sql.Scanner接收器为指针型，driver.Valuer接收器为值型，sqlaux约定这里的参
 // 数统一用T而不用*T。参见包文档和README。
+// 与MapStruct相同，配置中的InitOnly为false时可以在任何时候调用；已缓存的Scan
+// 接收计划随之作废。
 func MapType(orig, self interface{}) error {
-	// check caller is init(), ensure no race condition
-	if !isinit() {
+	// strict mode: check caller is init()
+	if conf().InitOnly && !isinit() {
 		return fmt.Errorf("MapType: must be called in init()")
 	}
 
-	// check if already initiated
 	ov := reflect.TypeOf(orig)
 	sv := reflect.TypeOf(self)
-	if _, ok := typemap[ov]; ok {
-		return fmt.Errorf("MapType: type %q already mapped", ov)
-	}
+	err := update(func(mapping map[string]entryT) error {
+		// check if already initiated
+		if _, ok := typemap[ov]; ok {
+			return fmt.Errorf("type %q already mapped", ov)
+		}
 
-	// check if the 2 types deep equal
-	if !ov.ConvertibleTo(sv) {
-		return fmt.Errorf("MapType: %q not convertible to %q", ov, sv)
-	}
+		// check if the 2 types deep equal
+		if !ov.ConvertibleTo(sv) {
+			return fmt.Errorf("%q not convertible to %q", ov, sv)
+		}
 
-	// map and update mapping
-	typemap[ov] = sv
-	for k, v := range mapping {
-		if v.typ == ov {
-			v.typ = sv
-			mapping[k] = v
+		// map and update mapping
+		typemap[ov] = sv
+		for k, v := range mapping {
+			if v.typ == ov || v.typ != nil && v.typ == builtin(ov, v.info) {
+				v.typ = sv
+				mapping[k] = v
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("MapType: %v", err)
 	}
+	planCache.Lock()
+	planCache.m = make(map[string]*planT) // plans of the old types
+	planCache.Unlock()
 	return nil
 }
 
@@ -201,70 +515,331 @@ func MapType(orig, self interface{}) error {
 // 果，覆盖写入dest。接收后Scan 不主动关闭rows。
 //
 // 约定：
-//	● 每一个dest的类型形如*[]*struct。
+//	● 每一个dest的类型形如*[]*struct，或形如*struct：只接收第一行，结果集为空
+//		时返回sql.ErrNoRows（可用errors.Is判断），且不改变任何dest。
 //	● SELECT选择列时逐表罗列，表间可选地用''空列分隔。当两表“交界”处有重名列
 //		时，默认sqlaux将其视为前一个表的列，用空列区隔可避免重名歧义。
+//
+// 参见MaxRows。
 func Scan(rows *sql.Rows, dest ...interface{}) error {
+	mapping := loadmap()
+	if err := scan(rows, &scanOpts{mp: mapping}, dest); err != nil {
+		return fmt.Errorf("Scan: %w", err)
+	}
+	return nil
+}
+
+// ScanContext 与Scan相同，但在接收每一行之前检查ctx，ctx 被取消时停止接收，
+// 返回包含ctx.Err()的错误（可用errors.Is判断），且不改变任何dest；配置中的
+// Partial为true时（见WithPartial），则将已接收的行写入dest后再返回该错误，
+// 以便批处理程序记录进度，而不是丢失全部结果。
+func ScanContext(ctx context.Context, rows *sql.Rows,
+	dest ...interface{}) error {
+	return conf().ScanContext(ctx, rows, dest...)
+}
+
+// ScanUnique 与Scan相同，但对于有主键（tag中有"pk"）的结构，按主键去重，只
+// 保留其第一次出现的行，用于连接查询中同一父行重复出现的情况，如：
+//
+//	SELECT 订单列...,'',订单明细列... FROM 订单 JOIN 订单明细 ...
+//
+// 接收后每个订单只出现一次。没有主键的结构不去重。
+func ScanUnique(rows *sql.Rows, dest ...interface{}) error {
+	mapping := loadmap()
+	o := &scanOpts{mp: mapping, unique: true}
+	if err := scan(rows, o, dest); err != nil {
+		return fmt.Errorf("ScanUnique: %w", err)
+	}
+	return nil
+}
+
+// ScanAlias 与Scan相同，但按调用处给出的别名alias（结果列名-->映射列名，大小
+// 写不敏感）接收结果列，用于不宜全局映射的表达式列，如：
+//
+//	SELECT dept,COUNT(*) AS n FROM ... 以{"n": "headcount"}接收到headcount列
+//
+// 另外，任何表达式列只要其别名为映射列名（如"COUNT(*) AS headcount"），Scan
+// 等都可以直接接收。
+func ScanAlias(rows *sql.Rows, alias map[string]string,
+	dest ...interface{}) error {
+	mapping := loadmap()
+	a := make(map[string]string, len(alias))
+	for k, v := range alias {
+		a[strings.ToLower(k)] = strings.ToLower(v)
+	}
+	if err := scan(rows, &scanOpts{mp: mapping, alias: a}, dest); err != nil {
+		return fmt.Errorf("ScanAlias: %w", err)
+	}
+	return nil
+}
+
+// ScanShared 与Scan相同，但结果列中名为shared（大小写不敏感）的列，除按Scan
+// 约定接收到其所属的结构外，还同时接收到其他所有映射了该列的dest结构中，从
+// 而多个dest都需要的列（如id）只需选择一次，如：
+//
+//	SELECT id,name,'',total FROM ... 以shared为{"id"}接收到订单和汇总的ID
+//
+// 不同结构中该列的字段类型须可以转换，但整数不转换为字符串（Go的转换结果为
+// 字符而不是数字串）。
+func ScanShared(rows *sql.Rows, shared []string,
+	dest ...interface{}) error {
+	sh := make(map[string]bool, len(shared))
+	for _, c := range shared {
+		sh[strings.ToLower(c)] = true
+	}
+	if err := scan(rows, &scanOpts{mp: loadmap(), shared: sh},
+		dest); err != nil {
+		return fmt.Errorf("ScanShared: %w", err)
+	}
+	return nil
+}
+
+// scanOpts 为Scan各变体的选项。
+type scanOpts struct {
+	mp     map[string]entryT // 映射，全局映射或MapAdhoc的独立映射
+	id     string            // 独立映射的标识，用于区分接收计划缓存
+	unique bool              // 按主键去重，见ScanUnique
+	alias  map[string]string // 结果列名（小写）到映射列名的别名，见ScanAlias
+	shared map[string]bool   // 共享列名（小写），见ScanShared
+	cfg    *Config           // 配置，nil 为全局配置
+	ctx    context.Context   // 不为nil时，每行之前检查其是否已取消
+	// into 不为nil时，scanEach 不为每行新建结构变量，而是在读取每行之前调用它
+	// 取得各结构变量填入tmp，返回false时停止接收；读到下一行后才将其置为零
+	// 值。见ScanInto
+	into func(tmp []reflect.Value) bool
+}
+
+// scan 为Scan各变体的公共实现。
+func scan(rows *sql.Rows, o *scanOpts, dest []interface{}) (err error) {
 	l := len(dest)
 	if l == 0 {
-		return fmt.Errorf("Scan: no dest argument")
+		return fmt.Errorf("no dest argument")
+	}
+	if o.cfg == nil {
+		o.cfg = conf()
 	}
 
 	// prepare receiver variable
 	typ := make([]reflect.Type, l)  // type of every dest
 	rsa := make([]reflect.Value, l) // []*struct, for accumulating results
-	for i, d := range dest {
-		t := reflect.TypeOf(d)          // *[]*struct
+	one := make([]bool, l)          // dest is *struct, receiving one row
+	for i := range dest {
+		var t reflect.Type
+		var err error
+		if one[i] = single(dest[i]); one[i] { // as *[]*struct
+			t = reflect.PtrTo(reflect.SliceOf(reflect.TypeOf(dest[i])))
+		} else if t, err = destof(dest, i); err != nil {
+			return err
+		}
 		typ[i] = t.Elem().Elem().Elem() // struct
-		if !strings.HasPrefix(t.String(), "*[]*") ||
-			typ[i].Kind() != reflect.Struct {
-			return fmt.Errorf("Scan: dest[%d] not like *[]*struct", i)
+		rsa[i] = reflect.MakeSlice(t.Elem(), 0, o.cfg.Capacity)
+		if o.id == "" { // only the global mapping automaps
+			if o.mp, err = automap(o.mp, typ[i]); err != nil {
+				return err
+			}
+		}
+	}
+	var pks [][]entryT         // primary key fields of every dest
+	var seen []map[string]bool // primary keys received of every dest
+	if o.unique {
+		pks, seen = make([][]entryT, l), make([]map[string]bool, l)
+		for i, t := range typ {
+			pks[i], seen[i] = pkeys(o.mp, t.Name()), make(map[string]bool)
+		}
+	}
+
+	max := o.cfg.MaxRows
+	n := 0 // number of rows
+	if len(loadhooks().observers) > 0 {
+		defer observe("scan", typ, time.Now(), &n, &err)
+	}
+	err = scanEach(rows, typ, o, func(tmp []reflect.Value) error {
+		if o.ctx != nil && o.ctx.Err() != nil { // cancelled between rows
+			return o.ctx.Err()
+		}
+		if n++; max > 0 && n > max {
+			return &RowLimitError{max}
+		}
+		for i, v := range tmp {
+			if o.unique && len(pks[i]) > 0 {
+				k := pkey(v.UnsafePointer(), pks[i])
+				if seen[i][k] {
+					continue
+				}
+				seen[i][k] = true
+			}
+			rsa[i] = reflect.Append(rsa[i], v)
+		}
+		return nil
+	})
+	if err != nil && !(o.cfg.Partial && o.ctx != nil && o.ctx.Err() != nil) {
+		return err
+	}
+
+	// write to dest, or the partial results with err of the cancellation
+	for i := 0; i < l && err == nil; i++ {
+		if one[i] && rsa[i].Len() == 0 {
+			return sql.ErrNoRows // leave all dest untouched
+		}
+	}
+	for i := 0; i < l; i++ {
+		if !one[i] {
+			reflect.ValueOf(dest[i]).Elem().Set(rsa[i])
+		} else if rsa[i].Len() > 0 {
+			reflect.ValueOf(dest[i]).Elem().Set(rsa[i].Index(0).Elem())
 		}
-		rsa[i] = reflect.MakeSlice(t.Elem(), 0, 0) // []*struct
 	}
-	ref, err := scanField(rows, typ) // calculate dest fields reference
+	return err
+}
+
+// single 判断d的类型是否形如*struct且不为nil，即只接收一行的dest。
+func single(d interface{}) bool {
+	t := reflect.TypeOf(d)
+	return t != nil && t.Kind() == reflect.Ptr &&
+		t.Elem().Kind() == reflect.Struct && !reflect.ValueOf(d).IsNil()
+}
+
+// destof 检查dest[i]的类型形如*[]*struct且不为nil、不与其前的dest重复，返
+// 回其类型；否则返回指明参数序号、实际类型和期望形式的错误。
+func destof(dest []interface{}, i int) (reflect.Type, error) {
+	d := dest[i]
+	if d == nil {
+		return nil, fmt.Errorf("dest[%d] is nil, want *[]*struct", i)
+	}
+	t := reflect.TypeOf(d)
+	if t.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("dest[%d] is %v not a pointer, want *%v (pass "+
+			"its address)", i, t, t)
+	}
+	if reflect.ValueOf(d).IsNil() {
+		return nil, fmt.Errorf("dest[%d] is a nil %v", i, t)
+	}
+	switch e := t.Elem(); {
+	case e.Kind() == reflect.Struct:
+		return nil, fmt.Errorf("dest[%d] is %v, want *[]%v (a slice even "+
+			"for one row)", i, t, t)
+	case e.Kind() != reflect.Slice:
+		return nil, fmt.Errorf("dest[%d] is %v, want *[]*struct", i, t)
+	case e.Elem().Kind() == reflect.Struct:
+		return nil, fmt.Errorf("dest[%d] is %v, want *[]*%v (a slice of "+
+			"pointers)", i, t, e.Elem())
+	case e.Elem().Kind() != reflect.Ptr ||
+		e.Elem().Elem().Kind() != reflect.Struct:
+		return nil, fmt.Errorf("dest[%d] is %v, its elements are not "+
+			"*struct", i, t)
+	}
+	for j := 0; j < i; j++ {
+		if dest[j] == d {
+			return nil, fmt.Errorf("dest[%d] is the same as dest[%d]", i, j)
+		}
+	}
+	return t, nil
+}
+
+// scanEach 为Scan等的公共实现：逐行接收rows的结果，每行为typ中的各结构新建
+// 变量，接收后以其指针（*struct）切片调用fn，fn 返回错误时终止接收。
+func scanEach(rows *sql.Rows, typ []reflect.Type, o *scanOpts,
+	fn func(tmp []reflect.Value) error) (err error) {
+	var stru, col string // where a panic occurs
+	infn := false        // panics of fn are the caller's, go on
+	defer func() {
+		if r := recover(); r != nil {
+			if infn {
+				panic(r)
+			}
+			err = topanic(r, stru, col)
+		}
+	}()
+	plan, err := scanPlan(rows, typ, o) // calculate fields reference
 	if err != nil {
-		return fmt.Errorf("Scan: %v", err)
+		return err
+	}
+	cfg := o.cfg
+	if cfg == nil {
+		cfg = conf()
+	}
+	ref := plan.ref
+	// for NULL column '' and discarded ones, cannot be nil
+	var null = new(interface{})
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	guards := make([]guardT, len(ref)) // for Scanner fields
+	for i, e := range ref {
+		if e.name != nil {
+			guards[i] = guardT{stru: typ[e.name.(int)].Name(), col: cols[i]}
+		}
+	}
+	shared := make([]string, len(plan.copies)) // column names of copies
+	for i, c := range plan.copies {
+		for j, e := range ref {
+			if e.name == c[0].name && e.same(c[0]) {
+				shared[i] = cols[j]
+			}
+		}
 	}
-	var null = new(string) // for NULL column '', cannot be nil
 
-	// receive all results
-	tmp := make([]reflect.Value, l)      // new struct variable for a scan
-	ptr := make([]interface{}, len(ref)) // their appropriate fields pointer
-	for rows.Next() {
-		for i := 0; i < l; i++ { // create new struct variable
-			tmp[i] = reflect.New(typ[i])
-			rsa[i] = reflect.Append(rsa[i], tmp[i])
+	tmp := make([]reflect.Value, len(typ)) // new struct variable for a scan
+	ptr := make([]interface{}, len(ref))   // their appropriate fields pointer
+	for {
+		if o.into == nil {
+			if !rows.Next() {
+				break
+			}
+			for i := range typ { // create new struct variable
+				tmp[i] = reflect.New(typ[i])
+			}
+		} else if !o.into(tmp) || !rows.Next() { // caller's buffer
+			break
+		} else {
+			for i := range typ { // no stale fields
+				tmp[i].Elem().Set(reflect.Zero(typ[i]))
+			}
 		}
 		for i := 0; i < len(ref); i++ {
-			if ref[i].name == nil { // NULL column
+			if ref[i].name == nil { // NULL or discarded column
 				ptr[i] = null
 			} else {
-				p := unsafe.Pointer(tmp[ref[i].name.(int)].Pointer() +
-					ref[i].offset)
-				ptr[i] = reflect.NewAt(ref[i].typ, p).Interface()
+				stru, col = guards[i].stru, guards[i].col
+				p := ref[i].addrw(tmp[ref[i].name.(int)].UnsafePointer())
+				ptr[i] = ref[i].receiver(p, cfg)
+				if sc, ok := ptr[i].(sql.Scanner); ok {
+					guards[i].s, ptr[i] = sc, &guards[i]
+				}
 			}
 		}
+		stru, col = "", "" // Scanners are guarded by themselves
 		if err = rows.Scan(ptr...); err != nil {
-			return fmt.Errorf("Scan: %v", err)
+			return err
 		}
+		for _, e := range plan.defs { // absent columns, col is ""
+			stru = typ[e.name.(int)].Name()
+			e.setdefault(e.addrw(tmp[e.name.(int)].UnsafePointer()))
+		}
+		for i, c := range plan.copies { // shared columns
+			stru, col = typ[c[1].name.(int)].Name(), shared[i]
+			src := reflect.NewAt(c[0].typ,
+				c[0].addr(tmp[c[0].name.(int)].UnsafePointer())).Elem()
+			reflect.NewAt(c[1].typ,
+				c[1].addrw(tmp[c[1].name.(int)].UnsafePointer())).Elem().Set(
+				src.Convert(c[1].typ))
+		}
+		infn = true
+		if err = fn(tmp); err != nil {
+			return err
+		}
+		infn = false
 	}
-	if err = rows.Err(); err != nil {
-		return fmt.Errorf("Scan: %v", err)
-	}
-
-	// write to dest
-	for i := 0; i < l; i++ {
-		reflect.ValueOf(dest[i]).Elem().Set(rsa[i])
-	}
-	return nil
+	return rows.Err()
 }
 
-// scanField 根据rows.Columns()和映射，返回ts中适合 Scan的字段参考信息。
+// scanField 根据查询结果列名col和映射mp，返回ts中适合 Scan的字段参考信息。
 // 如果在当前struct中未找到某列名的映射，则必须在其紧接着的struct中找到，
-// 否则违背Scan约定。
-func scanField(rows *sql.Rows, ts []reflect.Type) ([]entryT, error) {
-	col, _ := rows.Columns()
+// 否则违背Scan约定；lenient 为true时则丢弃该列（其参考信息的name为nil）。
+// col 会被改写。
+func scanField(mp map[string]entryT, col []string, ts []reflect.Type,
+	lenient bool) ([]entryT, error) {
 	ref := make([]entryT, len(col))
 	var i, j int // i for col, j for ts
 	var v entryT
@@ -286,28 +861,32 @@ func scanField(rows *sql.Rows, ts []reflect.Type) ([]entryT, error) {
 			col[i] = strings.ToLower(col[i])
 		}
 		// mapping must exist in the current or the successive struct
-		if v, ok = mapping["1."+stru+"."+col[i]]; !ok {
-			j++
-			if j == len(ts) {
+		if v, ok = mp["1."+stru+"."+col[i]]; !ok {
+			if j+1 < len(ts) {
+				v, ok = mp["1."+ts[j+1].Name()+"."+col[i]]
+			}
+			if !ok && lenient {
+				continue // ref[i] is zero
+			}
+			if j++; j == len(ts) {
 				return nil, fmt.Errorf("column %q has no mapping", col[i])
 			}
 			stru = ts[j].Name()
-			if v, ok = mapping["1."+stru+"."+col[i]]; !ok {
+			if !ok {
 				return nil, fmt.Errorf("column %q has no mapping", col[i])
 			}
 		}
-		ref[i].name = j
-		ref[i].offset = v.offset
-		ref[i].typ = v.typ
+		v.name = j
+		ref[i] = v
 	}
-	if i < len(col) {
+	if i < len(col) && !lenient {
 		return nil, fmt.Errorf("column %v has no mapping", col[i:])
 	}
 	return ref, nil
 }
 
 // Buildstr 为单表SQL INSERT、UPDATE语句，将data的 field字段拼接成符合规范的
-//（赋）值串。field缺省时拼接所有映射字段。返回值：
+//（赋）值串。field缺省时拼接所有映射字段（计算列除外）。返回值：
 // data为切片时："(列名1,列名2,...) VALUES (值1,值2,...),..."，用于INSERT。
 // data为单值时："SET 列名1=值1,列名2=值2,..."，用于INSERT、UPDATE。
 //
@@ -317,128 +896,330 @@ func scanField(rows *sql.Rows, ts []reflect.Type) ([]entryT, error) {
 //
 // 注意：Buildstr不限制结果字符串的长度，调用者需防止SQL语句超长。
 func Buildstr(data interface{}, field ...string) (string, error) {
+	return conf().Buildstr(data, field...)
+}
+
+// BuildstrTo 与Buildstr相同，但将结果直接写入w，而不在内存中生成完整的字符
+// 串，适用于生成超大的数据导入脚本等场合。w 的第一个写入错误即终止拼接并返
+// 回。调用者可自行为w加缓冲。
+func BuildstrTo(w io.Writer, data interface{}, field ...string) error {
+	return conf().BuildstrTo(w, data, field...)
+}
+
+// errWriter 包装io.Writer，记录其第一个写入错误，之后的写入全部忽略。
+type errWriter struct {
+	w   io.Writer
+	err error
+	n   int64 // bytes written
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	e.n += int64(n)
+	return n, err
+}
+
+// build 为Buildstr、BuildstrTo的公共实现。d 为nil时按Go语法拼接字面值，c为
+// 配置，mp为映射。
+func build(w io.Writer, d Dialect, c *Config, mp map[string]entryT,
+	data interface{}, field ...string) error {
 	v := reflect.ValueOf(data)
 	t := v.Type()
 	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Ptr &&
 		t.Elem().Elem().Kind() == reflect.Struct {
 		if v.Len() == 0 {
-			return "", fmt.Errorf("Buildstr: data is nil")
+			return fmt.Errorf("data is nil")
 		}
-		return valuebuild(v, field...)
+		return valuebuild(w, d, c, mp, v, field...)
 	}
 	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
-		return setbuild(v, field...)
+		return setbuild(w, d, c, mp, v, field...)
 	}
-	return "", fmt.Errorf("Buildstr: argument 'data' bad type %q", t)
+	return fmt.Errorf("argument 'data' bad type %q", t)
 }
 
 // valuebuild equivalent to Buildstr, but just for []*struct.
-func valuebuild(v reflect.Value, field ...string) (string, error) {
+func valuebuild(w io.Writer, d Dialect, c *Config, mp map[string]entryT,
+	v reflect.Value, field ...string) error {
 	stru := v.Type().Elem().Elem().Name() // record struct name
-	if e, ok := mapping[stru]; ok {
+	if e, ok := mp[stru]; ok {
 		if len(field) == 0 { // default all mapped fields
 			field = e.name.([]string)
+			if w, ok := mp["w."+stru]; ok { // except computed ones
+				field = w.name.([]string)
+			}
 		}
 	} else {
-		return "", fmt.Errorf("Buildstr: %q has no mapping", stru)
+		return fmt.Errorf("%q has no mapping", stru)
 	}
 
 	// build: "(col1,col2,...) VALUES ("
-	var sql strings.Builder
-	sql.WriteString("(")
+	es := make([]entryT, len(field))
+	io.WriteString(w, "(")
 	for i, n := range field {
 		if i > 0 {
-			sql.WriteString(",")
+			io.WriteString(w, ",")
 		}
-		if m, ok := mapping["0."+stru+"."+n]; ok {
-			sql.WriteString(m.name.(string))
-		} else {
-			return "", fmt.Errorf("Buildstr: %q has no field %q", stru, n)
+		m, ok := mp["0."+stru+"."+n]
+		if !ok {
+			return nofield(mp, stru, n)
 		}
+		io.WriteString(w, m.name.(string))
+		es[i] = m
 	}
-	sql.WriteString(") VALUES (")
+	io.WriteString(w, ") VALUES (")
 
 	// build others
+	if err := valuestr(w, d, c, v, es); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, ")")
+	return err
+}
+
+// valuestr 向w写入v（[]*struct）中各元素es字段的值串："值1,值2,...),(值1,..."，
+// 首尾括号由调用者负责。
+func valuestr(w io.Writer, d Dialect, c *Config, v reflect.Value,
+	es []entryT) (err error) {
+	stru, col := v.Type().Elem().Elem().Name(), ""
+	defer recovered(&err, "", &stru, &col)
 	for i := 0; i < v.Len(); i++ {
 		if v.Index(i).IsNil() {
-			return "", fmt.Errorf("Buildstr: data[%d] is nil", i)
+			return fmt.Errorf("data[%d] is nil", i)
 		}
-		b := v.Index(i).Pointer() // base address
+		p := v.Index(i).UnsafePointer() // base address
 		if i > 0 {
-			sql.WriteString("),(")
+			if _, err := io.WriteString(w, "),("); err != nil {
+				return err // stop as soon as the writer fails
+			}
 		}
-		for j, n := range field {
+		for j, m := range es {
 			if j > 0 {
-				sql.WriteString(",")
+				io.WriteString(w, ",")
 			}
-			m := mapping["0."+stru+"."+n]
-			ptr := reflect.NewAt(m.typ, unsafe.Pointer(b+m.offset))
-			if err := buildstr(&sql, "", ptr); err != nil {
-				return "", fmt.Errorf("Buildstr: %v", err)
+			col = m.name.(string)
+			ptr := reflect.NewAt(m.typ, m.addr(p))
+			if err := m.value(w, d, c, "", ptr); err != nil {
+				return fmt.Errorf("data[%d] %v", i, err)
 			}
 		}
 	}
-
-	return sql.String() + ")", nil
+	return nil
 }
 
 // setbuild equivalent to Buildstr, but just for *struct.
-func setbuild(v reflect.Value, field ...string) (string, error) {
-	stru := v.Type().Elem().Name() // record struct name
-	if e, ok := mapping[stru]; ok {
+func setbuild(w io.Writer, d Dialect, c *Config, mp map[string]entryT,
+	v reflect.Value, field ...string) (err error) {
+	stru, col := v.Type().Elem().Name(), "" // record struct name
+	defer recovered(&err, "", &stru, &col)
+	if e, ok := mp[stru]; ok {
 		if len(field) == 0 { // default all mapped fields
 			field = e.name.([]string)
+			if w, ok := mp["w."+stru]; ok { // except computed ones
+				field = w.name.([]string)
+			}
 		}
 	} else {
-		return "", fmt.Errorf("Buildstr: %q has no mapping", stru)
+		return fmt.Errorf("%q has no mapping", stru)
 	}
 
-	var sql strings.Builder
-	sql.WriteString("SET ")
-	b := v.Pointer() // base address
-	for j, n := range field {
+	io.WriteString(w, "SET ")
+	b := v.UnsafePointer() // base address
+	j := 0           // number of assignments
+	for _, n := range field {
+		m, ok := mp["0."+stru+"."+n]
+		if !ok {
+			return nofield(mp, stru, n)
+		}
+		col = m.name.(string)
+		ptr := reflect.NewAt(m.typ, m.addr(b))
+		if m.omit(c, ptr) {
+			continue
+		}
 		if j > 0 {
-			sql.WriteString(",")
+			io.WriteString(w, ",")
 		}
-		m, ok := mapping["0."+stru+"."+n]
-		if !ok {
-			return "", fmt.Errorf("Buildstr: %q has no field %q", stru, n)
+		if err := m.value(w, d, c, m.name.(string)+"=", ptr); err != nil {
+			return err
+		}
+		j++
+	}
+	if j == 0 {
+		return fmt.Errorf("no field to set")
+	}
+	return nil
+}
+
+// Buildcol 与Buildstr类似，但严格按照调用者给定的列名顺序column拼接值串，用
+// 于与手写的语句（如预先准备好的"INSERT INTO t (a,b,c)"）配合，保证两者的列
+// 顺序不会错位。返回值："VALUES (值1,值2,...),..."。
+//
+// 约定：
+//	● data的类型形如[]*struct或*struct。
+//	● column为数据库列名（大小写不敏感），不能为空、不能重复，且必须存在映射。
+func Buildcol(data interface{}, column ...string) (string, error) {
+	v, err := toslice(data)
+	if err != nil {
+		return "", fmt.Errorf("Buildcol: %v", err)
+	}
+	if len(column) == 0 {
+		return "", fmt.Errorf("Buildcol: no column argument")
+	}
+	stru := v.Type().Elem().Elem().Name() // record struct name
+	mapping, err := automap(loadmap(), v.Type().Elem().Elem())
+	if err != nil {
+		return "", fmt.Errorf("Buildcol: %v", err)
+	}
+	if _, ok := mapping[stru]; !ok {
+		return "", fmt.Errorf("Buildcol: %q has no mapping", stru)
+	}
+
+	es := make([]entryT, len(column))
+	for i, c := range column {
+		c = strings.ToLower(strings.TrimSpace(c))
+		for _, cc := range column[:i] {
+			if strings.ToLower(strings.TrimSpace(cc)) == c {
+				return "", fmt.Errorf("Buildcol: duplicate column %q", c)
+			}
 		}
-		ptr := reflect.NewAt(m.typ, unsafe.Pointer(b+m.offset))
-		if err := buildstr(&sql, m.name.(string)+"=", ptr); err != nil {
-			return "", fmt.Errorf("Buildstr: %v", err)
+		m, ok := mapping["1."+stru+"."+c]
+		if !ok {
+			return "", fmt.Errorf("Buildcol: %v", nocolumn(mapping, stru, c))
 		}
+		m.name = c
+		es[i] = m
 	}
 
-	return sql.String(), nil
+	var sql strings.Builder
+	sql.WriteString("VALUES (")
+	if err := valuestr(&sql, nil, conf(), v, es); err != nil {
+		return "", fmt.Errorf("Buildcol: %v", err)
+	}
+	return sql.String() + ")", nil
 }
 
-// buildstr 向b写入一条符合 SQL规范的（赋）值串。s为“列名=”或“”。
+// toslice 将形如[]*struct或*struct的data统一为[]*struct，且其长度不为0。
+func toslice(data interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(data)
+	t := v.Type()
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		return reflect.Append(reflect.MakeSlice(reflect.SliceOf(t), 0, 1), v),
+			nil
+	}
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Ptr ||
+		t.Elem().Elem().Kind() != reflect.Struct {
+		return v, fmt.Errorf("argument 'data' bad type %q", t)
+	}
+	if v.Len() == 0 {
+		return v, fmt.Errorf("data is nil")
+	}
+	return v, nil
+}
+
+// buildstr 向w写入一条符合 SQL规范的（赋）值串。s为“列名=”或“”。
 // v 可以是实现了driver.Valuer接口的类型值，及反射Kind为 Bool、Int、Uint、
-// Float、String的“简单”类型或其指针，其它报错。
-func buildstr(b *strings.Builder, s string, v reflect.Value) error {
-	if f, ok := v.Interface().(driver.Valuer); ok {
-		val, _ := f.Value()
-		fmt.Fprintf(b, "%s%#v", s, val)
-		return nil
+// Float、String的“简单”类型或其指针，其它报错。v 指向指针字段时，nil 为
+// NULL，否则按其所指的值拼接。v 为接口（defer字段的动态值）
+// 时，其值按driver.Value拼接。
+func buildstr(w io.Writer, d Dialect, c *Config, s string,
+	v reflect.Value) error {
+	val, err := valueof(v)
+	if err != nil {
+		return err
 	}
+	io.WriteString(w, s)
+	return literal(w, d, c, val)
+}
+
+// valueof 返回buildstr所述v的driver.Value值。
+func valueof(v reflect.Value) (interface{}, error) {
+	var val interface{}
+	if v.Kind() == reflect.Interface {
+		var err error
+		val, err = driver.DefaultParameterConverter.ConvertValue(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+	} else if f, ok := v.Interface().(driver.Valuer); ok {
+		val, _ = f.Value()
+	} else if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Ptr {
+		if v.Elem().IsNil() { // pointer field, nil is NULL
+			return nil, nil
+		}
+		return valueof(v.Elem())
+	} else {
+		v = reflect.Indirect(v)
+		switch v.Kind() {
+		case reflect.Bool:
+			val = v.Bool()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+			reflect.Int64:
+			val = v.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+			reflect.Uint64:
+			val = v.Uint()
+		case reflect.Float32, reflect.Float64:
+			val = v.Float()
+		case reflect.String:
+			val = v.String()
+		default:
+			return nil, fmt.Errorf("type %q cannot be valued", v.Type())
+		}
+	}
+	return val, nil
+}
 
-	v = reflect.Indirect(v)
-	switch v.Kind() {
-	case reflect.Bool:
-		fmt.Fprintf(b, "%s%t", s, v.Bool())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
-		reflect.Int64:
-		fmt.Fprintf(b, "%s%d", s, v.Int())
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
-		reflect.Uint64:
-		fmt.Fprintf(b, "%s%d", s, v.Uint())
-	case reflect.Float32, reflect.Float64:
-		fmt.Fprintf(b, "%s%g", s, v.Float())
-	case reflect.String:
-		fmt.Fprintf(b, "%s%q", s, v.String())
+// literal 向w写入val的SQL字面值。d 为nil时字符串按Go语法加双引号，无法识别的
+// 类型按Go语法输出；否则按d的规范转义，无法识别的类型报错。
+func literal(w io.Writer, d Dialect, c *Config, val interface{}) error {
+	switch x := val.(type) {
+	case nil:
+		io.WriteString(w, "NULL")
+	case bool:
+		fmt.Fprintf(w, "%t", x)
+	case int64:
+		fmt.Fprintf(w, "%d", x)
+	case uint64:
+		fmt.Fprintf(w, "%d", x)
+	case float64:
+		fmt.Fprintf(w, "%g", x)
+	case string:
+		if d == nil {
+			fmt.Fprintf(w, "%q", x)
+		} else {
+			io.WriteString(w, d.EscapeString(x))
+		}
+	case time.Time:
+		if c.TimeZone != nil {
+			x = x.In(c.TimeZone)
+		} else if c.Deterministic {
+			x = x.UTC()
+		}
+		switch {
+		case d == nil && c.TimeLayout == "":
+			fmt.Fprintf(w, "%q", x.Format(timeLayout))
+		case d == nil:
+			fmt.Fprintf(w, "%q", x.Format(c.TimeLayout))
+		case c.TimeLayout == "":
+			io.WriteString(w, d.FormatTime(x))
+		default:
+			io.WriteString(w, d.EscapeString(x.Format(c.TimeLayout)))
+		}
+	case []byte:
+		if d != nil && d.Name() == "postgres" {
+			fmt.Fprintf(w, `'\x%x'`, x)
+		} else {
+			fmt.Fprintf(w, "X'%x'", x)
+		}
 	default:
-		return fmt.Errorf("type %q cannot be valued", v.Type())
+		if d != nil || c.Deterministic {
+			return fmt.Errorf("value type %T cannot be valued", val)
+		}
+		fmt.Fprintf(w, "%#v", val)
 	}
 	return nil
 }
@@ -0,0 +1,334 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type BuildUser struct {
+	ID   int `db:"col=uid"`
+	Name string
+	Age  int
+}
+
+func TestBuildcol(t *testing.T) {
+	us := []*BuildUser{{1, "a", 20}, {2, "it's", 30}}
+	s, err := Buildcol(us, "name", "UID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `VALUES ("a",1),("it's",2)`; s != want {
+		t.Errorf("Buildcol:\n got %s\nwant %s", s, want)
+	}
+	if s, _ = Buildcol(us[0], "age"); s != "VALUES (20)" {
+		t.Errorf("Buildcol of *struct: %s", s)
+	}
+	for _, cols := range [][]string{nil, {"name", "x"}, {"age", "Age"},
+		{""}} {
+		if _, err = Buildcol(us, cols...); err == nil {
+			t.Errorf("Buildcol(%q): no error", cols)
+		}
+	}
+}
+
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.n--; w.n < 0 {
+		return 0, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func TestBuildstrTo(t *testing.T) {
+	us := []*BuildUser{{1, "a", 20}, {2, "b", 30}}
+	for _, data := range []interface{}{us, us[1]} {
+		want, err := Buildstr(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err = BuildstrTo(&b, data); err != nil || b.String() != want {
+			t.Errorf("BuildstrTo:\n got %s %v\nwant %s", b.String(), err, want)
+		}
+	}
+	err := BuildstrTo(&failWriter{n: 1}, us)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("BuildstrTo with failing writer: %v", err)
+	}
+}
+
+type UniqOrder struct {
+	ID   int `db:"pk"`
+	Cust string
+}
+
+type UniqLine struct {
+	OrderID int
+	SKU     string
+}
+
+func TestScanUnique(t *testing.T) {
+	cols := []string{"id", "cust", "", "orderid", "sku"}
+	rows := testRows(t, cols,
+		[]driver.Value{int64(1), "a", "", int64(1), "x"},
+		[]driver.Value{int64(1), "a", "", int64(1), "y"},
+		[]driver.Value{int64(2), "b", "", int64(2), "x"},
+		[]driver.Value{int64(1), "a", "", int64(1), "x"})
+	defer rows.Close()
+	var o []*UniqOrder
+	var l []*UniqLine
+	if err := ScanUnique(rows, &o, &l); err != nil {
+		t.Fatal(err)
+	}
+	want := []*UniqOrder{{1, "a"}, {2, "b"}}
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("orders: got %v, want %v", o, want)
+	}
+	if len(l) != 4 { // no primary key, not deduplicated
+		t.Errorf("lines: got %d, want 4", len(l))
+	}
+}
+
+type AliasDept struct {
+	Dept      string
+	Headcount int
+}
+
+func TestScanAlias(t *testing.T) {
+	rows := testRows(t, []string{"dept", "N"},
+		[]driver.Value{"a", int64(3)})
+	defer rows.Close()
+	var d []*AliasDept
+	err := ScanAlias(rows, map[string]string{"n": "HeadCount"}, &d)
+	if err != nil || len(d) != 1 || *d[0] != (AliasDept{"a", 3}) {
+		t.Errorf("ScanAlias: %v %v", d, err)
+	}
+
+	// the same columns without the alias
+	rows = testRows(t, []string{"dept", "n"}, []driver.Value{"a", int64(3)})
+	defer rows.Close()
+	if err = Scan(rows, &d); err == nil {
+		t.Error("Scan reused the aliased plan")
+	}
+	// an expression aliased to a mapped column
+	rows = testRows(t, []string{"dept", "headcount"},
+		[]driver.Value{"b", int64(4)})
+	defer rows.Close()
+	if err = Scan(rows, &d); err != nil || *d[0] != (AliasDept{"b", 4}) {
+		t.Errorf("Scan: %v %v", d, err)
+	}
+}
+
+type detValue struct{ p *int }
+
+func (v detValue) Value() (driver.Value, error) { return v.p, nil }
+func (v *detValue) Scan(interface{}) error      { return nil }
+
+type DetRow struct {
+	ID int
+	At time.Time
+	M  map[string]int `db:"json"`
+	V  detValue
+}
+
+func TestDeterministic(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600))
+	d := &DetRow{1, at, map[string]int{"b": 1, "a": 2}, detValue{}}
+	c := GetConfig()
+	c.Deterministic = true
+	want := `SET id=1,at="2024-01-02 02:04:05",m="{\"a\":2,\"b\":1}"`
+	for i := 0; i < 3; i++ {
+		s, err := c.Buildstr(d, "ID", "At", "M")
+		if err != nil || s != want {
+			t.Fatalf("Buildstr:\n got %s %v\nwant %s", s, err, want)
+		}
+	}
+	if _, err := Buildstr(d); err != nil {
+		t.Errorf("Buildstr without Deterministic: %v", err)
+	}
+	if _, err := c.Buildstr(d); err == nil {
+		t.Error("nondeterministic value accepted")
+	}
+}
+
+func TestScanDest(t *testing.T) {
+	var d []*BuildUser
+	var s []BuildUser
+	var n []*int
+	var np *[]*BuildUser
+	for _, c := range []struct {
+		dest []interface{}
+		want string
+	}{
+		{[]interface{}{nil}, "dest[0] is nil, want *[]*struct"},
+		{[]interface{}{&d, d}, "dest[1] is []*sqlaux.BuildUser not a " +
+			"pointer, want *[]*sqlaux.BuildUser (pass its address)"},
+		{[]interface{}{np}, "dest[0] is a nil *[]*sqlaux.BuildUser"},
+		{[]interface{}{&s}, "dest[0] is *[]sqlaux.BuildUser, want " +
+			"*[]*sqlaux.BuildUser (a slice of pointers)"},
+		{[]interface{}{&n}, "dest[0] is *[]*int, its elements are not " +
+			"*struct"},
+		{[]interface{}{new(int)}, "dest[0] is *int, want *[]*struct"},
+		{[]interface{}{&d, &d}, "dest[1] is the same as dest[0]"},
+		{nil, "no dest argument"},
+	} {
+		rows := testRows(t, []string{"uid"}, []driver.Value{int64(1)})
+		err := Scan(rows, c.dest...)
+		rows.Close()
+		if err == nil || err.Error() != "Scan: "+c.want {
+			t.Errorf("got %v, want %q", err, c.want)
+		}
+	}
+}
+
+type ShareOrder struct {
+	ID   int
+	Name string
+}
+
+type ShareSum struct {
+	ID    int64
+	Total float64
+}
+
+type ShareBad struct {
+	ID  string
+	Qty int
+}
+
+func TestScanShared(t *testing.T) {
+	rows := testRows(t, []string{"ID", "name", "", "total"},
+		[]driver.Value{int64(1), "a", "", 2.5},
+		[]driver.Value{int64(2), "b", "", 3.5})
+	defer rows.Close()
+	var o []*ShareOrder
+	var s []*ShareSum
+	if err := ScanShared(rows, []string{"id"}, &o, &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(o) != 2 || *o[1] != (ShareOrder{2, "b"}) || len(s) != 2 ||
+		*s[0] != (ShareSum{1, 2.5}) || *s[1] != (ShareSum{2, 3.5}) {
+		t.Errorf("got %v %v", o, s)
+	}
+
+	rows = testRows(t, []string{"id", "name", "", "qty"},
+		[]driver.Value{int64(1), "a", "", int64(1)})
+	defer rows.Close()
+	var b []*ShareBad
+	err := ScanShared(rows, []string{"id"}, &o, &b)
+	if err == nil || !strings.Contains(err.Error(), "not convertible") {
+		t.Errorf("inconvertible shared column: %v", err)
+	}
+}
+
+func TestScanOne(t *testing.T) {
+	rows := testRows(t, []string{"uid", "name", "", "total"},
+		[]driver.Value{int64(1), "a", "", 2.5},
+		[]driver.Value{int64(2), "b", "", 3.5})
+	defer rows.Close()
+	var u BuildUser
+	var s []*ShareSum
+	if err := Scan(rows, &u, &s); err != nil {
+		t.Fatal(err)
+	}
+	if u != (BuildUser{ID: 1, Name: "a"}) || len(s) != 2 {
+		t.Errorf("got %v %v", u, s)
+	}
+
+	rows = testRows(t, []string{"uid", "name"})
+	defer rows.Close()
+	u2 := u
+	if err := Scan(rows, &u2); !errors.Is(err, sql.ErrNoRows) || u2 != u {
+		t.Errorf("empty result: %v %v", err, u2)
+	}
+}
+
+type NullUser struct {
+	ID    *int
+	Name  *string
+	Seen  *time.Time
+	Score *float64
+}
+
+func TestScanNullPointer(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := testRows(t, []string{"id", "name", "seen", "score"},
+		[]driver.Value{int64(1), []byte("a"), at, 1.5},
+		[]driver.Value{nil, nil, nil, nil})
+	defer rows.Close()
+	var d []*NullUser
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	u := d[0]
+	if u.ID == nil || *u.ID != 1 || u.Name == nil || *u.Name != "a" ||
+		u.Seen == nil || !u.Seen.Equal(at) || u.Score == nil ||
+		*u.Score != 1.5 {
+		t.Errorf("values: %+v", *u)
+	}
+	if *d[1] != (NullUser{}) {
+		t.Errorf("NULLs: %+v", *d[1])
+	}
+	s, err := Buildstr(d)
+	w := `(id,name,seen,score) VALUES (1,"a","2024-01-02 03:04:05",1.5),` +
+		`(NULL,NULL,NULL,NULL)`
+	if err != nil || s != w {
+		t.Errorf("Buildstr:\n got %s %v\nwant %s", s, err, w)
+	}
+
+	// a NULL into a non-pointer field fails
+	bad := testRows(t, []string{"uid"}, []driver.Value{nil})
+	defer bad.Close()
+	var b []*BuildUser
+	if err = Scan(bad, &b); err == nil {
+		t.Error("NULL into an int accepted")
+	}
+}
+
+// cancelAt 为接收时调用cancel的列类型，用于在接收中途取消ctx。
+type cancelAt struct{}
+
+func (c *cancelAt) Scan(v interface{}) error {
+	if v == "stop" {
+		ctxCancel()
+	}
+	return nil
+}
+
+var ctxCancel context.CancelFunc
+
+type CtxRow struct {
+	ID   int
+	Flag cancelAt
+}
+
+func TestScanContext(t *testing.T) {
+	scan := func(c Config) ([]*CtxRow, error) {
+		var ctx context.Context
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		defer ctxCancel()
+		rows := testRows(t, []string{"id", "flag"},
+			[]driver.Value{int64(1), ""}, []driver.Value{int64(2), "stop"},
+			[]driver.Value{int64(3), ""})
+		defer rows.Close()
+		d := []*CtxRow{{ID: 9}}
+		err := c.ScanContext(ctx, rows, &d)
+		return d, err
+	}
+	d, err := scan(GetConfig())
+	if !errors.Is(err, context.Canceled) || len(d) != 1 || d[0].ID != 9 {
+		t.Errorf("cancelled: %v %v", d, err)
+	}
+	// the row being received when cancelled is dropped
+	d, err = scan(With(WithPartial()))
+	if !errors.Is(err, context.Canceled) || len(d) != 1 || d[0].ID != 1 {
+		t.Errorf("partial: %v %v", d, err)
+	}
+}
@@ -0,0 +1,309 @@
+package sqlauxtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/laokz/sqlaux"
+)
+
+// Recorder 包装数据库句柄，将其上每次查询的结果（列名、列类型和各行的值）记
+// 录到目录中的快照文件，供Replay在没有数据库的单元测试中回放。它满足
+// sqlaux.Querier，可以代替句柄传给QueryContext等，如在集成测试中：
+//
+//	r := sqlauxtest.NewRecorder(db, "testdata/orders")
+//	err := sqlaux.QueryContext(ctx, r, query, args, &orders)
+//
+// 每个快照文件对应一条语句及其参数的一次执行，同一语句和参数的多次执行按次
+// 序分别记录。查询的结果先完整读出并写入快照，再以其返回*sql.Rows，因而调用
+// 者不受影响；执行语句（ExecContext）照常执行而不记录。Recorder 可以并发使
+// 用，但并发执行的同一语句和参数的次序不确定。
+type Recorder struct {
+	db  sqlaux.Querier
+	dir string
+	mu  sync.Mutex
+	n   map[string]int // number of executions by key
+	rep *sql.DB        // serves the recorded results
+}
+
+// NewRecorder 返回将db上的查询结果记录到目录dir的Recorder，目录不存在时在第
+// 一次记录时创建。
+func NewRecorder(db sqlaux.Querier, dir string) *Recorder {
+	return &Recorder{db: db, dir: dir, n: make(map[string]int),
+		rep: sql.OpenDB(&replayT{dir: dir, n: make(map[string]int)})}
+}
+
+// ExecContext 在被包装的句柄上执行语句query，不作记录。
+func (r *Recorder) ExecContext(ctx context.Context, query string,
+	args ...interface{}) (sql.Result, error) {
+	return r.db.ExecContext(ctx, query, args...)
+}
+
+// QueryContext 在被包装的句柄上执行查询query，记录其结果后返回之。
+func (r *Recorder) QueryContext(ctx context.Context, query string,
+	args ...interface{}) (*sql.Rows, error) {
+	vals := make([]driver.Value, len(args))
+	for i, a := range args {
+		v, err := driver.DefaultParameterConverter.ConvertValue(a)
+		if err != nil {
+			return nil, fmt.Errorf("Recorder: args[%d] %v", i, err)
+		}
+		vals[i] = v
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	s, err := capture(rows, query, vals)
+	if err != nil {
+		return nil, fmt.Errorf("Recorder: %v", err)
+	}
+
+	k := key(query, vals)
+	r.mu.Lock()
+	r.n[k]++
+	n := r.n[k]
+	r.mu.Unlock()
+	if err = s.save(filepath.Join(r.dir, fmt.Sprintf("%s-%d.json", k,
+		n))); err != nil {
+		return nil, fmt.Errorf("Recorder: %v", err)
+	}
+	return r.rep.QueryContext(context.WithValue(ctx, snapKey{}, s), query,
+		args...)
+}
+
+// Replay 返回回放目录dir中由Recorder记录的查询结果的*sql.DB：查询语句及其参
+// 数与记录时相同时，按执行的次序返回所记录的结果，次数多于记录时重复最后一
+// 次的结果，没有记录时报错；执行语句总是成功且影响0行，事务的提交、回滚不作
+// 任何操作。结果的各列值与记录时驱动返回的相同，列类型名（见
+// sql.ColumnType.DatabaseTypeName）也相同，因而Scan、ScanMap等的行为与访问
+// 真实数据库时一致。
+func Replay(dir string) *sql.DB {
+	return sql.OpenDB(&replayT{dir: dir, n: make(map[string]int)})
+}
+
+// snapT 为一次查询的结果快照，即快照文件的内容。
+type snapT struct {
+	Query   string     `json:"query"`
+	Args    []string   `json:"args,omitempty"` // for reading only
+	Columns []string   `json:"columns"`
+	Types   []string   `json:"types"`
+	Rows    [][]*cellT `json:"rows"`
+}
+
+// cellT 为结果中的一个非NULL值，按驱动返回的类型只有一个成员不为nil。
+type cellT struct {
+	I *int64     `json:"i,omitempty"`
+	F *float64   `json:"f,omitempty"`
+	B *bool      `json:"b,omitempty"`
+	S *string    `json:"s,omitempty"`
+	X *[]byte    `json:"x,omitempty"`
+	T *time.Time `json:"t,omitempty"`
+}
+
+// snapKey 为context中直接回放的快照的键，用于Recorder。
+type snapKey struct{}
+
+// key 返回语句query及其参数（驱动值）vals的快照文件名前缀。
+func key(query string, vals []driver.Value) string {
+	h := sha256.New()
+	io.WriteString(h, query)
+	for _, v := range vals {
+		fmt.Fprintf(h, "\x00%T:%v", v, v)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:8])
+}
+
+// capture 读出rows的全部结果并关闭之，返回语句query、参数vals的快照。
+func capture(rows *sql.Rows, query string, vals []driver.Value) (*snapT,
+	error) {
+	defer rows.Close()
+	s := &snapT{Query: query}
+	for _, v := range vals {
+		s.Args = append(s.Args, fmt.Sprintf("%T:%v", v, v))
+	}
+	var err error
+	if s.Columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	for _, ct := range cts {
+		s.Types = append(s.Types, ct.DatabaseTypeName())
+	}
+	dest := make([]interface{}, len(s.Columns))
+	ptrs := make([]interface{}, len(dest))
+	for i := range dest {
+		ptrs[i] = &dest[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]*cellT, len(dest))
+		for i, v := range dest {
+			if row[i], err = tocell(v); err != nil {
+				return nil, fmt.Errorf("column %q %v", s.Columns[i], err)
+			}
+		}
+		s.Rows = append(s.Rows, row)
+	}
+	return s, rows.Err()
+}
+
+// tocell 返回驱动值v的快照值，NULL 为nil。
+func tocell(v interface{}) (*cellT, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return &cellT{I: &x}, nil
+	case float64:
+		return &cellT{F: &x}, nil
+	case bool:
+		return &cellT{B: &x}, nil
+	case string:
+		return &cellT{S: &x}, nil
+	case []byte:
+		return &cellT{X: &x}, nil
+	case time.Time:
+		return &cellT{T: &x}, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+// value 返回快照值c的驱动值。
+func (c *cellT) value() driver.Value {
+	switch {
+	case c == nil:
+		return nil
+	case c.I != nil:
+		return *c.I
+	case c.F != nil:
+		return *c.F
+	case c.B != nil:
+		return *c.B
+	case c.S != nil:
+		return *c.S
+	case c.X != nil:
+		return *c.X
+	case c.T != nil:
+		return *c.T
+	}
+	return nil
+}
+
+// save 将快照写入文件name。
+func (s *snapT) save(name string) error {
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(b, '\n'), 0644)
+}
+
+// replayT 为回放快照的驱动，同时是其连接。
+type replayT struct {
+	dir string
+	mu  sync.Mutex
+	n   map[string]int // number of executions by key
+}
+
+func (d *replayT) Connect(context.Context) (driver.Conn, error) {
+	return d, nil
+}
+
+func (d *replayT) Driver() driver.Driver { return d }
+
+func (d *replayT) Open(string) (driver.Conn, error) { return d, nil }
+
+func (d *replayT) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("Replay: prepared statements not supported")
+}
+
+func (d *replayT) Close() error { return nil }
+
+func (d *replayT) Begin() (driver.Tx, error) { return d, nil }
+
+func (d *replayT) Commit() error { return nil }
+
+func (d *replayT) Rollback() error { return nil }
+
+func (d *replayT) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (d *replayT) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+	if s, ok := ctx.Value(snapKey{}).(*snapT); ok { // by Recorder
+		return &rowsT{s: s}, nil
+	}
+	vals := make([]driver.Value, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	k := key(query, vals)
+	d.mu.Lock()
+	d.n[k]++
+	n := d.n[k]
+	d.mu.Unlock()
+	for ; n > 0; n-- { // the last one repeats
+		b, err := os.ReadFile(filepath.Join(d.dir, fmt.Sprintf("%s-%d.json",
+			k, n)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Replay: %v", err)
+		}
+		s := new(snapT)
+		if err = json.Unmarshal(b, s); err != nil {
+			return nil, fmt.Errorf("Replay: %s-%d.json %v", k, n, err)
+		}
+		return &rowsT{s: s}, nil
+	}
+	return nil, fmt.Errorf("Replay: no snapshot of %q in %s", query, d.dir)
+}
+
+// rowsT 为回放的结果集。
+type rowsT struct {
+	s *snapT
+	i int
+}
+
+func (r *rowsT) Columns() []string { return r.s.Columns }
+
+func (r *rowsT) Close() error { return nil }
+
+func (r *rowsT) Next(dest []driver.Value) error {
+	if r.i >= len(r.s.Rows) {
+		return io.EOF
+	}
+	for i, c := range r.s.Rows[r.i] {
+		dest[i] = c.value()
+	}
+	r.i++
+	return nil
+}
+
+func (r *rowsT) ColumnTypeDatabaseTypeName(i int) string {
+	if i < len(r.s.Types) {
+		return r.s.Types[i]
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package sqlauxtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/laokz/sqlaux"
+)
+
+type ReplayUser struct {
+	ID   int64
+	Name string
+	At   *time.Time
+}
+
+func TestReplay(t *testing.T) {
+	if _, err := sqlaux.Signature(ReplayUser{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	const q = "SELECT id,name,at FROM users WHERE id>$1"
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	ids, names := []int64{1, 2}, []string{"a", "b"}
+	src := t.TempDir()
+	k := key(q, []driver.Value{int64(0)})
+	for i, row := range [][]*cellT{
+		{{I: &ids[0]}, {S: &names[0]}, {T: &at}},
+		{{I: &ids[1]}, {S: &names[1]}, nil},
+	} {
+		s := &snapT{Query: q, Columns: []string{"id", "name", "at"},
+			Types: []string{"BIGINT", "TEXT", "TIMESTAMPTZ"},
+			Rows:  [][]*cellT{row}}
+		name := filepath.Join(src, k+"-"+string(rune('1'+i))+".json")
+		if err := s.save(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// record from a replayed source, then replay the recording
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "rec")
+	r := NewRecorder(Replay(src), dir)
+	want := []ReplayUser{{1, "a", &at}, {2, "b", nil}, {2, "b", nil}}
+	for i, w := range want {
+		var us []*ReplayUser
+		err := sqlaux.QueryContext(ctx, r, q, []interface{}{0}, &us)
+		if err != nil || len(us) != 1 || !reflect.DeepEqual(*us[0], w) {
+			t.Errorf("Recorder %d: %v %v", i, us, err)
+		}
+	}
+	fs, err := filepath.Glob(filepath.Join(dir, k+"-*.json"))
+	if err != nil || len(fs) != 3 {
+		t.Errorf("snapshots: %q %v", fs, err)
+	}
+	db := Replay(dir)
+	for i, w := range append(want, want[2]) {
+		rows, err := db.QueryContext(ctx, q, 0)
+		if err != nil {
+			t.Fatalf("Replay %d: %v", i, err)
+		}
+		ms, err := sqlaux.ScanMap(rows)
+		rows.Close()
+		m := map[string]interface{}{"id": w.ID, "name": w.Name, "at": nil}
+		if w.At != nil {
+			m["at"] = *w.At
+		}
+		if err != nil || len(ms) != 1 || !reflect.DeepEqual(ms[0], m) {
+			t.Errorf("Replay %d:\n got %v %v\nwant %v", i, ms, err, m)
+		}
+	}
+
+	if res, err := db.Exec("DELETE FROM users"); err != nil {
+		t.Errorf("Exec: %v", err)
+	} else if n, _ := res.RowsAffected(); n != 0 {
+		t.Errorf("Exec affected %d rows", n)
+	}
+	_, err = db.QueryContext(ctx, q, 1)
+	if err == nil || !strings.Contains(err.Error(), "no snapshot") {
+		t.Errorf("other args: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, k+"-1.json"), []byte("{"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Replay(dir).QueryContext(ctx, q, 0); err == nil {
+		t.Error("corrupt snapshot accepted")
+	}
+}
@@ -0,0 +1,346 @@
+// Package sqlauxtest 按sqlaux的映射生成随机测试数据，用于基于性质的测试，以
+// 及配合Buildstr、BuildInsert等插入函数生成负载，如：
+//
+//	var users []*User
+//	if err := sqlauxtest.FakeSlice(&users, 1000); err != nil {
+//		...
+//	}
+//	query, args, err := sqlaux.BuildInsert(sqlaux.PostgreSQL, users)
+//
+// 生成的值尽量合理：字符串长度不超过tag中的"size="，满足tag中"check="的简单
+// 约束（数值的上下界、IN列表即枚举值），整数主键（tag中有"pk"）在进程内递增
+// 因而不重复，时间为最近一年内整秒的UTC时间。
+//
+// 包中还有查询结果的记录与回放（见Recorder、Replay），使集成测试的场景可以在
+// 没有数据库的单元测试中重现。
+package sqlauxtest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/laokz/sqlaux"
+)
+
+// Rand 为生成数据所用的随机数源，缺省以当前时间为种子。需要可重现的数据时，
+// 在生成之前替换为固定种子的源，如rand.New(rand.NewSource(1))。
+var Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// randMu 保护Rand，rand.Rand不能并发使用。
+var randMu sync.Mutex
+
+// seq 为整数主键的进程内序列。
+var seq int64
+
+// Fake 以随机值填充dest（形如*struct）的全部可写映射字段，计算列（tag中有
+// "expr="）保持不变。结构须已映射。
+func Fake(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Fake: argument 'dest' bad type %T", dest)
+	}
+	fs, err := sqlaux.FieldNames(dest)
+	if err != nil {
+		return fmt.Errorf("Fake: %v", err)
+	}
+	if err = fake(v.Elem(), fs); err != nil {
+		return fmt.Errorf("Fake: %v", err)
+	}
+	return nil
+}
+
+// FakeSlice 生成n个以随机值填充的结构追加到dest（形如*[]*struct）中，见Fake。
+func FakeSlice(dest interface{}, n int) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		v.Elem().Kind() != reflect.Slice ||
+		v.Elem().Type().Elem().Kind() != reflect.Ptr ||
+		v.Elem().Type().Elem().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("FakeSlice: argument 'dest' bad type %T", dest)
+	}
+	t := v.Elem().Type().Elem().Elem()
+	fs, err := sqlaux.FieldNames(reflect.Zero(t).Interface())
+	if err != nil {
+		return fmt.Errorf("FakeSlice: %v", err)
+	}
+	s := v.Elem()
+	for i := 0; i < n; i++ {
+		p := reflect.New(t)
+		if err = fake(p.Elem(), fs); err != nil {
+			return fmt.Errorf("FakeSlice: %v", err)
+		}
+		s = reflect.Append(s, p)
+	}
+	v.Elem().Set(s)
+	return nil
+}
+
+// fake 以随机值填充结构v中名为fs（嵌套结构成员为全名）的映射字段。
+func fake(v reflect.Value, fs []string) error {
+	c := sqlaux.GetConfig()
+	randMu.Lock()
+	defer randMu.Unlock()
+	for _, n := range fs {
+		f, sf := v, reflect.StructField{}
+		for _, p := range strings.Split(n, ".") {
+			if f.Kind() == reflect.Ptr { // embedded *struct
+				if f.IsNil() {
+					f.Set(reflect.New(f.Type().Elem()))
+				}
+				f = f.Elem()
+			}
+			sf, _ = f.Type().FieldByName(p)
+			f = f.FieldByName(p)
+		}
+		kv, err := sqlaux.ParseTag(sf.Tag.Get(c.Tag))
+		if err != nil {
+			return fmt.Errorf("%s.%s %v", v.Type().Name(), n, err)
+		}
+		if _, ok := kv["expr"]; ok { // computed
+			continue
+		}
+		col := strings.ToLower(sf.Name)
+		if c.NameMapper != nil {
+			col = c.NameMapper(sf.Name)
+		}
+		if s, ok := kv[c.Key]; ok {
+			col = s
+		}
+		if err = value(f, kv, col); err != nil {
+			return fmt.Errorf("%s.%s %v", v.Type().Name(), n, err)
+		}
+	}
+	return nil
+}
+
+// 常见类型。
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	ipType       = reflect.TypeOf(net.IP(nil))
+	ipnetType    = reflect.TypeOf(net.IPNet{})
+	macType      = reflect.TypeOf(net.HardwareAddr(nil))
+)
+
+// value 按字段tag选项kv以随机值填充字段f，col为其列名。调用者持有randMu。
+func value(f reflect.Value, kv map[string]string, col string) error {
+	t := f.Type()
+	if t.Kind() == reflect.Ptr { // never NULL
+		p := reflect.New(t.Elem())
+		if err := value(p.Elem(), kv, col); err != nil {
+			return err
+		}
+		f.Set(p)
+		return nil
+	}
+	if t.PkgPath() == "database/sql" && strings.HasPrefix(t.Name(), "Null") &&
+		t.Kind() == reflect.Struct { // sql.NullString etc.
+		f.FieldByName("Valid").SetBool(true)
+		return value(f.Field(0), kv, col)
+	}
+	if _, ok := kv["json"]; ok { // left to the caller
+		return nil
+	}
+	if _, ok := kv["geo"]; ok {
+		f.SetString(fmt.Sprintf("POINT(%.6f %.6f)", Rand.Float64()*360-180,
+			Rand.Float64()*180-90))
+		return nil
+	}
+
+	cons := constraint(kv["check"], col)
+	switch t {
+	case timeType:
+		d := time.Duration(Rand.Int63n(int64(365 * 24 * time.Hour)))
+		f.Set(reflect.ValueOf(time.Now().UTC().Add(-d).Truncate(
+			time.Second)))
+		return nil
+	case durationType:
+		f.SetInt(int64(time.Duration(Rand.Int63n(3600)) * time.Second))
+		return nil
+	case ipType:
+		f.Set(reflect.ValueOf(net.IPv4(byte(Rand.Intn(223)+1),
+			byte(Rand.Intn(256)), byte(Rand.Intn(256)),
+			byte(Rand.Intn(254)+1))))
+		return nil
+	case ipnetType:
+		ip := net.IPv4(byte(Rand.Intn(223)+1), byte(Rand.Intn(256)), 0, 0)
+		f.Set(reflect.ValueOf(net.IPNet{IP: ip.To4(),
+			Mask: net.CIDRMask(16, 32)}))
+		return nil
+	case macType:
+		mac := make(net.HardwareAddr, 6)
+		Rand.Read(mac)
+		mac[0] = mac[0]&^1 | 2 // unicast, locally administered
+		f.Set(reflect.ValueOf(mac))
+		return nil
+	}
+	if len(cons.enum) > 0 {
+		return enum(f, cons.enum[Rand.Intn(len(cons.enum))])
+	}
+
+	_, pk := kv["pk"]
+	switch t.Kind() {
+	case reflect.Bool:
+		f.SetBool(Rand.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64:
+		var n int64
+		if pk {
+			n = atomic.AddInt64(&seq, 1)
+		} else {
+			lo, hi := cons.bounds(0, 1000)
+			if lo, hi = math.Ceil(lo), math.Floor(hi); lo > hi {
+				return fmt.Errorf("cannot fake integer in %q", kv["check"])
+			}
+			n = int64(lo) + Rand.Int63n(int64(hi-lo)+1)
+		}
+		if t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64 {
+			if n < 0 || f.OverflowUint(uint64(n)) {
+				return fmt.Errorf("cannot fake %d into %v", n, t)
+			}
+			f.SetUint(uint64(n))
+		} else {
+			if f.OverflowInt(n) {
+				return fmt.Errorf("cannot fake %d into %v", n, t)
+			}
+			f.SetInt(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		lo, hi := cons.bounds(0, 1000)
+		if lo > hi {
+			return fmt.Errorf("cannot fake number in %q", kv["check"])
+		}
+		x := lo + Rand.Float64()*(hi-lo)
+		if r := math.Round(x*100) / 100; r >= lo && r <= hi {
+			x = r // plausible with 2 decimals
+		}
+		f.SetFloat(x)
+	case reflect.String:
+		size := 12
+		if s, err := strconv.Atoi(kv["size"]); err == nil && s < size {
+			size = s
+		}
+		b := make([]byte, 1+Rand.Intn(size))
+		for i := range b {
+			b[i] = byte('a' + Rand.Intn(26))
+		}
+		f.SetString(string(b))
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return nil // child table or the like
+		}
+		b := make([]byte, 1+Rand.Intn(16))
+		Rand.Read(b)
+		f.SetBytes(b)
+	}
+	return nil
+}
+
+// enum 将IN列表中的值s存入字段f。
+func enum(f reflect.Value, s string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err == nil && !f.OverflowInt(n) {
+			f.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err == nil && !f.OverflowUint(n) {
+			f.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, 64)
+		if err == nil {
+			f.SetFloat(x)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot fake %q into %v", s, f.Type())
+}
+
+// consT 为从"check="约束中提取的简单条件。
+type consT struct {
+	enum   []string // IN列表的值
+	lo, hi *float64 // 数值的下界、上界
+}
+
+var (
+	inRe    = regexp.MustCompile(`(?i)^\s*(\w+)\s+in\s*\((.*)\)\s*$`)
+	boundRe = regexp.MustCompile(`^\s*(\w+)\s*(>=|<=|>|<)\s*(-?[0-9.]+)\s*$`)
+	andRe   = regexp.MustCompile(`(?i)\s+and\s+`)
+)
+
+// constraint 从列col的约束expr中提取以AND连接的简单条件：col IN (值,...)、
+// col与数值的比较。其他条件忽略，生成的值可能不满足之。
+func constraint(expr, col string) consT {
+	var c consT
+	if expr == "" {
+		return c
+	}
+	for _, p := range andRe.Split(expr, -1) {
+		if m := inRe.FindStringSubmatch(p); m != nil &&
+			strings.ToLower(m[1]) == col {
+			for _, s := range strings.Split(m[2], ",") {
+				s = strings.TrimSpace(s)
+				if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+					s = strings.Replace(s[1:len(s)-1], "''", "'", -1)
+				}
+				c.enum = append(c.enum, s)
+			}
+			continue
+		}
+		m := boundRe.FindStringSubmatch(p)
+		if m == nil || strings.ToLower(m[1]) != col {
+			continue
+		}
+		x, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			continue
+		}
+		switch m[2] {
+		case ">":
+			x = math.Nextafter(x, math.Inf(1))
+			fallthrough
+		case ">=":
+			c.lo = &x
+		case "<":
+			x = math.Nextafter(x, math.Inf(-1))
+			fallthrough
+		case "<=":
+			c.hi = &x
+		}
+	}
+	return c
+}
+
+// bounds 返回数值的下界、上界，没有约束的一侧取lo、hi（但不越过另一侧）。
+func (c consT) bounds(lo, hi float64) (float64, float64) {
+	switch {
+	case c.lo != nil && c.hi != nil:
+		return *c.lo, *c.hi
+	case c.lo != nil:
+		return *c.lo, *c.lo + hi - lo
+	case c.hi != nil:
+		return *c.hi - (hi - lo), *c.hi
+	}
+	return lo, hi
+}
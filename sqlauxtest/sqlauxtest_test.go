@@ -0,0 +1,85 @@
+package sqlauxtest
+
+import (
+	"database/sql"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+type FakeUser struct {
+	ID     int64  `db:"pk"`
+	Name   string `db:"size=4"`
+	Role   string `db:"check='role IN (''admin'',''guest'')'"`
+	Level  int8   `db:"check='level >= 1 AND level <= 3'"`
+	Score  float64
+	Born   time.Time
+	Note   sql.NullString
+	Parent *int
+	Full   string `db:"expr='name'"`
+}
+
+type FakeBadCheck struct {
+	N uint8 `db:"check='n > 300'"`
+}
+
+func TestFake(t *testing.T) {
+	old := Rand
+	defer func() { Rand = old }()
+	Rand = rand.New(rand.NewSource(1))
+
+	var d []*FakeUser
+	if err := FakeSlice(&d, 50); err != nil || len(d) != 50 {
+		t.Fatalf("FakeSlice: %d %v", len(d), err)
+	}
+	year := time.Now().UTC().AddDate(-1, 0, -1)
+	for i, u := range d {
+		if i > 0 && u.ID <= d[i-1].ID {
+			t.Errorf("row %d: pk %d not after %d", i, u.ID, d[i-1].ID)
+		}
+		if n := utf8.RuneCountInString(u.Name); n < 1 || n > 4 {
+			t.Errorf("row %d: name %q exceeds size", i, u.Name)
+		}
+		if u.Role != "admin" && u.Role != "guest" {
+			t.Errorf("row %d: role %q not in enum", i, u.Role)
+		}
+		if u.Level < 1 || u.Level > 3 {
+			t.Errorf("row %d: level %d out of check", i, u.Level)
+		}
+		if u.Score < 0 || u.Score > 1000 {
+			t.Errorf("row %d: score %v", i, u.Score)
+		}
+		if u.Born.Before(year) || u.Born.Location() != time.UTC ||
+			u.Born.Nanosecond() != 0 {
+			t.Errorf("row %d: born %v", i, u.Born)
+		}
+		if !u.Note.Valid || u.Note.String == "" || u.Parent == nil {
+			t.Errorf("row %d: nullable left NULL %v %v", i, u.Note, u.Parent)
+		}
+		if u.Full != "" {
+			t.Errorf("row %d: computed column faked %q", i, u.Full)
+		}
+	}
+
+	u := FakeUser{Full: "keep"}
+	if err := Fake(&u); err != nil || u.ID <= d[49].ID || u.Full != "keep" {
+		t.Errorf("Fake: %+v %v", u, err)
+	}
+	if err := Fake(u); err == nil || !strings.Contains(err.Error(),
+		"bad type") {
+		t.Errorf("Fake non-pointer: %v", err)
+	}
+	if err := Fake((*FakeUser)(nil)); err == nil {
+		t.Error("Fake nil pointer accepted")
+	}
+	var bad []FakeUser
+	if err := FakeSlice(&bad, 1); err == nil {
+		t.Error("FakeSlice into []struct accepted")
+	}
+	err := Fake(&FakeBadCheck{})
+	if err == nil || !strings.Contains(err.Error(), "FakeBadCheck.N") {
+		t.Errorf("unsatisfiable check: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package sqlaux
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BuildInsert 生成向data的结构所登记的表（见Tablename）插入data的完整语句：
+// "INSERT INTO 表 (列名1,...) VALUES ($1,...),..."，值作为参数返回，如：
+//
+//	query, args, err := sqlaux.BuildInsert(sqlaux.PostgreSQL, users)
+//	_, err = db.ExecContext(ctx, query, args...)
+//
+// 占位符和参数的处理与Buildargs相同，d 为nil时使用?占位符。
+//
+// 约定：data的类型形如[]*struct或*struct，结构须已登记表名，field同Buildstr。
+func BuildInsert(d Dialect, data interface{}, field ...string) (string,
+	[]interface{}, error) {
+	v, err := toslice(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("BuildInsert: %v", err)
+	}
+	_, table, err := stmttable(v.Type().Elem().Elem())
+	if err != nil {
+		return "", nil, fmt.Errorf("BuildInsert: %v", err)
+	}
+	f, err := Buildargs(d, v.Interface(), field...) // always VALUES form
+	if err != nil {
+		return "", nil, fmt.Errorf("BuildInsert: %v", err)
+	}
+	return "INSERT INTO " + table + " " + f.SQL, f.Args, nil
+}
+
+// BuildUpdate 生成以data的字段值更新其结构所登记的表的完整语句：
+// "UPDATE 表 SET 列名1=$1,... WHERE 条件"，值作为参数返回。where 为条件片段，
+// 其$n占位符从$1开始，自动接续SET中的编号；where.SQL为""时按data的主键定位
+// 该行，结构没有主键时报错，从而不会无意中更新全表。field 缺省时为除主键外的
+// 所有可写字段。占位符和参数的处理与Buildargs相同，d 为nil时使用?占位符。
+//
+// 约定：data的类型形如*struct，结构须已登记表名，field同Buildstr。
+func BuildUpdate(d Dialect, data interface{}, where Fragment,
+	field ...string) (string, []interface{}, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("BuildUpdate: argument 'data' bad type %T",
+			data)
+	}
+	t := v.Elem().Type()
+	mapping, table, err := stmttable(t)
+	if err != nil {
+		return "", nil, fmt.Errorf("BuildUpdate: %v", err)
+	}
+	if where.SQL == "" {
+		if where, err = pkfragment(d, mapping, v); err != nil {
+			return "", nil, fmt.Errorf("BuildUpdate: %v", err)
+		}
+	}
+	if len(field) == 0 {
+		field = nonpkeys(mapping, t.Name())
+		if len(field) == 0 { // Buildargs would default to all fields
+			return "", nil, fmt.Errorf("BuildUpdate: no field to set")
+		}
+	}
+	set, err := Buildargs(d, data, field...)
+	if err != nil {
+		return "", nil, fmt.Errorf("BuildUpdate: %v", err)
+	}
+	f := Join(" WHERE ", set, where)
+	return "UPDATE " + table + " " + f.SQL, f.Args, nil
+}
+
+// BuildDelete 生成从data的结构所登记的表中删除行的完整语句：
+// "DELETE FROM 表 WHERE 条件"，参数为where的参数。where.SQL为""时按data的主
+// 键定位该行，这时data须为*struct，结构没有主键时报错，从而不会无意中删除全
+// 表。d 为nil时使用?占位符。
+//
+// 约定：data以变量值的形式作参数，结构须已登记表名。
+func BuildDelete(d Dialect, data interface{}, where Fragment) (string,
+	[]interface{}, error) {
+	v := reflect.ValueOf(data)
+	if data == nil || structof(v.Type()).Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("BuildDelete: argument 'data' bad type %T",
+			data)
+	}
+	t := structof(v.Type())
+	mapping, table, err := stmttable(t)
+	if err != nil {
+		return "", nil, fmt.Errorf("BuildDelete: %v", err)
+	}
+	if where.SQL == "" {
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return "", nil, fmt.Errorf("BuildDelete: no where and data not " +
+				"*struct")
+		}
+		if where, err = pkfragment(d, mapping, v); err != nil {
+			return "", nil, fmt.Errorf("BuildDelete: %v", err)
+		}
+	}
+	return "DELETE FROM " + table + " WHERE " + where.SQL, where.Args, nil
+}
+
+// BuildSelect 生成从data的结构所登记的表中查询行的完整语句：
+// "SELECT 列名1,... FROM 表 WHERE 条件"，列为所有映射字段（同Selectstr），
+// 参数为where的参数。where.SQL为""时按data的主键定位该行（同BuildDelete）；
+// 也可以带ORDER BY、LIMIT等后续子句。lock 不为""时附加在末尾，通常为
+// ForUpdate、ForShare的返回值，如工作队列取任务：
+//
+//	lock := sqlaux.ForUpdate(sqlaux.PostgreSQL, sqlaux.LockSkipLocked)
+//	query, args, err := sqlaux.BuildSelect(sqlaux.PostgreSQL, Job{},
+//		sqlaux.Fragment{SQL: "state='new' ORDER BY id LIMIT 10"}, lock)
+//
+// d 为nil时使用?占位符。
+//
+// 约定：data以变量值的形式作参数，结构须已登记表名。
+func BuildSelect(d Dialect, data interface{}, where Fragment,
+	lock string) (string, []interface{}, error) {
+	v := reflect.ValueOf(data)
+	if data == nil || structof(v.Type()).Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("BuildSelect: argument 'data' bad type %T",
+			data)
+	}
+	t := structof(v.Type())
+	mapping, table, err := stmttable(t)
+	if err != nil {
+		return "", nil, fmt.Errorf("BuildSelect: %v", err)
+	}
+	if where.SQL == "" {
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return "", nil, fmt.Errorf("BuildSelect: no where and data not " +
+				"*struct")
+		}
+		if where, err = pkfragment(d, mapping, v); err != nil {
+			return "", nil, fmt.Errorf("BuildSelect: %v", err)
+		}
+	}
+	cols, err := selectstr("", data, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("BuildSelect: %v", err)
+	}
+	return "SELECT " + cols + " FROM " + table + " WHERE " + where.SQL + lock,
+		where.Args, nil
+}
+
+// stmttable 返回结构t的映射及其所登记的表名，t没有映射或表名时报错。
+func stmttable(t reflect.Type) (map[string]entryT, string, error) {
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, ok := mapping[t.Name()]; !ok {
+		return nil, "", fmt.Errorf("%q has no mapping", t)
+	}
+	table := tablename(mapping, t.Name())
+	if table == "" {
+		return nil, "", fmt.Errorf("%q has no registered table", t)
+	}
+	return mapping, table, nil
+}
+
+// pkfragment 返回按主键定位v（*struct）的条件片段："主键列1=$1 AND ..."。
+// 结构没有主键或主键值为NULL（nil指针）时报错：条件将更新、删除全表或永远
+// 不成立。
+func pkfragment(d Dialect, mp map[string]entryT, v reflect.Value) (f Fragment,
+	err error) {
+	stru, col := v.Elem().Type().Name(), ""
+	defer recovered(&err, "", &stru, &col)
+	pks := pkeys(mp, stru)
+	if len(pks) == 0 {
+		return f, fmt.Errorf("no where and %q has no primary key", stru)
+	}
+	var sql strings.Builder
+	for i, e := range pks {
+		if i > 0 {
+			sql.WriteString(" AND ")
+		}
+		col = e.name.(string)
+		ptr := reflect.NewAt(e.typ, e.addr(v.UnsafePointer()))
+		s, arg, err := e.placeholder(d, conf(), len(f.Args)+1, ptr)
+		if err != nil {
+			return f, err
+		}
+		if arg == nil {
+			return f, fmt.Errorf("primary key %q is NULL", col)
+		}
+		sql.WriteString(col + "=" + s)
+		f.Args = append(f.Args, arg)
+	}
+	f.SQL = sql.String()
+	return f, nil
+}
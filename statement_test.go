@@ -0,0 +1,96 @@
+package sqlaux
+
+import (
+	"reflect"
+	"testing"
+)
+
+type StmtTagged struct {
+	_    struct{} `db:"table=app.users"`
+	ID   int      `db:"pk"`
+	Name string
+}
+
+func TestBuildInsertTableTag(t *testing.T) {
+	// no MapStruct: the first call automaps and finds the tagged table
+	for i := 0; i < 2; i++ {
+		q, args, err := BuildInsert(PostgreSQL, &StmtTagged{ID: 1, Name: "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q != "INSERT INTO app.users (id,name) VALUES ($1,$2)" ||
+			!reflect.DeepEqual(args, []interface{}{int64(1), "a"}) {
+			t.Errorf("got %q %v", q, args)
+		}
+	}
+	if tb := Tablename(StmtTagged{}); tb != "app.users" {
+		t.Errorf("Tablename %q", tb)
+	}
+}
+
+type StmtUntagged struct{ ID int }
+
+func TestBuildInsertNoTable(t *testing.T) {
+	_, _, err := BuildInsert(nil, &StmtUntagged{})
+	if err == nil || err.Error() !=
+		`BuildInsert: "sqlaux.StmtUntagged" has no registered table` {
+		t.Errorf("got %v", err)
+	}
+	if tb := Tablename(&StmtUntagged{}); tb != "" {
+		t.Errorf("Tablename %q", tb)
+	}
+}
+
+type StmtUser struct {
+	_    struct{} `db:"table=stmt_users"`
+	ID   int      `db:"pk"`
+	Name string
+	Age  int
+}
+
+type StmtKey struct {
+	_  struct{} `db:"table=stmt_keys"`
+	ID int      `db:"pk"`
+}
+
+func TestBuildUpdateDelete(t *testing.T) {
+	u := &StmtUser{ID: 7, Name: "x", Age: 3}
+	check := func(name, q string, args []interface{}, err error,
+		wq string, wargs ...interface{}) {
+		t.Helper()
+		if err != nil || q != wq || !reflect.DeepEqual(args, wargs) {
+			t.Errorf("%s:\n got %s %v %v\nwant %s %v", name, q, args, err,
+				wq, wargs)
+		}
+	}
+	q, args, err := BuildUpdate(PostgreSQL, u, Fragment{})
+	check("BuildUpdate", q, args, err,
+		"UPDATE stmt_users SET name=$1,age=$2 WHERE id=$3", "x", int64(3),
+		int64(7))
+	q, args, err = BuildUpdate(PostgreSQL, u, Fragment{"age<$1 AND id>$2",
+		[]interface{}{5, 1}}, "Name")
+	check("where", q, args, err,
+		"UPDATE stmt_users SET name=$1 WHERE age<$2 AND id>$3", "x", 5, 1)
+	q, args, err = BuildDelete(nil, u, Fragment{})
+	check("BuildDelete", q, args, err, "DELETE FROM stmt_users WHERE id=?",
+		int64(7))
+	q, args, err = BuildDelete(PostgreSQL, (*StmtUser)(nil),
+		Fragment{"age>$1", []interface{}{9}})
+	check("nil pointer", q, args, err,
+		"DELETE FROM stmt_users WHERE age>$1", 9)
+
+	if _, _, err = BuildUpdate(nil, &StmtKey{ID: 1}, Fragment{}); err == nil {
+		t.Error("BuildUpdate without field to set accepted")
+	}
+	if _, _, err = BuildUpdate(nil, StmtUser{}, Fragment{}); err == nil {
+		t.Error("BuildUpdate of struct value accepted")
+	}
+	for _, data := range []interface{}{nil, 1, StmtUser{}, &StmtUntagged{}} {
+		if _, _, err = BuildDelete(nil, data, Fragment{}); err == nil {
+			t.Errorf("BuildDelete(%#v) accepted", data)
+		}
+		if _, _, err = BuildSelect(nil, data, Fragment{}, ""); err == nil {
+			t.Errorf("BuildSelect(%#v) accepted", data)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package sqlaux
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// planT 为Scan的接收计划。ref 为各列对应的字段参考信息（见scanField）；defs
+// 为结果集中没有对应列、但有scan_default的字段，其name为所在dest的索引。
+type planT struct {
+	ref  []entryT
+	defs []entryT
+	// copies 为共享列（见ScanShared）的复制项：[0]为接收该列的字段，[1]为其
+	// 他dest中映射同一列的字段，name均为所在dest的索引
+	copies [][2]entryT
+}
+
+// planCache 缓存Scan的接收计划，键为"结构名,...|列名,..."。
+var planCache = struct {
+	sync.Mutex
+	m            map[string]*planT
+	hits, misses uint64
+}{m: make(map[string]*planT)}
+
+// scanPlan 从缓存中取得rows、ts对应的接收计划，未命中时计算并缓存之。返回的
+// 计划为只读。o 为Scan的选项，其中的映射、别名决定计划。
+func scanPlan(rows *sql.Rows, ts []reflect.Type, o *scanOpts) (*planT, error) {
+	col, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if o.alias != nil {
+		col = append([]string(nil), col...) // never change driver's
+	}
+	for i, c := range col {
+		if a, ok := o.alias[strings.ToLower(c)]; ok {
+			col[i] = a
+		}
+	}
+	var k strings.Builder
+	k.WriteString(o.id)
+	for _, t := range ts {
+		k.WriteString(t.Name())
+		k.WriteString(",")
+	}
+	k.WriteString("|")
+	k.WriteString(strings.Join(col, ","))
+	if len(o.shared) > 0 {
+		sh := make([]string, 0, len(o.shared))
+		for c := range o.shared {
+			sh = append(sh, c)
+		}
+		sort.Strings(sh)
+		k.WriteString("|" + strings.Join(sh, ","))
+	}
+	lenient := o.cfg != nil && o.cfg.Lenient
+	if lenient {
+		k.WriteString("|lenient")
+	}
+	key := k.String()
+
+	planCache.Lock()
+	plan, ok := planCache.m[key]
+	if ok {
+		planCache.hits++
+	} else {
+		planCache.misses++
+	}
+	planCache.Unlock()
+	if ok {
+		return plan, nil
+	}
+
+	plan = new(planT)
+	if plan.ref, err = scanField(o.mp, col, ts, lenient); err != nil {
+		return nil, err
+	}
+	plan.defs = scanDefault(o.mp, plan.ref, ts)
+	if len(o.shared) > 0 {
+		if plan.copies, err = scanShared(o.mp, col, plan.ref, ts,
+			o.shared); err != nil {
+			return nil, err
+		}
+	}
+	size := conf().PlanCacheSize
+	planCache.Lock()
+	if len(planCache.m) >= size {
+		planCache.m = make(map[string]*planT)
+	}
+	if size > 0 {
+		planCache.m[key] = plan
+	}
+	planCache.Unlock()
+	return plan, nil
+}
+
+// scanDefault 返回ts各结构中有scan_default、但不在ref中的字段，mp为映射。
+func scanDefault(mp map[string]entryT, ref []entryT,
+	ts []reflect.Type) []entryT {
+	var defs []entryT
+	for j, t := range ts {
+	next:
+		for _, f := range mp[t.Name()].name.([]string) {
+			e := mp["0."+t.Name()+"."+f]
+			if e.info == nil || !e.info.def.IsValid() {
+				continue
+			}
+			for _, r := range ref {
+				if r.name == j && r.same(e) {
+					continue next
+				}
+			}
+			e.name = j
+			defs = append(defs, e)
+		}
+	}
+	return defs
+}
+
+// scanShared 返回结果列col中共享列shared的复制项：ref中接收该列的字段，到ts
+// 其他结构中映射同一列的各字段，mp为映射。
+func scanShared(mp map[string]entryT, col []string, ref []entryT,
+	ts []reflect.Type, shared map[string]bool) ([][2]entryT, error) {
+	var copies [][2]entryT
+	for i, c := range col {
+		if !shared[c] || ref[i].name == nil {
+			continue
+		}
+		for j, t := range ts {
+			e, ok := mp["1."+t.Name()+"."+c]
+			if !ok || j == ref[i].name.(int) {
+				continue
+			}
+			// an integer converts to string as a rune, not its digits
+			from := ref[i].typ
+			if !from.ConvertibleTo(e.typ) || e.typ.Kind() == reflect.String &&
+				from.Kind() != reflect.String && from.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("shared column %q: %v not "+
+					"convertible to %v", c, from, e.typ)
+			}
+			e.name = j
+			copies = append(copies, [2]entryT{ref[i], e})
+		}
+	}
+	return copies, nil
+}
+
+// Statistics 为sqlaux的运行统计信息，由Stats()返回。
+type Statistics struct {
+	PlanHits   uint64         // Scan接收计划缓存命中次数
+	PlanMisses uint64         // Scan接收计划缓存未命中次数
+	PlanCached int            // 当前缓存的接收计划数
+	StmtHits   uint64         // 全部StmtCache的预备语句缓存命中次数
+	StmtMisses uint64         // 全部StmtCache的预备语句缓存未命中次数
+	Structs    int            // 已映射的结构数
+	Columns    map[string]int // 各映射结构的映射列数，键为结构名
+}
+
+// PlanHitRate 返回Scan接收计划缓存的命中率，尚无Scan时返回0。
+func (s Statistics) PlanHitRate() float64 {
+	if s.PlanHits+s.PlanMisses == 0 {
+		return 0
+	}
+	return float64(s.PlanHits) / float64(s.PlanHits+s.PlanMisses)
+}
+
+// StmtHitRate 返回全部StmtCache的预备语句缓存命中率，尚无执行时返回0。
+func (s Statistics) StmtHitRate() float64 {
+	if s.StmtHits+s.StmtMisses == 0 {
+		return 0
+	}
+	return float64(s.StmtHits) / float64(s.StmtHits+s.StmtMisses)
+}
+
+// Stats 返回sqlaux当前的运行统计信息，用于调整缓存大小、发现动态SQL造成的
+// 缓存未命中等。
+func Stats() Statistics {
+	mapping := loadmap()
+	planCache.Lock()
+	s := Statistics{
+		PlanHits:   planCache.hits,
+		PlanMisses: planCache.misses,
+		PlanCached: len(planCache.m),
+		StmtHits:   atomic.LoadUint64(&stmtHits),
+		StmtMisses: atomic.LoadUint64(&stmtMisses),
+		Columns:    make(map[string]int),
+	}
+	planCache.Unlock()
+	for k, v := range mapping {
+		if !strings.Contains(k, ".") { // "struct名"
+			s.Structs++
+			s.Columns[k] = len(v.name.([]string))
+		}
+	}
+	return s
+}
+
+// StructStats 为结构的映射元数据，用于预估语句长度、选择批量大小。
+type StructStats struct {
+	Columns  int // 映射列数（含计算列）
+	Writable int // 可写列数，即Buildstr缺省拼接的列数
+	// HeadBytes 为Buildstr切片形式的列名部分"(列名1,...) VALUES "的字节数
+	HeadBytes int
+	// RowBytes 为Buildstr切片形式中每行值串"(值1,...),"的估计字节数：字符串
+	// 按"size="（无此选项时按32）个单字节字符估计，json、geo字段按64估计，
+	// 其他类型按其字面值的最大长度估计
+	RowBytes int
+}
+
+// Structure 返回结构stru的映射元数据。stru 以变量值的形式作参数，可以取零
+// 值，为nil或不是结构时报错。
+func Structure(stru interface{}) (StructStats, error) {
+	t, err := structarg(stru)
+	if err != nil {
+		return StructStats{}, fmt.Errorf("Structure: %v", err)
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return StructStats{}, fmt.Errorf("Structure: %v", err)
+	}
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return StructStats{}, fmt.Errorf("Structure: %q has no mapping", t)
+	}
+	s := StructStats{Columns: len(e.name.([]string))}
+	if w, ok := mapping["w."+t.Name()]; ok { // except computed columns
+		e = w
+	}
+	s.Writable = len(e.name.([]string))
+	s.HeadBytes = len("() VALUES ") + s.Writable - 1
+	s.RowBytes = len("(),") + s.Writable - 1
+	for _, n := range e.name.([]string) {
+		m := mapping["0."+t.Name()+"."+n]
+		s.HeadBytes += len(m.name.(string))
+		s.RowBytes += estimate(m)
+	}
+	return s, nil
+}
+
+// BatchSize 返回结构stru的切片用Buildstr生成的INSERT值串不超过max字节时，
+// 估计可以容纳的行数（按StructStats.RowBytes），至少为1。
+func BatchSize(stru interface{}, max int) (int, error) {
+	s, err := Structure(stru)
+	if err != nil {
+		return 0, fmt.Errorf("BatchSize: %v", err)
+	}
+	n := (max - s.HeadBytes) / s.RowBytes
+	if n < 1 {
+		n = 1
+	}
+	return n, nil
+}
+
+// estimate 返回映射项m所对应字段字面值的估计字节数，见StructStats。
+func estimate(m entryT) int {
+	if m.info != nil {
+		switch {
+		case m.info.json, m.info.geo:
+			return 64
+		case m.info.size > 0:
+			return m.info.size + 2 // quoted
+		}
+	}
+	switch m.typ {
+	case boolTType:
+		return 5 // false
+	case timeTType, timeType:
+		return len("'2006-01-02 15:04:05.999999'")
+	}
+	switch m.typ.Kind() {
+	case reflect.Bool:
+		return 5
+	case reflect.Int8:
+		return 4 // -128
+	case reflect.Int16:
+		return 6
+	case reflect.Int32:
+		return 11
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return 20
+	case reflect.Uint8:
+		return 3
+	case reflect.Uint16:
+		return 5
+	case reflect.Uint32:
+		return 10
+	case reflect.Float32:
+		return 15 // -3.4028235e+38
+	case reflect.Float64:
+		return 24
+	}
+	return 32 + 2
+}
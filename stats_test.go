@@ -0,0 +1,98 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+type StatsRow struct {
+	ID   int    `db:"pk"`
+	Name string `db:"size=10"`
+	Rank int    `db:"expr='rank() OVER (ORDER BY id)'"`
+}
+
+type StatsPlan struct {
+	ID   int
+	Name string
+}
+
+func TestStats(t *testing.T) {
+	if r := (Statistics{}).PlanHitRate(); r != 0 {
+		t.Errorf("PlanHitRate without Scan: %v", r)
+	}
+	before := Stats()
+	for i := 0; i < 3; i++ {
+		rows := testRows(t, []string{"id", "name"},
+			[]driver.Value{int64(1), "a"})
+		var d []*StatsPlan
+		err := Scan(rows, &d)
+		rows.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := Stats()
+	if s.PlanMisses-before.PlanMisses < 1 || s.PlanHits-before.PlanHits < 2 {
+		t.Errorf("plan hits %d misses %d after 3 identical Scans",
+			s.PlanHits-before.PlanHits, s.PlanMisses-before.PlanMisses)
+	}
+	if s.Columns["StatsPlan"] != 2 || s.Structs < 1 || s.PlanCached < 1 {
+		t.Errorf("Stats: %+v", s)
+	}
+	if r := s.PlanHitRate(); r <= 0 || r > 1 {
+		t.Errorf("PlanHitRate %v", r)
+	}
+}
+
+func TestStructure(t *testing.T) {
+	s, err := Structure([]*StatsRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "(id,name) VALUES ", "(20 digits,'10 chars'),"
+	want := StructStats{Columns: 3, Writable: 2, HeadBytes: 17,
+		RowBytes: 4 + 20 + 12}
+	if s != want {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+	if n, err := BatchSize(StatsRow{}, 17+36*3); err != nil || n != 3 {
+		t.Errorf("BatchSize: %d %v", n, err)
+	}
+
+	for _, bad := range []interface{}{nil, 3, []int{}} {
+		if _, err = Structure(bad); err == nil {
+			t.Errorf("Structure(%#v) accepted", bad)
+		}
+	}
+	if _, err = BatchSize(nil, 100); err == nil ||
+		!strings.Contains(err.Error(), "nil") {
+		t.Errorf("BatchSize(nil): %v", err)
+	}
+}
+
+type StatsKinds struct {
+	A int8
+	B uint16
+	C float32
+	D bool
+	E time.Time
+	F []int `db:"json"`
+	G string
+	H int `db:"expr='a+1'"`
+}
+
+func TestStructureKinds(t *testing.T) {
+	s, err := Structure(&StatsKinds{})
+	// "(a,b,c,d,e,f,g) VALUES ", "(-128,65535,float32,false,'time',json,
+	// 32 chars),"
+	want := StructStats{Columns: 8, Writable: 7, HeadBytes: 23,
+		RowBytes: 9 + 4 + 5 + 15 + 5 + 28 + 64 + 34}
+	if err != nil || s != want {
+		t.Errorf("got %+v %v, want %+v", s, err, want)
+	}
+	if n, _ := BatchSize(StatsKinds{}, 10); n != 1 {
+		t.Errorf("BatchSize less than one row: %d", n)
+	}
+}
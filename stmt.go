@@ -0,0 +1,166 @@
+package sqlaux
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Preparer 为能创建预备语句的数据库句柄，*sql.DB、*sql.Conn 都满足该接口。
+type Preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// StmtCache 为数据库句柄上的预备语句缓存，按语句文本复用*sql.Stmt。它满足
+// Querier，可以代替句柄传给ExecContext、QueryContext、Export等，如：
+//
+//	c := sqlaux.NewStmtCache(db, 256)
+//	f, _ := sqlaux.Buildargs(sqlaux.PostgreSQL, users, "Name", "Age")
+//	_, err := sqlaux.ExecContext(ctx, c, "INSERT INTO users "+f.SQL, f.Args...)
+//
+// 同一结构、同一字段集合（切片时还有同样的行数）由Buildargs生成同样的占位符
+// 语句，因而重复的插入、更新自动复用预备语句，省去每次的解析。语句按经中间
+// 件改写后的文本缓存，改写出每次不同的语句（如追踪注释）时缓存无效。StmtCache
+// 可以并发使用。
+type StmtCache struct {
+	db     Preparer
+	size   int
+	mu     sync.Mutex
+	m      map[string]*cachedT
+	lru    *list.List // of *cachedT, the most recently used at front
+	hits   uint64
+	misses uint64
+}
+
+// cachedT 为缓存的一条预备语句。refs 为已交给调用者而尚未执行完的次数，被淘
+// 汰（gone）的语句在其归零时才关闭，因而执行中的语句不会被关闭。
+type cachedT struct {
+	query string
+	s     *sql.Stmt
+	refs  int
+	gone  bool
+	elem  *list.Element
+}
+
+// stmtHits、stmtMisses 为全部StmtCache的命中、未命中次数，见Stats()。
+var stmtHits, stmtMisses uint64
+
+// NewStmtCache 返回db上至多缓存size条预备语句的缓存，超出时淘汰最久未用的语
+// 句；size<=0时不限。
+func NewStmtCache(db Preparer, size int) *StmtCache {
+	return &StmtCache{db: db, size: size, m: make(map[string]*cachedT),
+		lru: list.New()}
+}
+
+// stmt 返回query的预备语句，没有时创建并缓存之。调用者执行后须以release归还。
+func (c *StmtCache) stmt(ctx context.Context, query string) (*cachedT,
+	error) {
+	c.mu.Lock()
+	if e, ok := c.m[query]; ok {
+		c.hits++
+		atomic.AddUint64(&stmtHits, 1)
+		e.refs++
+		c.lru.MoveToFront(e.elem)
+		c.mu.Unlock()
+		return e, nil
+	}
+	c.misses++
+	atomic.AddUint64(&stmtMisses, 1)
+	c.mu.Unlock()
+	s, err := c.db.PrepareContext(ctx, query) // never block others
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.m[query]; ok { // prepared by another goroutine
+		s.Close()
+		e.refs++
+		c.lru.MoveToFront(e.elem)
+		return e, nil
+	}
+	for c.size > 0 && len(c.m) >= c.size {
+		c.evict(c.lru.Back().Value.(*cachedT))
+	}
+	e := &cachedT{query: query, s: s, refs: 1}
+	e.elem = c.lru.PushFront(e)
+	c.m[query] = e
+	return e, nil
+}
+
+// evict 从缓存中移除e，没有执行中的调用时关闭之。调用者持有c.mu。
+func (c *StmtCache) evict(e *cachedT) error {
+	delete(c.m, e.query)
+	c.lru.Remove(e.elem)
+	e.gone = true
+	if e.refs == 0 {
+		return e.s.Close()
+	}
+	return nil
+}
+
+// release 归还stmt返回的e，关闭执行完毕的已淘汰语句。
+func (c *StmtCache) release(e *cachedT) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e.refs--; e.refs == 0 && e.gone {
+		e.s.Close()
+	}
+}
+
+// ExecContext 以query的预备语句执行之。
+func (c *StmtCache) ExecContext(ctx context.Context, query string,
+	args ...interface{}) (sql.Result, error) {
+	e, err := c.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(e)
+	return e.s.ExecContext(ctx, args...)
+}
+
+// QueryContext 以query的预备语句执行查询。返回的结果集在语句被淘汰后仍可读
+// 完。
+func (c *StmtCache) QueryContext(ctx context.Context, query string,
+	args ...interface{}) (*sql.Rows, error) {
+	e, err := c.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(e) // open rows keep the statement till they are closed
+	return e.s.QueryContext(ctx, args...)
+}
+
+// Len 返回已缓存的预备语句数。
+func (c *StmtCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.m)
+}
+
+// Stats 返回缓存命中、未命中（须新建预备语句）的次数。
+func (c *StmtCache) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Close 关闭并清除全部预备语句，执行中的语句在执行完毕后关闭，之后仍可继续使
+// 用c。
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var first error
+	for _, e := range c.m {
+		if err := c.evict(e); err != nil && first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		return fmt.Errorf("StmtCache.Close: %v", first)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestStmtCacheEviction(t *testing.T) {
+	before := Stats()
+	c := NewStmtCache(tdb, 2)
+	defer c.Close()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				q := fmt.Sprintf("UPDATE t SET a=%d", (g+i)%5)
+				if _, err := c.ExecContext(ctx, q); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if n := c.Len(); n > 2 {
+		t.Errorf("Len %d, want at most 2", n)
+	}
+	hits, misses := c.Stats()
+	if hits+misses != 8*200 || misses < 5 {
+		t.Errorf("hits %d, misses %d", hits, misses)
+	}
+	// the cyclic workload may never hit, so force one
+	for i := 0; i < 2; i++ {
+		if _, err := c.ExecContext(ctx, "UPDATE t SET b=1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	takeExecs()
+	hits, misses = c.Stats()
+	s := Stats()
+	if s.StmtHits-before.StmtHits != hits ||
+		s.StmtMisses-before.StmtMisses != misses || s.StmtHitRate() == 0 {
+		t.Errorf("Stats %+v, before %+v", s, before)
+	}
+}
+
+func TestStmtCacheRowsAfterEviction(t *testing.T) {
+	c := NewStmtCache(tdb, 1)
+	defer c.Close()
+	ctx := context.Background()
+	tresult("SELECT 1", []string{"a"}, []driver.Value{int64(1)},
+		[]driver.Value{int64(2)})
+	rows, err := c.QueryContext(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ExecContext(ctx, "UPDATE t SET a=1"); err != nil { // evicts
+		t.Fatal(err)
+	}
+	takeExecs()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if err = rows.Err(); err != nil || n != 2 {
+		t.Fatalf("%d rows, %v", n, err)
+	}
+	rows.Close()
+	if hits, misses := c.Stats(); hits != 0 || misses != 2 {
+		t.Errorf("hits %d, misses %d", hits, misses)
+	}
+}
@@ -0,0 +1,53 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// ScanChan 逐行接收rows的结果，将每行依次发送到ch（形如chan *struct或
+// chan<- *struct），结束时关闭ch，用于在接收与处理之间建立流水线。接收后
+// ScanChan不主动关闭rows，调用者通常在单独的goroutine中调用之。
+//
+// ch 的缓冲大小即流水线的缓冲行数，由调用者按make(chan *T, n)配置。缓冲满时
+// （处理慢于数据库），ScanChan暂停读取下一行，从而也使驱动停止从网络接收，
+// 待处理者取走结果后立即恢复，内存占用不超过n行。ctx 被取消时停止接收，返回
+// 包含ctx.Err()的错误。
+func ScanChan(ctx context.Context, rows *sql.Rows, ch interface{}) error {
+	cv := reflect.ValueOf(ch)
+	if cv.Kind() != reflect.Chan || cv.IsNil() ||
+		cv.Type().ChanDir()&reflect.SendDir == 0 ||
+		cv.Type().Elem().Kind() != reflect.Ptr ||
+		cv.Type().Elem().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ScanChan: ch is %T, want chan *struct", ch)
+	}
+	ct := cv.Type()
+	defer cv.Close()
+	t := ct.Elem().Elem()
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return fmt.Errorf("ScanChan: %v", err)
+	}
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: cv},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	}
+	err = scanEach(rows, []reflect.Type{t}, &scanOpts{mp: mapping},
+		func(tmp []reflect.Value) error {
+			if err := ctx.Err(); err != nil { // select picks randomly
+				return err
+			}
+			cases[0].Send = tmp[0]
+			if i, _, _ := reflect.Select(cases); i == 1 { // blocks if full
+				return ctx.Err()
+			}
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("ScanChan: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type StreamRow struct {
+	ID   int
+	Name string
+}
+
+func TestScanChan(t *testing.T) {
+	rows := testRows(t, []string{"id", "name"},
+		[]driver.Value{int64(1), "a"}, []driver.Value{int64(2), "b"},
+		[]driver.Value{int64(3), "c"})
+	defer rows.Close()
+	ch := make(chan *StreamRow, 1)
+	done := make(chan error, 1)
+	go func() { done <- ScanChan(context.Background(), rows, ch) }()
+	var got []StreamRow
+	for r := range ch {
+		got = append(got, *r)
+	}
+	want := []StreamRow{{1, "a"}, {2, "b"}, {3, "c"}}
+	if err := <-done; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanChan: got %v %v, want %v", got, err, want)
+	}
+
+	// a full buffer blocks until ctx is cancelled
+	rows = testRows(t, []string{"id"}, []driver.Value{int64(1)},
+		[]driver.Value{int64(2)})
+	defer rows.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	unbuf := make(chan *StreamRow)
+	go func() { done <- ScanChan(ctx, rows, unbuf) }()
+	if r := <-unbuf; r.ID != 1 {
+		t.Errorf("first row %v", *r)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: %v", err)
+	}
+	if _, ok := <-unbuf; ok {
+		t.Error("ch not closed")
+	}
+
+	var nilch chan *StreamRow
+	for _, ch := range []interface{}{nil, nilch, make(<-chan *StreamRow),
+		make(chan StreamRow), make(chan *int), []*StreamRow{}} {
+		if err := ScanChan(context.Background(), rows, ch); err == nil {
+			t.Errorf("ch %T accepted", ch)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package sqlaux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MapTable 为已映射结构stru登记其对应的表table，供Tablename及生成完整语句的
+// 函数使用。也可以在结构定义中以空白字段的tag登记，MapStruct时一并建立：
+//
+//	type User struct {
+//		_    struct{} `db:"table=users"`
+//		Name string
+//	}
+//
+// table 为小写的表名，可以带模式名前缀（如"app.users"）。结构已登记了不同的
+// 表名时报错。与MapStruct相同，可以在任何时候调用（配置中的InitOnly为true时
+// 除外）。
+// stru 以变量值的形式作参数，可以取零值。
+func MapTable(stru interface{}, table string) error {
+	if conf().InitOnly && !isinit() {
+		return fmt.Errorf("MapTable: must be called in init()")
+	}
+	t, err := structarg(stru)
+	if err != nil {
+		return fmt.Errorf("MapTable: %v", err)
+	}
+	if !istable(table) {
+		return fmt.Errorf("MapTable: bad table %q", table)
+	}
+	err = update(func(mapping map[string]entryT) error {
+		if _, ok := mapping[t.Name()]; !ok {
+			return fmt.Errorf("%q has no mapping", t)
+		}
+		if tb := tablename(mapping, t.Name()); tb != "" && tb != table {
+			return fmt.Errorf("%q already has table %q", t, tb)
+		}
+		mapping["t."+t.Name()] = entryT{name: table}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("MapTable: %v", err)
+	}
+	return nil
+}
+
+// Tablename 返回stru所登记的表名（见MapTable、MapHistory），没有时返回""。
+// stru 以变量值的形式作参数，可以取零值，不能映射时返回""。
+func Tablename(stru interface{}) string {
+	t, err := structarg(stru)
+	if err != nil {
+		return ""
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return ""
+	}
+	return tablename(mapping, t.Name())
+}
+
+// tablename 返回mp中名为s的结构所登记的表名，优先取MapTable或tag的登记，其次
+// 取MapHistory的登记，没有时返回""。
+func tablename(mp map[string]entryT, s string) string {
+	if e, ok := mp["t."+s]; ok {
+		return e.name.(string)
+	}
+	h, _ := historyof(mp, s)
+	return h.table
+}
+
+// istable 报告s是否为合法的表名：以"."分隔的一至两个非空小写标识符。
+func istable(s string) bool {
+	p := strings.Split(s, ".")
+	if len(p) > 2 {
+		return false
+	}
+	for _, n := range p {
+		if n == "" || !isident(n) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package sqlaux
+
+import "testing"
+
+type TableUser struct {
+	ID int
+}
+
+type TableBadTag struct {
+	_  struct{} `db:"table=Users"`
+	ID int
+}
+
+func TestMapTable(t *testing.T) {
+	if _, err := Signature(TableUser{}); err != nil { // automapped
+		t.Fatal(err)
+	}
+	for _, tb := range []string{"", "Users", "a.b.c", "a..b", "a b"} {
+		if err := MapTable(TableUser{}, tb); err == nil {
+			t.Errorf("table %q accepted", tb)
+		}
+	}
+	if err := MapTable(&TableUser{}, "app.users"); err != nil {
+		t.Fatal(err)
+	}
+	if err := MapTable(TableUser{}, "app.users"); err != nil {
+		t.Errorf("same table again: %v", err)
+	}
+	if err := MapTable(TableUser{}, "users"); err == nil {
+		t.Error("conflicting table accepted")
+	}
+	for _, stru := range []interface{}{TableUser{}, &TableUser{},
+		[]*TableUser{}} {
+		if tb := Tablename(stru); tb != "app.users" {
+			t.Errorf("Tablename(%T) %q", stru, tb)
+		}
+	}
+
+	type unmapped struct{ ID int }
+	for _, stru := range []interface{}{nil, 1, unmapped{}} {
+		if err := MapTable(stru, "t"); err == nil {
+			t.Errorf("MapTable(%#v) accepted", stru)
+		}
+	}
+	for _, stru := range []interface{}{nil, 1, TableBadTag{}} {
+		if tb := Tablename(stru); tb != "" {
+			t.Errorf("Tablename(%#v) %q", stru, tb)
+		}
+	}
+}
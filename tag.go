@@ -0,0 +1,503 @@
+package sqlaux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// ParseTag 按sqlaux的规则解析字段tag值（即tag中Tag标签的值），返回其中的键
+// 值对，无值的键其值为""，供静态检查等工具使用。
+func ParseTag(tags string) (map[string]string, error) {
+	return parsetag(tags)
+}
+
+// parsetag 解析字段tag值，返回其中的键值对，无值的键（如"pk"）其值为""。
+// 各项以空白分隔，键值以Op分隔，值中含空白时可用单引号括起，其中的单引号写
+// 作两个，如：
+//
+//	`db:"col=age check='age >= 0'"`
+//	`db:"check='status IN (''a'',''b'')'"`
+func parsetag(tags string) (map[string]string, error) {
+	op := conf().Op
+	kv := make(map[string]string)
+	for tags = strings.TrimSpace(tags); tags != ""; {
+		end := strings.IndexAny(tags, " \t\n")
+		if end == -1 {
+			end = len(tags)
+		}
+		item := tags[:end]
+		if p := strings.Index(item, op+"'"); p != -1 { // quoted value
+			b := p + len(op) + 1
+			q := b
+			for ; q < len(tags); q++ {
+				if tags[q] == '\'' {
+					if q+1 < len(tags) && tags[q+1] == '\'' { // escaped
+						q++
+						continue
+					}
+					break
+				}
+			}
+			if q == len(tags) {
+				return nil, fmt.Errorf("bad tag %q: unclosed quote", tags)
+			}
+			end = q + 1
+			kv[tags[:p]] = strings.Replace(tags[b:q], "''", "'", -1)
+		} else if p := strings.Index(item, op); p != -1 {
+			kv[item[:p]] = item[p+len(op):]
+		} else {
+			kv[item] = ""
+		}
+		tags = strings.TrimSpace(tags[end:])
+	}
+	return kv, nil
+}
+
+// parseoverride 解析嵌套结构字段的tag选项"override="的值，如
+// "CreatedAt:created_on,Inner.By:creator"，返回字段名（嵌套成员为相对全名）
+// 到列名的映射。s为""时返回空映射。
+func parseoverride(s string) (map[string]string, error) {
+	ov := make(map[string]string)
+	if s == "" {
+		return ov, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		p := strings.Index(item, ":")
+		if p <= 0 || p == len(item)-1 {
+			return nil, fmt.Errorf("bad tagged 'override' %q", item)
+		}
+		ov[strings.TrimSpace(item[:p])] = strings.TrimSpace(item[p+1:])
+	}
+	return ov, nil
+}
+
+// fieldT 为字段tag中除列名外的附加选项，及由字段类型推导出的属性。
+type fieldT struct {
+	size     int    // 字符长度上限，来自"size="，0为不限
+	dbtype   string // 数据库列类型，来自"dbtype="或"type="，供DDL生成使用
+	nullable bool   // 列可否为NULL，由字段类型推导，可用"null"、"notnull"覆盖
+	deferred bool   // 来自"defer"，interface{}字段接收原始数据库值，延后解码
+	charset  string // MySQL字符集，来自"charset="，如utf8mb4
+	collate  string // MySQL排序规则，来自"collate="，如utf8mb4_bin
+	geo      bool   // 来自"geo[=srid]"，string字段保存WKT格式的几何对象
+	srid     int    // 几何对象的空间参考标识，0为不指定
+	unit     string // time.Duration字段的单位，来自"unit="，缺省为"ns"
+	json     bool   // 来自"json"，字段以JSON文本形式存储
+	expr     string // 来自"expr="，字段为计算列（如窗口函数），只读
+	pk       bool   // 来自"pk"，列为主键（之一）
+	// optional 来自"optional"，列可能尚不存在（如滚动升级中的旧表结构）：结果
+	// 集中没有该列时Scan不接收该字段，CheckColumns也不报告缺少
+	optional bool
+	alias    string // 来自"alias="，列的其他名称，以逗号分隔，Scan时也接收
+	seq      string // 来自"seq="，整数字段的取值序列，见AssignSeq
+	index    string // 来自"index="，所属索引名，以逗号分隔，见CreateIndexSQL
+	unique   string // 来自"unique="，列所属的唯一索引名，同index
+	where    string // 来自"where="，列所属索引为部分索引，此为其条件
+	ref      string // 来自"ref=表.列"，列为引用该表该列的外键，见CheckOrphans
+	comment  string // 来自"comment="，列的说明，生成DDL时一并生成列注释
+	// check 来自"check="，列的CHECK约束，Validate为true时在客户端检查简单的约
+	// 束（见parsecheck）
+	check *checkT
+	// omitempty 来自"omitempty"，字段为零值时不出现在SET值串中，用于部分更新
+	omitempty bool
+	// def 为"scan_default="的值（类型为映射项的typ），Scan时结果集中没有对应
+	// 列或列值为NULL时，字段接收此值
+	def reflect.Value
+}
+
+// fieldinfo 从字段tag键值对kv和字段类型t中提取附加选项，没有任何附加选项
+// 时返回nil。
+func fieldinfo(kv map[string]string, t reflect.Type) (*fieldT, error) {
+	var f fieldT
+	if v, ok := kv["size"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("bad tagged 'size'")
+		}
+		f.size = n
+	}
+	f.dbtype = kv["dbtype"]
+	if v, ok := kv["type"]; ok { // short form
+		if v == "" || f.dbtype != "" && f.dbtype != v {
+			return nil, fmt.Errorf("bad tagged 'type'")
+		}
+		f.dbtype = v
+	}
+	f.nullable = isnullable(t) || netTypes[t] != nil // nil is NULL
+	_, null := kv["null"]
+	_, notnull := kv["notnull"]
+	if null && notnull {
+		return nil, fmt.Errorf("tagged both 'null' and 'notnull'")
+	}
+	if null || notnull {
+		f.nullable = null
+	}
+	if _, ok := kv["defer"]; ok {
+		if t.Kind() != reflect.Interface || t.NumMethod() != 0 {
+			return nil, fmt.Errorf("tagged 'defer' but not interface{}")
+		}
+		f.deferred = true
+	}
+	f.charset, f.collate = kv["charset"], kv["collate"]
+	if !isident(f.charset) || !isident(f.collate) {
+		return nil, fmt.Errorf("bad tagged 'charset' or 'collate'")
+	}
+	if v, ok := kv["geo"]; ok {
+		if t.Kind() != reflect.String {
+			return nil, fmt.Errorf("tagged 'geo' but not string")
+		}
+		if v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("bad tagged 'geo'")
+			}
+			f.srid = n
+		}
+		f.geo = true
+		f.nullable = !notnull // "" is NULL
+	}
+	if _, ok := kv["json"]; ok {
+		switch t.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Ptr:
+			f.nullable = !notnull // nil is NULL
+		case reflect.Struct, reflect.Array:
+		default:
+			return nil, fmt.Errorf("tagged 'json' but not composite type")
+		}
+		f.json = true
+	}
+	f.expr = kv["expr"]
+	if v, ok := kv["alias"]; ok {
+		if v == "" {
+			return nil, fmt.Errorf("bad tagged 'alias'")
+		}
+		f.alias = v
+	}
+	_, f.pk = kv["pk"]
+	_, f.omitempty = kv["omitempty"]
+	if _, f.optional = kv["optional"]; f.optional && f.pk {
+		return nil, fmt.Errorf("tagged both 'optional' and 'pk'")
+	}
+	if v, ok := kv["seq"]; ok {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+			reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+			reflect.Uint32, reflect.Uint64:
+		default:
+			return nil, fmt.Errorf("tagged 'seq' but not integer")
+		}
+		if v == "" || !isident(strings.Replace(strings.ToLower(v), ".", "",
+			-1)) {
+			return nil, fmt.Errorf("bad tagged 'seq'")
+		}
+		f.seq = v
+	}
+	for _, k := range []string{"index", "unique"} {
+		v, ok := kv[k]
+		if !ok {
+			continue
+		}
+		for _, n := range strings.Split(v, ",") {
+			if n == "" || !isident(n) {
+				return nil, fmt.Errorf("bad tagged '%s'", k)
+			}
+		}
+	}
+	f.index, f.unique, f.where = kv["index"], kv["unique"], kv["where"]
+	if _, ok := kv["where"]; ok && (f.where == "" ||
+		f.index == "" && f.unique == "") {
+		return nil, fmt.Errorf("bad tagged 'where' without index")
+	}
+	if v, ok := kv["ref"]; ok {
+		p := strings.Split(v, ".")
+		if len(p) != 2 || p[0] == "" || p[1] == "" || !isident(p[0]) ||
+			!isident(p[1]) {
+			return nil, fmt.Errorf("bad tagged 'ref'")
+		}
+		f.ref = v
+	}
+	if v, ok := kv["comment"]; ok {
+		if strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("bad tagged 'comment'")
+		}
+		f.comment = v
+	}
+	if v, ok := kv["unit"]; ok || t == durationType {
+		if t != durationType {
+			return nil, fmt.Errorf("tagged 'unit' but not time.Duration")
+		}
+		if v == "" {
+			v = "ns"
+		}
+		if _, ok := durUnits[v]; !ok {
+			return nil, fmt.Errorf("bad tagged 'unit'")
+		}
+		f.unit = v
+	}
+	if f == (fieldT{}) {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// isnullable 报告类型t能否接收数据库NULL值：指针，及sql.NullString等
+// database/sql包中的Null*类型。
+func isnullable(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr || t.PkgPath() == "database/sql" &&
+		strings.HasPrefix(t.Name(), "Null")
+}
+
+// builtin 返回字段类型t及其附加选项info所对应的sqlaux内置等价类型，没有时
+// 返回t；指针类型（如*time.Time）对应于其基类型等价类型的指针。调用者通过
+// MapType所作的类型映射优先于内置等价类型。
+func builtin(t reflect.Type, info *fieldT) reflect.Type {
+	switch {
+	case info != nil && info.geo:
+		return geomType
+	case info != nil && info.unit != "":
+		return durUnits[info.unit].typ
+	case t == boolType:
+		return boolTType
+	case t == timeType:
+		return timeTType
+	case netTypes[t] != nil:
+		return netTypes[t]
+	case t.Kind() == reflect.Ptr && builtin(t.Elem(), nil) != t.Elem():
+		return reflect.PtrTo(builtin(t.Elem(), nil))
+	}
+	return t
+}
+
+// isident 报告s是否仅由小写字母、数字和下划线组成，空串也返回true。
+func isident(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// value 向w写入映射项e所对应字段（ptr指向之）的（赋）值串，s为“列名=”或
+// “”，c为配置。time.Time零值按ZeroTime策略处理；写入前按e的附加选项检查字
+// 段值；按d为MySQL时，字符串字面值按charset、collate选项加字符集引导符和
+// COLLATE子句，如：_utf8mb4'abc' COLLATE utf8mb4_bin。
+func (e entryT) value(w io.Writer, d Dialect, c *Config, s string,
+	ptr reflect.Value) error {
+	if e.zerotime(ptr) {
+		switch c.ZeroTime {
+		case ZeroTimeNull:
+			_, err := io.WriteString(w, s+"NULL")
+			return err
+		case ZeroTimeSkip: // SET omits it before here
+			_, err := io.WriteString(w, s+"DEFAULT")
+			return err
+		case ZeroTimeError:
+			return fmt.Errorf("column %q zero time", e.name)
+		case ZeroTimeSentinel:
+			io.WriteString(w, s)
+			return literal(w, d, c, c.ZeroTimeValue)
+		}
+	}
+	if e.info == nil {
+		return buildstr(w, d, c, s, ptr)
+	}
+	if e.info.expr != "" {
+		return fmt.Errorf("column %q is computed", e.name)
+	}
+	if err := e.check(c, ptr); err != nil {
+		return err
+	}
+	if e.info.deferred {
+		ptr = ptr.Elem() // the dynamic value
+	}
+	if e.info.geo {
+		g, err := geomtext(d, ptr.Elem().String(), e.info.srid)
+		if err == nil {
+			io.WriteString(w, s+g)
+		}
+		return err
+	}
+	if e.info.json {
+		v := ptr.Elem()
+		if k := v.Kind(); (k == reflect.Slice || k == reflect.Map ||
+			k == reflect.Ptr) && v.IsNil() {
+			_, err := io.WriteString(w, s+"NULL")
+			return err
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		io.WriteString(w, s)
+		return literal(w, d, c, string(b))
+	}
+	if e.info.unit != "" && d != nil && d.Name() == "postgres" {
+		t := time.Duration(ptr.Elem().Int())
+		_, err := io.WriteString(w, s+intervaltext(t))
+		return err
+	}
+	val, err := valueof(ptr)
+	if err != nil {
+		return err
+	}
+	io.WriteString(w, s)
+	_, str := val.(string)
+	my := str && d != nil && d.Name() == "mysql"
+	if my && e.info.charset != "" {
+		io.WriteString(w, "_"+e.info.charset)
+	}
+	if err = literal(w, d, c, val); err == nil && my && e.info.collate != "" {
+		io.WriteString(w, " COLLATE "+e.info.collate)
+	}
+	return err
+}
+
+// arg 返回映射项e所对应字段（ptr为指向字段的指针）作为语句参数时按配置c的
+// driver.Value值，规则与value相同，但geo字段为WKT文本，time.Time值按
+// c.TimeZone转换时区而不格式化。
+func (e entryT) arg(c *Config, ptr reflect.Value) (interface{}, error) {
+	if e.zerotime(ptr) {
+		switch c.ZeroTime {
+		case ZeroTimeNull, ZeroTimeSkip:
+			return nil, nil
+		case ZeroTimeError:
+			return nil, fmt.Errorf("column %q zero time", e.name)
+		case ZeroTimeSentinel:
+			return c.ZeroTimeValue, nil
+		}
+	}
+	if e.info != nil {
+		if e.info.expr != "" {
+			return nil, fmt.Errorf("column %q is computed", e.name)
+		}
+		if err := e.check(c, ptr); err != nil {
+			return nil, err
+		}
+		if e.info.deferred {
+			ptr = ptr.Elem() // the dynamic value
+		}
+		if e.info.json {
+			v := ptr.Elem()
+			if k := v.Kind(); (k == reflect.Slice || k == reflect.Map ||
+				k == reflect.Ptr) && v.IsNil() {
+				return nil, nil
+			}
+			b, err := json.Marshal(v.Interface())
+			if err != nil {
+				return nil, err
+			}
+			return string(b), nil
+		}
+	}
+	val, err := valueof(ptr)
+	if t, ok := val.(time.Time); ok && c.TimeZone != nil {
+		val = t.In(c.TimeZone)
+	}
+	return val, err
+}
+
+// receiver 返回Scan时接收映射项e所对应字段（地址为p）的变量，time.Time 字
+// 段按c的ZeroTime策略接收。
+func (e entryT) receiver(p unsafe.Pointer, c *Config) interface{} {
+	if e.info != nil && e.info.json {
+		return jsonT{reflect.NewAt(e.typ, p)}
+	}
+	if e.info != nil && e.info.def.IsValid() {
+		return defaultT{e, p, c}
+	}
+	switch e.typ {
+	case timeTType:
+		return timeR{(*timeT)(p), c}
+	case ptrTimeTType:
+		return ptrTimeR{(**timeT)(p), c}
+	}
+	return reflect.NewAt(e.typ, p).Interface()
+}
+
+// setdefault 将映射项e所对应字段（地址为p）置为其scan_default值。
+func (e entryT) setdefault(p unsafe.Pointer) {
+	def := e.info.def
+	if def.Type() != e.typ { // changed by MapType later
+		def = def.Convert(e.typ)
+	}
+	reflect.NewAt(e.typ, p).Elem().Set(def)
+}
+
+// defaultT 为Scan时接收有scan_default字段的变量，NULL接收为scan_default值，
+// 其它值按Decode的规则转换，time.Time 字段按c的ZeroTime策略。
+type defaultT struct {
+	e entryT
+	p unsafe.Pointer
+	c *Config
+}
+
+func (d defaultT) Scan(src interface{}) error {
+	if src == nil {
+		d.e.setdefault(d.p)
+		return nil
+	}
+	if d.e.typ == timeTType {
+		return (*timeT)(d.p).scan(src, d.c)
+	}
+	return convert(reflect.NewAt(d.e.typ, d.p).Elem(), src)
+}
+
+// jsonT 为Scan时接收json字段的变量，v 为指向字段的指针。数据库NULL接收为字段
+// 的零值。
+type jsonT struct{ v reflect.Value }
+
+func (j jsonT) Scan(src interface{}) error {
+	var b []byte
+	switch s := src.(type) {
+	case nil:
+		j.v.Elem().Set(reflect.Zero(j.v.Elem().Type()))
+		return nil
+	case string:
+		b = []byte(s)
+	case []byte:
+		b = s
+	default:
+		return fmt.Errorf("cannot scan %T into json field", src)
+	}
+	j.v.Elem().Set(reflect.Zero(j.v.Elem().Type())) // no merging
+	return json.Unmarshal(b, j.v.Interface())
+}
+
+// omit 报告在配置c下，映射项e所对应的字段（ptr指向之）是否不出现在SET值串
+// 中：按ZeroTimeSkip策略跳过的time.Time零值，或c.OmitEmpty为true、tag中有
+// "omitempty"时的零值。
+func (e entryT) omit(c *Config, ptr reflect.Value) bool {
+	if c.ZeroTime == ZeroTimeSkip && e.zerotime(ptr) {
+		return true
+	}
+	return (c.OmitEmpty || e.info != nil && e.info.omitempty) &&
+		ptr.Elem().IsZero()
+}
+
+// check 在c.Validate为true时，按e的附加选项（size、可在客户端求值的check）检
+// 查字段值v（指向字段的指针）。
+func (e entryT) check(c *Config, v reflect.Value) error {
+	if !c.Validate || e.info == nil {
+		return nil
+	}
+	v = reflect.Indirect(v)
+	if e.info.size > 0 && v.Kind() == reflect.String {
+		if n := utf8.RuneCountInString(v.String()); n > e.info.size {
+			return fmt.Errorf("column %q too long: %d > size %d",
+				e.name, n, e.info.size)
+		}
+	}
+	if e.info.check != nil && !e.info.check.holds(v) {
+		return fmt.Errorf("column %q value %v violates check %q", e.name,
+			v.Interface(), e.info.check.expr)
+	}
+	return nil
+}
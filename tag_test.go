@@ -0,0 +1,321 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TagCode struct {
+	ID   int    `db:"col=id dbtype=bigint"`
+	Code string `db:"size=3"`
+}
+
+func TestParseTag(t *testing.T) {
+	kv, err := ParseTag(`col=a size=3 check='age >= 0' pk`)
+	want := map[string]string{"col": "a", "size": "3",
+		"check": "age >= 0", "pk": ""}
+	if err != nil || !reflect.DeepEqual(kv, want) {
+		t.Errorf("ParseTag: %v %v", kv, err)
+	}
+	if _, err = ParseTag(`check='unterminated`); err == nil {
+		t.Error("ParseTag accepted an unterminated quote")
+	}
+}
+
+func TestSizeValidate(t *testing.T) {
+	long := []*TagCode{{1, "abc"}, {2, "日本語x"}}
+	if _, err := Buildstr(long); err != nil {
+		t.Errorf("Buildstr validated without Validate: %v", err)
+	}
+	c := GetConfig()
+	c.Validate = true
+	_, err := c.Buildstr(long)
+	if err == nil || !strings.Contains(err.Error(),
+		`data[1] column "code" too long: 4 > size 3`) {
+		t.Errorf("Buildstr: %v", err)
+	}
+	if _, err = c.Buildstr(long[0]); err != nil {
+		t.Errorf("Buildstr within size: %v", err)
+	}
+
+	s, err := CreateTableSQL(TagCode{}, "code", PostgreSQL)
+	if err != nil || !strings.Contains(s, `"id" bigint NOT NULL`) ||
+		!strings.Contains(s, `"code" varchar(3) NOT NULL`) {
+		t.Errorf("CreateTableSQL: %s %v", s, err)
+	}
+}
+
+type TagName struct {
+	ID   int
+	Name string `db:"charset=utf8mb4 collate=utf8mb4_bin size=5"`
+}
+
+type TagBadCollate struct {
+	Name string `db:"collate='a b'"`
+}
+
+func TestCharsetCollate(t *testing.T) {
+	var b strings.Builder
+	err := DumpInsertScript(&b, &TagName{1, "x"}, ScriptOpts{Table: "t",
+		Dialect: MySQL})
+	if err != nil || !strings.Contains(b.String(),
+		"VALUES (1,_utf8mb4'x' COLLATE utf8mb4_bin);") {
+		t.Errorf("MySQL literal: %s %v", b.String(), err)
+	}
+	b.Reset()
+	err = DumpInsertScript(&b, &TagName{1, "x"}, ScriptOpts{Table: "t",
+		Dialect: PostgreSQL})
+	if err != nil || !strings.Contains(b.String(), "VALUES (1,'x');") {
+		t.Errorf("PostgreSQL literal: %s %v", b.String(), err)
+	}
+
+	s, err := CreateTableSQL(TagName{}, "t", MySQL)
+	if err != nil || !strings.Contains(s, "`name` varchar(5) "+
+		"CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL") {
+		t.Errorf("CreateTableSQL: %s %v", s, err)
+	}
+	s, _ = CreateTableSQL(TagName{}, "t", PostgreSQL)
+	if strings.Contains(s, "CHARACTER SET") {
+		t.Errorf("charset for PostgreSQL: %s", s)
+	}
+	if _, err = Buildstr(&TagBadCollate{}); err == nil {
+		t.Error("collate with a space accepted")
+	}
+}
+
+type TagLine struct {
+	SKU string
+	Qty int
+}
+
+type TagOrder struct {
+	ID    int
+	Lines []TagLine `db:"json"`
+	Last  TagLine   `db:"json col=last"`
+}
+
+type TagBadJSON struct {
+	N int `db:"json"`
+}
+
+func TestJSONField(t *testing.T) {
+	rows := testRows(t, []string{"id", "lines", "last"},
+		[]driver.Value{int64(1), `[{"SKU":"a","Qty":2}]`,
+			[]byte(`{"SKU":"b"}`)},
+		[]driver.Value{int64(2), nil, "{}"})
+	defer rows.Close()
+	var d []*TagOrder
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	want := []*TagOrder{{1, []TagLine{{"a", 2}}, TagLine{"b", 0}},
+		{2, nil, TagLine{}}}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Scan: %v %v", *d[0], *d[1])
+	}
+	s, err := Buildstr(d)
+	w := `(id,lines,last) VALUES (1,"[{\"SKU\":\"a\",\"Qty\":2}]",` +
+		`"{\"SKU\":\"b\",\"Qty\":0}"),` +
+		`(2,NULL,"{\"SKU\":\"\",\"Qty\":0}")`
+	if err != nil || s != w {
+		t.Errorf("Buildstr:\n got %s %v\nwant %s", s, err, w)
+	}
+	s, err = CreateTableSQL(TagOrder{}, "t", PostgreSQL)
+	if err != nil || !strings.Contains(s, `"lines" jsonb,`) ||
+		!strings.Contains(s, `"last" jsonb NOT NULL`) {
+		t.Errorf("CreateTableSQL: %s %v", s, err)
+	}
+	if _, err = Buildstr(&TagBadJSON{}); err == nil {
+		t.Error("json tag on an int accepted")
+	}
+}
+
+type TagFlags struct {
+	ID    int
+	Beta  bool          `db:"scan_default=true"`
+	Label string        `db:"scan_default='no label'"`
+	Wait  time.Duration `db:"scan_default=5s"`
+}
+
+func TestScanDefault(t *testing.T) {
+	rows := testRows(t, []string{"id", "label"},
+		[]driver.Value{int64(1), nil}, []driver.Value{int64(2), "x"})
+	defer rows.Close()
+	var d []*TagFlags
+	if err := Scan(rows, &d); err != nil {
+		t.Fatal(err)
+	}
+	want := []*TagFlags{{1, true, "no label", 5 * time.Second},
+		{2, true, "x", 5 * time.Second}}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("got %v %v", *d[0], *d[1])
+	}
+}
+
+type TagAudBy struct{ Who, When string }
+
+type TagAudit struct {
+	Created string
+	By      TagAudBy `db:"override='Who:x'"`
+}
+
+type TagDoc struct {
+	ID int
+	A  TagAudit `db:"override='Created:created_on,By.When:at'"`
+}
+
+type TagBadOverride struct {
+	A TagAudit `db:"override='Creatd:c'"`
+}
+
+func TestOverride(t *testing.T) {
+	s, err := Signature(TagDoc{})
+	if want := []string{"id", "created_on", "x", "at"}; err != nil ||
+		!reflect.DeepEqual(s, want) {
+		t.Errorf("Signature: got %q %v, want %q", s, err, want)
+	}
+	if s, _ = Signature(TagAudit{}); !reflect.DeepEqual(s,
+		[]string{"created", "x", "when"}) {
+		t.Errorf("embedded struct itself: %q", s)
+	}
+	_, err = Signature(TagBadOverride{})
+	if err == nil || !strings.Contains(err.Error(), `unknown field "Creatd"`) {
+		t.Errorf("bad override: %v", err)
+	}
+}
+
+type TagAddr struct {
+	Street, City string
+}
+
+type TagShop struct {
+	ID   int
+	Addr TagAddr  `db:"prefix=addr_"`
+	Aud  TagAudit `db:"prefix=aud_ override='By.When:at'"`
+}
+
+func TestPrefix(t *testing.T) {
+	s, err := Signature(TagShop{})
+	want := []string{"id", "addr_street", "addr_city", "aud_created",
+		"aud_x", "at"}
+	if err != nil || !reflect.DeepEqual(s, want) {
+		t.Errorf("Signature: got %q %v, want %q", s, err, want)
+	}
+	rows := testRows(t, want, []driver.Value{int64(1), "s", "c", "", "",
+		""})
+	defer rows.Close()
+	var d []*TagShop
+	if err = Scan(rows, &d); err != nil || d[0].Addr != (TagAddr{"s", "c"}) {
+		t.Errorf("Scan: %v %v", d, err)
+	}
+}
+
+type TagProfile struct {
+	ID    int
+	Email string `db:"optional"`
+	Bio   string `db:"optional"`
+}
+
+type TagBadOptional struct {
+	ID int `db:"pk optional"`
+}
+
+func TestOptional(t *testing.T) {
+	old := testRows(t, []string{"id", "bio"}, []driver.Value{int64(1), "b"})
+	defer old.Close()
+	if err := CheckColumns(old, TagProfile{}); err != nil {
+		t.Errorf("CheckColumns: %v", err)
+	}
+	var d []*TagProfile
+	if err := Scan(old, &d); err != nil || *d[0] != (TagProfile{1, "", "b"}) {
+		t.Errorf("old schema: %v %v", d, err)
+	}
+	cur := testRows(t, []string{"id", "email", "bio"},
+		[]driver.Value{int64(2), "e", "b"})
+	defer cur.Close()
+	d = nil
+	if err := Scan(cur, &d); err != nil || *d[0] != (TagProfile{2, "e", "b"}) {
+		t.Errorf("new schema: %v %v", d, err)
+	}
+	bad := testRows(t, []string{"email"}, []driver.Value{"e"})
+	defer bad.Close()
+	err := CheckColumns(bad, TagProfile{})
+	if err == nil || !strings.Contains(err.Error(), "missing [id]") {
+		t.Errorf("CheckColumns missing id: %v", err)
+	}
+	if _, err = Signature(TagBadOptional{}); err == nil {
+		t.Error("optional primary key accepted")
+	}
+}
+
+type TagContact struct {
+	ID    int
+	Email string `db:"col=email alias=mail,e_mail"`
+}
+
+type TagBadAlias struct {
+	Email string `db:"alias="`
+}
+
+func TestAlias(t *testing.T) {
+	for _, c := range []string{"email", "mail", "E_MAIL"} {
+		rows := testRows(t, []string{"id", c}, []driver.Value{int64(1), "x"})
+		if err := CheckColumns(rows, TagContact{}); err != nil {
+			t.Errorf("CheckColumns %s: %v", c, err)
+		}
+		var d []*TagContact
+		if err := Scan(rows, &d); err != nil || d[0].Email != "x" {
+			t.Errorf("Scan %s: %v %v", c, d, err)
+		}
+		rows.Close()
+	}
+	s, err := Buildstr(&TagContact{1, "x"})
+	if err != nil || s != `SET id=1,email="x"` {
+		t.Errorf("Buildstr: %s %v", s, err)
+	}
+	if s, _ := Signature(TagContact{}); !reflect.DeepEqual(s,
+		[]string{"id", "email"}) {
+		t.Errorf("Signature: %q", s)
+	}
+	if _, err = Signature(TagBadAlias{}); err == nil {
+		t.Error("empty alias accepted")
+	}
+}
+
+type TagPatch struct {
+	ID   int    `db:"pk"`
+	Name string `db:"omitempty"`
+	Age  int    `db:"omitempty"`
+	Memo *string
+}
+
+func TestOmitEmpty(t *testing.T) {
+	p := &TagPatch{ID: 1, Name: "x"}
+	tests := []struct {
+		c    Config
+		want string
+	}{
+		{With(), "SET id=1,name=\"x\",memo=NULL"},
+		{With(WithOmitEmpty()), "SET id=1,name=\"x\""},
+	}
+	for i, tt := range tests {
+		if s, err := tt.c.Buildstr(p); err != nil || s != tt.want {
+			t.Errorf("%d:\n got %s %v\nwant %s", i, s, err, tt.want)
+		}
+	}
+	s, err := Buildstr([]*TagPatch{p})
+	if want := `(id,name,age,memo) VALUES (1,"x",0,NULL)`; err != nil ||
+		s != want {
+		t.Errorf("VALUES:\n got %s %v\nwant %s", s, err, want)
+	}
+	s, err = BuildUpdateByPK(&TagPatch{ID: 1, Age: 3})
+	if want := "SET age=3,memo=NULL WHERE id=1"; err != nil || s != want {
+		t.Errorf("BuildUpdateByPK:\n got %s %v\nwant %s", s, err, want)
+	}
+	if s, err = Buildstr(&TagPatch{}, "Name", "Age"); err == nil {
+		t.Errorf("all fields omitted: %s", s)
+	}
+}
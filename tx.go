@@ -0,0 +1,123 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TxHook 为事务开始钩子，WithTx开启事务后、调用fn之前被调用，可以在事务上
+// 执行会话设置（如PostgreSQL行级安全策略所依赖的会话变量），返回错误时回滚
+// 事务。
+type TxHook func(ctx context.Context, tx *sql.Tx) error
+
+// OnTx 注册事务开始钩子h。与MapStruct相同，可以在任何时候调用（配置中的
+// InitOnly为true时除外），只影响此后开启的事务。
+func OnTx(h ...TxHook) error {
+	if conf().InitOnly && !isinit() {
+		return fmt.Errorf("OnTx: must be called in init()")
+	}
+	for i, f := range h {
+		if f == nil {
+			return fmt.Errorf("OnTx: h[%d] is nil", i)
+		}
+	}
+	updatehooks(func(hs *hooksT) {
+		n := len(hs.txhooks)
+		hs.txhooks = append(hs.txhooks[:n:n], h...)
+	})
+	return nil
+}
+
+// SetLocal 返回一个PostgreSQL事务开始钩子，它将settings(ctx)返回的各项设置
+// 为仅在本事务内有效的会话变量，等价于"SET LOCAL 名=值"，但值以参数传递：
+//
+//	SELECT set_config($1,$2,true),...
+//
+// 如多租户的行级安全策略"USING (tenant_id = current_setting('app.tenant')
+// ::int)"，可注册：
+//
+//	sqlaux.OnTx(sqlaux.SetLocal(func(ctx context.Context) map[string]string {
+//		return map[string]string{"app.tenant": tenantOf(ctx)}
+//	}))
+//
+// settings 返回空时不执行任何语句。
+func SetLocal(settings func(ctx context.Context) map[string]string) TxHook {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		m := settings(ctx)
+		if len(m) == 0 {
+			return nil
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var query strings.Builder
+		args := make([]interface{}, 0, 2*len(keys))
+		query.WriteString("SELECT ")
+		for i, k := range keys {
+			if i > 0 {
+				query.WriteString(",")
+			}
+			query.WriteString("set_config($" + strconv.Itoa(2*i+1) + ",$" +
+				strconv.Itoa(2*i+2) + ",true)")
+			args = append(args, k, m[k])
+		}
+		_, err := tx.ExecContext(ctx, query.String(), args...)
+		return err
+	}
+}
+
+// WithTx 在db上以选项opts开启事务，依次调用已注册的事务开始钩子（见OnTx）后
+// 执行fn：fn 返回nil时提交事务，返回错误或panic时回滚（panic 随后继续）。
+// db 为nil时由路由函数选择写句柄，路由的结构名同ExecContext。fn 返回的错误
+// 原样返回，以便调用者判别。
+func WithTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions,
+	fn func(tx *sql.Tx) error) error {
+	if db == nil {
+		q, err := route(ctx, nil, RouteWrite, "")
+		if err != nil {
+			return fmt.Errorf("WithTx: %v", err)
+		}
+		db = q.(*sql.DB)
+	}
+	return withTx(ctx, db, opts, fn)
+}
+
+// beginner 为可以开启事务的数据库句柄，*sql.DB、*sql.Conn 都满足该接口。
+type beginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// withTx 为WithTx的实现，在db上开启事务。
+func withTx(ctx context.Context, db beginner, opts *sql.TxOptions,
+	fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("WithTx: %v", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	for _, h := range loadhooks().txhooks {
+		if err = h(ctx, tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("WithTx: %v", err)
+		}
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("WithTx: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func TestWithTx(t *testing.T) {
+	old := loadhooks()
+	defer hooks.Store(old)
+	err := OnTx(SetLocal(func(ctx context.Context) map[string]string {
+		s, _ := ctx.Value(tenantKey{}).(string)
+		if s == "" {
+			return nil
+		}
+		return map[string]string{"app.tenant": s, "app.role": "r"}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), tenantKey{}, "7")
+	takeExecs()
+	err = WithTx(ctx, tdb, nil, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE t SET a=1")
+		return err
+	})
+	want := []string{"SELECT set_config($1,$2,true),set_config($3,$4,true)",
+		"UPDATE t SET a=1"}
+	if got := takeExecs(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("WithTx: got %q %v", got, err)
+	}
+	WithTx(context.Background(), tdb, nil, func(*sql.Tx) error { return nil })
+	if got := takeExecs(); got != nil {
+		t.Errorf("empty settings executed %q", got)
+	}
+
+	errFn := errors.New("fn")
+	if err = WithTx(ctx, tdb, nil, func(*sql.Tx) error {
+		return errFn
+	}); err != errFn {
+		t.Errorf("fn error: %v", err)
+	}
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v", p)
+			}
+		}()
+		WithTx(ctx, tdb, nil, func(*sql.Tx) error { panic("boom") })
+	}()
+
+	called := false
+	OnTx(func(context.Context, *sql.Tx) error { return errFn })
+	err = WithTx(ctx, tdb, nil, func(*sql.Tx) error {
+		called = true
+		return nil
+	})
+	if err == nil || called {
+		t.Errorf("failing hook: %v, fn called %v", err, called)
+	}
+	if err = OnTx(nil); err == nil {
+		t.Error("nil hook accepted")
+	}
+	SetRouter(nil)
+	if err = WithTx(ctx, nil, nil, func(*sql.Tx) error {
+		return nil
+	}); err == nil {
+		t.Error("WithTx without a database accepted")
+	}
+}
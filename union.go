@@ -0,0 +1,102 @@
+package sqlaux
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Fragment 为一条带参数的SQL语句或语句片段，Args 与SQL中的占位符一一对应。
+type Fragment struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Union 用UNION（all为true时用UNION ALL）连接各个SELECT语句part，合并其参
+// 数。各part使用PostgreSQL风格的$n占位符时自动重新编号。dest 不为nil时，检
+// 查结果列（即第一个part的选择列）都能映射到dest结构，且各part的列数相同。
+// dest 可以是结构值、结构指针，或Scan的dest参数（如&[]*T）。
+//
+// 注意：各part末尾不能含有ORDER BY、LIMIT子句，整体的排序、限制应附加在结果
+// 语句之后。
+func Union(all bool, dest interface{}, part ...Fragment) (Fragment, error) {
+	if len(part) < 2 {
+		return Fragment{}, fmt.Errorf("Union: need at least 2 parts")
+	}
+	if dest != nil {
+		if err := unioncheck(dest, part); err != nil {
+			return Fragment{}, fmt.Errorf("Union: %v", err)
+		}
+	}
+	op := " UNION "
+	if all {
+		op = " UNION ALL "
+	}
+	var r Fragment
+	var sql strings.Builder
+	for i, p := range part {
+		if i > 0 {
+			sql.WriteString(op)
+		}
+		sql.WriteString(rebase(strings.TrimSpace(p.SQL), len(r.Args)))
+		r.Args = append(r.Args, p.Args...)
+	}
+	r.SQL = sql.String()
+	return r, nil
+}
+
+// unioncheck 检查part的结果列与dest结构的映射是否一致，见Union。
+func unioncheck(dest interface{}, part []Fragment) error {
+	t := structof(reflect.TypeOf(dest))
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return err
+	}
+	if _, ok := mapping[t.Name()]; !ok {
+		return fmt.Errorf("%q has no mapping", t)
+	}
+	var n int
+	for i, p := range part {
+		cols, err := selectcols(p.SQL)
+		if err != nil {
+			return fmt.Errorf("part[%d] %v", i, err)
+		}
+		if i == 0 {
+			n = len(cols)
+			for _, c := range cols {
+				if c == "*" {
+					continue
+				}
+				if _, ok := mapping["1."+t.Name()+"."+c]; !ok {
+					return fmt.Errorf("column %q has no mapping in %q", c,
+						t.Name())
+				}
+			}
+		} else if len(cols) != n && !strings.Contains(p.SQL, "*") {
+			return fmt.Errorf("part[%d] has %d columns, want %d", i,
+				len(cols), n)
+		}
+	}
+	return nil
+}
+
+// structof 去掉t的指针、切片层次，返回其最终的元素类型。
+func structof(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// structarg 返回参数stru（结构，或其指针、切片）的结构类型，stru 为nil或不
+// 是结构时报错。
+func structarg(stru interface{}) (reflect.Type, error) {
+	if stru == nil {
+		return nil, fmt.Errorf("stru is nil")
+	}
+	t := structof(reflect.TypeOf(stru))
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("stru %T not a struct", stru)
+	}
+	return t, nil
+}
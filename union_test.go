@@ -0,0 +1,40 @@
+package sqlaux
+
+import (
+	"reflect"
+	"testing"
+)
+
+type UnionUser struct {
+	ID   int
+	Name string
+}
+
+func TestUnion(t *testing.T) {
+	f, err := Union(true, &[]*UnionUser{},
+		Fragment{"SELECT id, name FROM a WHERE x=$1 AND y=$2",
+			[]interface{}{1, 2}},
+		Fragment{"SELECT id, nick FROM b WHERE z=$1 AND s='$1'",
+			[]interface{}{3}})
+	want := Fragment{"SELECT id, name FROM a WHERE x=$1 AND y=$2 UNION ALL " +
+		"SELECT id, nick FROM b WHERE z=$3 AND s='$1'",
+		[]interface{}{1, 2, 3}}
+	if err != nil || !reflect.DeepEqual(f, want) {
+		t.Errorf("Union:\n got %v %v\nwant %v", f, err, want)
+	}
+	f, err = Union(false, nil, Fragment{SQL: "SELECT a FROM x"},
+		Fragment{SQL: "SELECT b FROM y"})
+	if err != nil || f.SQL != "SELECT a FROM x UNION SELECT b FROM y" {
+		t.Errorf("Union without dest: %v %v", f, err)
+	}
+
+	for _, parts := range [][]Fragment{
+		{{SQL: "SELECT id, nick FROM a"}, {SQL: "SELECT id, name FROM b"}},
+		{{SQL: "SELECT id, name FROM a"}, {SQL: "SELECT id FROM b"}},
+		{{SQL: "SELECT id FROM a"}},
+	} {
+		if _, err = Union(false, UnionUser{}, parts...); err == nil {
+			t.Errorf("Union(%v) accepted", parts)
+		}
+	}
+}
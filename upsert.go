@@ -0,0 +1,112 @@
+package sqlaux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Buildupsert 与Buildstr相同，但生成按d规范的INSERT或更新值串：
+// MySQL："(列...) VALUES (...),... ON DUPLICATE KEY UPDATE 列=VALUES(列),..."
+// PostgreSQL、SQLite："(列...) VALUES (...),... ON CONFLICT (冲突列...) DO
+// UPDATE SET 列=EXCLUDED.列,..."
+// conflict 为唯一约束的字段（MySQL忽略），缺省时为主键字段；update 为冲突时
+// 更新的字段，缺省时为其余所有可写字段，没有时保留原行不变。
+//
+// 约定：data的类型形如[]*struct或*struct，字段名同Buildstr。
+func Buildupsert(d Dialect, data interface{}, conflict,
+	update []string) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("Buildupsert: no dialect")
+	}
+	c := conf()
+	v, err := toslice(data)
+	if err != nil {
+		return "", fmt.Errorf("Buildupsert: %v", err)
+	}
+	t := v.Type().Elem().Elem()
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return "", fmt.Errorf("Buildupsert: %v", err)
+	}
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return "", fmt.Errorf("Buildupsert: %q has no mapping", t)
+	}
+	all := e.name.([]string)
+	if w, ok := mapping["w."+t.Name()]; ok { // except computed ones
+		all = w.name.([]string)
+	}
+	if len(conflict) == 0 {
+		for _, pk := range pkeys(mapping, t.Name()) {
+			for _, f := range all {
+				if mapping["0."+t.Name()+"."+f].same(pk) {
+					conflict = append(conflict, f)
+				}
+			}
+		}
+		if len(conflict) == 0 {
+			return "", fmt.Errorf("Buildupsert: %q has no primary key", t)
+		}
+	}
+	if len(update) == 0 {
+	next:
+		for _, f := range all {
+			for _, k := range conflict {
+				if f == k {
+					continue next
+				}
+			}
+			update = append(update, f)
+		}
+	}
+	cols := func(field []string) ([]string, error) {
+		cs := make([]string, len(field))
+		for i, n := range field {
+			m, ok := mapping["0."+t.Name()+"."+n]
+			if !ok {
+				return nil, nofield(mapping, t.Name(), n)
+			}
+			cs[i] = m.name.(string)
+		}
+		return cs, nil
+	}
+	ks, err := cols(conflict)
+	if err != nil {
+		return "", fmt.Errorf("Buildupsert: %v", err)
+	}
+	us, err := cols(update)
+	if err != nil {
+		return "", fmt.Errorf("Buildupsert: %v", err)
+	}
+
+	var sql strings.Builder
+	if err := valuebuild(&sql, d, c, mapping, v); err != nil {
+		return "", fmt.Errorf("Buildupsert: %v", err)
+	}
+	if d.Name() == "mysql" {
+		sql.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if len(us) == 0 { // keep the old row
+			us = ks[:1]
+		}
+		for i, u := range us {
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString(u + "=VALUES(" + u + ")")
+		}
+		return sql.String(), nil
+	}
+	sql.WriteString(" ON CONFLICT (" + strings.Join(ks, ",") + ")")
+	if len(us) == 0 {
+		sql.WriteString(" DO NOTHING")
+		return sql.String(), nil
+	}
+	sql.WriteString(" DO UPDATE SET ")
+	for i, u := range us {
+		if i > 0 {
+			sql.WriteString(",")
+		}
+		sql.WriteString(u + "=EXCLUDED." + u)
+	}
+	return sql.String(), nil
+}
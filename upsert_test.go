@@ -0,0 +1,56 @@
+package sqlaux
+
+import "testing"
+
+type UpsStock struct {
+	SKU string `db:"pk"`
+	Qty int
+	At  string `db:"col=updated_at"`
+}
+
+type UpsNote struct {
+	Text string
+}
+
+func TestBuildupsert(t *testing.T) {
+	d := []*UpsStock{{"a", 1, "t1"}, {"b", 2, "t2"}}
+	vals := "(sku,qty,updated_at) VALUES ('a',1,'t1'),('b',2,'t2')"
+	tests := []struct {
+		d                Dialect
+		conflict, update []string
+		want             string
+	}{
+		{PostgreSQL, nil, nil, vals + " ON CONFLICT (sku) DO UPDATE SET " +
+			"qty=EXCLUDED.qty,updated_at=EXCLUDED.updated_at"},
+		{SQLite, []string{"SKU", "At"}, []string{"Qty"}, vals +
+			" ON CONFLICT (sku,updated_at) DO UPDATE SET qty=EXCLUDED.qty"},
+		{PostgreSQL, []string{"SKU", "Qty", "At"}, nil, vals +
+			" ON CONFLICT (sku,qty,updated_at) DO NOTHING"},
+		{MySQL, nil, []string{"At"}, vals +
+			" ON DUPLICATE KEY UPDATE updated_at=VALUES(updated_at)"},
+		{MySQL, []string{"SKU", "Qty", "At"}, nil, vals +
+			" ON DUPLICATE KEY UPDATE sku=VALUES(sku)"},
+	}
+	for i, tt := range tests {
+		s, err := Buildupsert(tt.d, d, tt.conflict, tt.update)
+		if err != nil || s != tt.want {
+			t.Errorf("%d:\n got %s %v\nwant %s", i, s, err, tt.want)
+		}
+	}
+	s, err := Buildupsert(MySQL, d[0], nil, nil)
+	if want := "(sku,qty,updated_at) VALUES ('a',1,'t1') ON DUPLICATE KEY " +
+		"UPDATE qty=VALUES(qty),updated_at=VALUES(updated_at)"; err != nil ||
+		s != want {
+		t.Errorf("single:\n got %s %v\nwant %s", s, err, want)
+	}
+
+	if _, err = Buildupsert(nil, d, nil, nil); err == nil {
+		t.Error("nil dialect accepted")
+	}
+	if _, err = Buildupsert(MySQL, d, nil, []string{"Qt"}); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err = Buildupsert(MySQL, &UpsNote{}, nil, nil); err == nil {
+		t.Error("default conflict without primary key accepted")
+	}
+}
@@ -0,0 +1,214 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// VerifyMapping 从db的系统视图（MySQL、PostgreSQL为information_schema.columns，
+// SQLite为pragma_table_info）读取表table的列定义，检查stru的每个映射列都存在，
+// 且类型与字段兼容（按CreateTableSQL推导的列类型比较其大类：整数、小数、字符
+// 串、时间等），不一致时返回详细的差异：缺少的列、类型不兼容的列，以及可为
+// NULL而字段不能接收NULL的列。通常在启动时调用，使映射与表结构的偏差尽早暴
+// 露，而不是在第一次查询失败时。tag 中有"optional"的字段的列可以缺少，有
+// "alias="的字段的列可以是其别名；计算列不检查；表中多余的列不报告。
+// table 为""时取stru所登记的表名（见Tablename），可以带模式名前缀。数据库方
+// 言取Config.Dialect，没有时用DetectDialect探测。
+// stru 以变量值的形式作参数，可以取零值。
+func VerifyMapping(db *sql.DB, stru interface{}, table string) error {
+	ctx := context.Background()
+	c := conf()
+	t, err := structarg(stru)
+	if err != nil {
+		return fmt.Errorf("VerifyMapping: %v", err)
+	}
+	mapping, err := automap(loadmap(), t)
+	if err != nil {
+		return fmt.Errorf("VerifyMapping: %v", err)
+	}
+	e, ok := mapping[t.Name()]
+	if !ok {
+		return fmt.Errorf("VerifyMapping: %q has no mapping", t)
+	}
+	if table == "" {
+		if table = tablename(mapping, t.Name()); table == "" {
+			return fmt.Errorf("VerifyMapping: no table argument")
+		}
+	}
+	if db == nil {
+		q, err := route(ctx, nil, RouteRead, t.Name())
+		if err != nil {
+			return fmt.Errorf("VerifyMapping: %v", err)
+		}
+		db = q.(*sql.DB)
+	}
+	d := c.Dialect
+	if d == nil {
+		var err error
+		if d, _, err = DetectDialect(ctx, db); err != nil {
+			return fmt.Errorf("VerifyMapping: %v", err)
+		}
+	}
+	cols, err := tablecols(ctx, db, d, table)
+	if err != nil {
+		return fmt.Errorf("VerifyMapping: %v", err)
+	}
+	if len(cols) == 0 {
+		return fmt.Errorf("VerifyMapping: table %q not found", table)
+	}
+
+	if w, ok := mapping["w."+t.Name()]; ok { // except computed columns
+		e = w
+	}
+	var missing, diff []string
+	for _, n := range e.name.([]string) {
+		m := mapping["0."+t.Name()+"."+n]
+		col := m.name.(string)
+		got, ok := cols[col]
+		if !ok && m.info != nil && m.info.alias != "" {
+			for _, a := range strings.Split(m.info.alias, ",") {
+				if got, ok = cols[a]; ok {
+					col = a
+					break
+				}
+			}
+		}
+		if !ok {
+			if m.info == nil || !m.info.optional {
+				missing = append(missing, col)
+			}
+			continue
+		}
+		want, err := columntype(d, m)
+		if err == nil && !compatible(typeclass(want), typeclass(got.typ)) {
+			diff = append(diff, fmt.Sprintf("column %q is %s, field %s.%s "+
+				"wants %s", col, got.typ, t.Name(), n, want))
+		}
+		if got.nullable && m.notnull(c.ZeroTime) {
+			diff = append(diff, fmt.Sprintf("column %q is nullable, field "+
+				"%s.%s cannot receive NULL", col, t.Name(), n))
+		}
+	}
+	if len(missing) > 0 {
+		diff = append([]string{fmt.Sprintf("missing %v", missing)}, diff...)
+	}
+	if len(diff) > 0 {
+		return fmt.Errorf("VerifyMapping: %s", strings.Join(diff, "; "))
+	}
+	return nil
+}
+
+// colT 为数据库中一列的定义。
+type colT struct {
+	typ      string // 列类型，小写
+	nullable bool
+}
+
+// tablecols 按d的方言从db的系统视图读取表table的列定义，key为小写的列名。
+// 表不存在时返回空映射。
+func tablecols(ctx context.Context, db *sql.DB, d Dialect,
+	table string) (map[string]colT, error) {
+	schema := ""
+	if dot := strings.Index(table, "."); dot != -1 {
+		schema, table = table[:dot], table[dot+1:]
+	}
+	var query string
+	args := []interface{}{table}
+	switch d.Name() {
+	case "mysql", "postgres":
+		query = "SELECT column_name,data_type,is_nullable FROM " +
+			"information_schema.columns WHERE table_name=" + d.Placeholder(1)
+		switch {
+		case schema != "":
+			query += " AND table_schema=" + d.Placeholder(2)
+			args = append(args, schema)
+		case d.Name() == "mysql":
+			query += " AND table_schema=DATABASE()"
+		default:
+			query += " AND table_schema=current_schema()"
+		}
+	case "sqlite":
+		if schema != "" {
+			return nil, fmt.Errorf("schema %q not supported by sqlite",
+				schema)
+		}
+		query = "SELECT name,type,CASE WHEN \"notnull\"=0 THEN 'YES' " +
+			"ELSE 'NO' END FROM pragma_table_info(" + d.Placeholder(1) + ")"
+	default:
+		return nil, fmt.Errorf("dialect %q not supported", d.Name())
+	}
+	query, args, err := rewrite(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := make(map[string]colT)
+	for rows.Next() {
+		var name, typ, null string
+		if err = rows.Scan(&name, &typ, &null); err != nil {
+			return nil, err
+		}
+		cols[strings.ToLower(name)] = colT{strings.ToLower(typ),
+			strings.EqualFold(null, "YES")}
+	}
+	return cols, rows.Err()
+}
+
+// typeclass 返回列类型typ的大类："int"、"decimal"、"float"、"bool"、
+// "string"、"time"、"interval"、"bytes"、"geo"，不认识时返回""。
+func typeclass(typ string) string {
+	has := func(s ...string) bool {
+		for _, x := range s {
+			if strings.Contains(typ, x) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("interval"):
+		return "interval"
+	case has("geometry", "geography", "point", "line", "polygon"):
+		return "geo"
+	case has("int", "serial"):
+		return "int"
+	case has("numeric", "decimal"):
+		return "decimal"
+	case has("real", "float", "double"):
+		return "float"
+	case has("bool"):
+		return "bool"
+	case has("char", "text", "clob", "uuid", "json", "enum", "inet",
+		"cidr", "macaddr"):
+		return "string"
+	case has("date", "time"):
+		return "time"
+	case has("blob", "bytea", "binary"):
+		return "bytes"
+	}
+	return ""
+}
+
+// compatible 报告推导的列类型大类want与实际的大类got是否兼容：相同，或数值
+// 间可以无损接收，或任一方不认识。
+func compatible(want, got string) bool {
+	switch {
+	case want == "" || got == "" || want == got:
+		return true
+	case want == "bool":
+		return got == "int" // eg. MySQL tinyint(1)
+	case want == "float":
+		return got == "int" || got == "decimal"
+	case want == "int":
+		return got == "decimal" // eg. numeric(20,0)
+	case want == "string":
+		return got == "bytes" // eg. MySQL varbinary
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type VerUser struct {
+	_     struct{} `db:"table=app.ver_users"`
+	ID    int64
+	Name  string
+	Email string  `db:"alias=mail"`
+	Bio   *string `db:"optional"`
+	Age   int
+	Score float64
+	Total int `db:"expr='age + 1'"`
+}
+
+func TestVerifyMapping(t *testing.T) {
+	old := GetConfig()
+	defer SetConfig(old)
+	SetConfig(With(WithDialect(PostgreSQL)))
+	q := "SELECT column_name,data_type,is_nullable FROM " +
+		"information_schema.columns WHERE table_name=$1 AND table_schema=$2"
+	cols := []string{"column_name", "data_type", "is_nullable"}
+	tresult(q, cols, []driver.Value{"id", "bigint", "NO"},
+		[]driver.Value{"name", "character varying", "NO"},
+		[]driver.Value{"mail", "text", "NO"},
+		[]driver.Value{"age", "integer", "NO"},
+		[]driver.Value{"score", "numeric", "NO"},
+		[]driver.Value{"extra", "text", "YES"})
+	if err := VerifyMapping(tdb, &VerUser{}, ""); err != nil {
+		t.Errorf("VerifyMapping: %v", err)
+	}
+
+	tresult(q, cols, []driver.Value{"id", "bigint", "NO"},
+		[]driver.Value{"name", "integer", "NO"},
+		[]driver.Value{"age", "integer", "YES"},
+		[]driver.Value{"score", "double precision", "NO"})
+	err := VerifyMapping(tdb, VerUser{}, "")
+	want := `VerifyMapping: missing [email]; column "name" is integer, ` +
+		`field VerUser.Name wants text; column "age" is nullable, field ` +
+		`VerUser.Age cannot receive NULL`
+	if err == nil || err.Error() != want {
+		t.Errorf("VerifyMapping:\n got %v\nwant %s", err, want)
+	}
+
+	tresult(q, cols)
+	if err = VerifyMapping(tdb, VerUser{}, ""); err == nil {
+		t.Error("missing table accepted")
+	}
+	SetConfig(With(WithDialect(SQLite)))
+	if err = VerifyMapping(tdb, VerUser{}, ""); err == nil {
+		t.Error("schema for SQLite accepted")
+	}
+	if err = VerifyMapping(tdb, nil, "t"); err == nil {
+		t.Error("nil stru accepted")
+	}
+}
@@ -0,0 +1,125 @@
+package vet
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/laokz/sqlaux"
+)
+
+// Queries 检查目录dirs中所有Go源文件里的SQL字符串（字面值和常量）：简单的
+// SELECT语句的选择列、INSERT语句的列清单中的每一列，都应是dirs中某个已映射
+// （MapStruct、MapAdhoc）结构的映射列，否则Scan等运行时将报"has no mapping"。
+// 无法解析的语句忽略。
+func Queries(dirs ...string) ([]Diagnostic, error) {
+	fset := token.NewFileSet()
+	var pkgs []*ast.Package
+	for _, d := range dirs {
+		ps, err := parser.ParseDir(fset, d, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ps {
+			pkgs = append(pkgs, p)
+		}
+	}
+
+	// all mapped columns of the codebase
+	mapped := make(map[string]bool)
+	var all []*ast.File
+	for _, p := range pkgs {
+		files := make([]*ast.File, 0, len(p.Files))
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+		all = append(all, files...)
+		c := newChecker(fset)
+		for _, f := range files {
+			c.decls(f)
+		}
+		for _, f := range files {
+			c.calls(f)
+		}
+		for n := range c.reg {
+			for _, col := range c.columns(n) {
+				mapped[col] = true
+			}
+		}
+	}
+
+	c := newChecker(fset)
+	for _, f := range all {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if s, ok := strlit(exprOf(n)); ok {
+				for _, col := range sqlcols(s) {
+					if !mapped[col] {
+						c.report(n.Pos(), "column %q matches no mapped "+
+							"struct", col)
+					}
+				}
+			}
+			return true
+		})
+	}
+	sortDiags(c.ds)
+	return c.ds, nil
+}
+
+// exprOf 返回n作为表达式的值，n 不是表达式时返回nil。
+func exprOf(n ast.Node) ast.Expr {
+	e, _ := n.(ast.Expr)
+	return e
+}
+
+// insertRe 匹配INSERT语句的列清单。
+var insertRe = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+[^\s(]+\s*\(([^)]*)\)`)
+
+// sqlcols 返回SQL语句s中应有映射的列名，s 不是可解析的SELECT、INSERT语句时返
+// 回nil。
+func sqlcols(s string) []string {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(t, "SELECT "):
+		cols, err := sqlaux.SelectColumns(s)
+		if err != nil {
+			return nil
+		}
+		var r []string
+		for _, c := range cols {
+			if c == "*" {
+				return nil
+			}
+			if c != "" {
+				r = append(r, c)
+			}
+		}
+		return r
+	case strings.HasPrefix(t, "INSERT "):
+		m := insertRe.FindStringSubmatch(s)
+		if m == nil {
+			return nil
+		}
+		var r []string
+		for _, c := range strings.Split(m[1], ",") {
+			r = append(r, strings.ToLower(strings.Trim(strings.TrimSpace(c),
+				"`\"")))
+		}
+		return r
+	}
+	return nil
+}
+
+// sortDiags 按位置排序ds。
+func sortDiags(ds []Diagnostic) {
+	sort.Slice(ds, func(i, j int) bool {
+		a, b := ds[i].Pos, ds[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+}
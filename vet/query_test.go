@@ -0,0 +1,47 @@
+package vet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const querySrc = `package p
+
+import "github.com/laokz/sqlaux"
+
+type Row struct {
+	ID   int
+	Name string
+}
+
+func init() { sqlaux.MapStruct(Row{}) }
+
+const (
+	ok  = "SELECT id,Name,'',COUNT(*) AS name FROM t"
+	bad = "SELECT id,nme FROM t"
+	all = "SELECT * FROM t"
+)
+
+var ins = "INSERT INTO t (id,` + "`name`" + `,age) VALUES (?,?,?)"
+`
+
+func TestQueries(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(querySrc), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := Queries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 2 || !strings.Contains(ds[0].Message, `"nme"`) ||
+		!strings.Contains(ds[1].Message, `"age"`) {
+		t.Errorf("diagnostics %v", ds)
+	}
+	if _, err = Queries(filepath.Join(dir, "none")); err == nil {
+		t.Error("missing directory accepted")
+	}
+}
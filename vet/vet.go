@@ -0,0 +1,497 @@
+// Package vet 静态检查使用sqlaux的Go源码，在编译或CI阶段发现那些运行时才会
+// 出现的映射错误：
+//
+//	● struct tag语法错误，同一结构中重复的列名；
+//	● 用于Scan、Buildstr等，但本包中从未用MapStruct、MapAdhoc映射的结构；
+//	● 选择列与Scan接收结构不符的SELECT语句。
+//
+// vet 只作语法分析而不作类型检查，因而只识别本包中定义的结构，以及常见的写
+// 法，如"var d []*T"、"d := &T{}"、"rows, err := db.Query("SELECT ...")"。
+package vet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/laokz/sqlaux"
+)
+
+// Diagnostic 为一条检查结果。
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%v: %s", d.Pos, d.Message)
+}
+
+// Dir 检查目录path中的Go源文件（含测试文件），按包分别检查。
+func Dir(path string) ([]Diagnostic, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(pkgs))
+	for n := range pkgs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var ds []Diagnostic
+	for _, n := range names {
+		files := make([]*ast.File, 0, len(pkgs[n].Files))
+		for _, f := range pkgs[n].Files {
+			files = append(files, f)
+		}
+		ds = append(ds, Files(fset, files)...)
+	}
+	return ds, nil
+}
+
+// Files 检查同一个包的源文件files，结果按位置排序。
+func Files(fset *token.FileSet, files []*ast.File) []Diagnostic {
+	c := newChecker(fset)
+	sort.Slice(files, func(i, j int) bool {
+		return fset.File(files[i].Pos()).Name() <
+			fset.File(files[j].Pos()).Name()
+	})
+	for _, f := range files {
+		c.decls(f)
+	}
+	for _, n := range c.sortedStructs() {
+		c.columns(n)
+	}
+	for _, f := range files {
+		c.calls(f)
+	}
+	for n, pos := range c.used {
+		if !c.reg[n] {
+			c.report(pos, "struct %s used with sqlaux but never mapped", n)
+		}
+	}
+	sortDiags(c.ds)
+	return c.ds
+}
+
+// checker 为一个包的检查状态。
+type checker struct {
+	fset    *token.FileSet
+	structs map[string]*ast.StructType // 包中定义的结构
+	consts  map[string]string          // 包级字符串常量
+	cols    map[string][]string        // 结构的映射列名，按字段顺序
+	reg     map[string]bool            // 已映射的结构
+	used    map[string]token.Pos       // 用于sqlaux的结构及其首次使用位置
+	busy    map[string]bool            // 正在展开的结构，防止指针循环
+	ds      []Diagnostic
+}
+
+func newChecker(fset *token.FileSet) *checker {
+	return &checker{fset: fset, structs: make(map[string]*ast.StructType),
+		consts: make(map[string]string), reg: make(map[string]bool),
+		used: make(map[string]token.Pos), busy: make(map[string]bool)}
+}
+
+func (c *checker) report(pos token.Pos, format string, a ...interface{}) {
+	c.ds = append(c.ds, Diagnostic{c.fset.Position(pos),
+		fmt.Sprintf(format, a...)})
+}
+
+// decls 收集文件f中的结构定义和字符串常量。
+func (c *checker) decls(f *ast.File) {
+	for _, d := range f.Decls {
+		g, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, s := range g.Specs {
+			switch s := s.(type) {
+			case *ast.TypeSpec:
+				if st, ok := s.Type.(*ast.StructType); ok {
+					c.structs[s.Name.Name] = st
+				}
+			case *ast.ValueSpec:
+				if g.Tok != token.CONST {
+					continue
+				}
+				for i, n := range s.Names {
+					if i < len(s.Values) {
+						if v, ok := strlit(s.Values[i]); ok {
+							c.consts[n.Name] = v
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *checker) sortedStructs() []string {
+	ns := make([]string, 0, len(c.structs))
+	for n := range c.structs {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+// columns 返回结构n的映射列名，首次计算时检查其tag语法和重复列名。
+func (c *checker) columns(n string) []string {
+	if c.cols == nil {
+		c.cols = make(map[string][]string)
+	}
+	if cs, ok := c.cols[n]; ok {
+		return cs
+	}
+	c.cols[n] = nil // no endless recursion
+	var cs []string
+	var pos []token.Pos
+	c.busy[n] = true
+	c.flatten(c.structs[n], "", nil, &cs, &pos)
+	delete(c.busy, n)
+	for i := range cs {
+		for j := 0; j < i; j++ {
+			if cs[i] == cs[j] {
+				c.report(pos[i], "struct %s duplicate column %q", n, cs[i])
+				break
+			}
+		}
+	}
+	c.cols[n] = cs
+	return cs
+}
+
+// flatten 同sqlaux的initmap，将结构st各字段的列名（加前缀pre，按外层的覆盖
+// ov）及其位置追加到cs、pos。
+func (c *checker) flatten(st *ast.StructType, pre string,
+	ov map[string]string, cs *[]string, pos *[]token.Pos) {
+	cfg := sqlaux.GetConfig()
+	for _, f := range st.Fields.List {
+		var kv map[string]string
+		if f.Tag != nil {
+			t, _ := strconv.Unquote(f.Tag.Value)
+			var err error
+			if kv, err = sqlaux.ParseTag(reflect.StructTag(t).Get(
+				cfg.Tag)); err != nil {
+				c.report(f.Tag.Pos(), "%v", err)
+				continue
+			}
+		}
+		if _, ok := kv["child"]; ok { // not a column
+			continue
+		}
+		names := f.Names
+		if len(names) == 0 { // embedded
+			if id := typename(f.Type); id != "" {
+				names = []*ast.Ident{{NamePos: f.Pos(), Name: id}}
+			}
+		}
+		for _, id := range names {
+			if !unicode.IsUpper([]rune(id.Name)[0]) {
+				continue
+			}
+			col, got := strings.ToLower(id.Name), false
+			if m := cfg.NameMapper; m != nil {
+				col = m(id.Name)
+			}
+			if v, ok := kv[cfg.Key]; ok {
+				col, got = v, true
+			}
+			col = pre + col
+			if v, ok := ov[id.Name]; ok {
+				col, got = v, true
+			}
+			_, js := kv["json"]
+			tn := typename(f.Type)
+			if sub, ok := c.structs[tn]; ok && !got && !js &&
+				(!isptr(f.Type) || !c.busy[tn]) { // *struct unless cyclic
+				nov := overrides(kv["override"], pre)
+				for k, v := range ov {
+					if strings.HasPrefix(k, id.Name+".") {
+						nov[k[len(id.Name)+1:]] = v
+					}
+				}
+				c.busy[tn] = true
+				c.flatten(sub, pre+kv["prefix"], nov, cs, pos)
+				delete(c.busy, tn)
+				continue
+			}
+			*cs = append(*cs, col)
+			*pos = append(*pos, id.Pos())
+		}
+	}
+}
+
+// overrides 解析tag选项"override="的值s，列名加前缀pre。
+func overrides(s, pre string) map[string]string {
+	ov := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		if p := strings.Index(item, ":"); p > 0 {
+			ov[strings.TrimSpace(item[:p])] = pre +
+				strings.TrimSpace(item[p+1:])
+		}
+	}
+	return ov
+}
+
+// sqlauxName 返回文件f中sqlaux包的引用名，没有引入时返回""。
+func sqlauxName(f *ast.File) string {
+	for _, im := range f.Imports {
+		p, _ := strconv.Unquote(im.Path.Value)
+		if p == "sqlaux" || strings.HasSuffix(p, "/sqlaux") {
+			if im.Name != nil {
+				return im.Name.Name
+			}
+			return "sqlaux"
+		}
+	}
+	return ""
+}
+
+// calls 检查文件f中对sqlaux各函数的调用。
+func (c *checker) calls(f *ast.File) {
+	pkg := sqlauxName(f)
+	if pkg == "" {
+		return
+	}
+	for _, d := range f.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if x, ok := sel.X.(*ast.Ident); !ok || x.Name != pkg {
+				return true
+			}
+			var ts []string
+			for _, a := range call.Args {
+				t := c.typeOf(fn, a)
+				if t != "" {
+					if sel.Sel.Name == "MapStruct" ||
+						sel.Sel.Name == "MapAdhoc" {
+						c.reg[t] = true
+					} else if _, ok := c.used[t]; !ok {
+						c.used[t] = a.Pos()
+					}
+				}
+				ts = append(ts, t)
+			}
+			switch sel.Sel.Name {
+			case "Scan", "ScanUnique":
+				if len(call.Args) > 1 {
+					c.selects(fn, call.Args[0], ts[1:])
+				}
+			}
+			return true
+		})
+	}
+}
+
+// selects 检查rows所执行的SELECT语句的选择列能否被ts中的结构接收。
+func (c *checker) selects(fn *ast.FuncDecl, rows ast.Expr, ts []string) {
+	q, pos := c.query(fn, rows)
+	if q == "" {
+		return
+	}
+	for _, t := range ts {
+		if t == "" {
+			return // unknown dest
+		}
+	}
+	cols, err := sqlaux.SelectColumns(q)
+	if err != nil {
+		return // too complex to check
+	}
+	j := 0
+	for _, col := range cols {
+		if col == "*" {
+			return
+		}
+		if col == "" {
+			j++
+			continue
+		}
+		for ; j < len(ts) && !contains(c.columns(ts[j]), col); j++ {
+		}
+		if j == len(ts) {
+			c.report(pos, "SELECT column %q has no mapping in %s", col,
+				strings.Join(ts, ", "))
+			return
+		}
+	}
+}
+
+// query 返回函数fn中rows变量所执行的SELECT语句及其位置，找不到时返回""。
+func (c *checker) query(fn *ast.FuncDecl, rows ast.Expr) (string, token.Pos) {
+	id, ok := rows.(*ast.Ident)
+	if !ok {
+		return "", 0
+	}
+	var q string
+	var pos token.Pos
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		as, ok := n.(*ast.AssignStmt)
+		if !ok || len(as.Rhs) != 1 || as.Pos() > id.Pos() {
+			return true
+		}
+		for _, l := range as.Lhs {
+			if l, ok := l.(*ast.Ident); ok && l.Name == id.Name {
+				if call, ok := as.Rhs[0].(*ast.CallExpr); ok {
+					for _, a := range call.Args {
+						if s, ok := c.str(a); ok && strings.HasPrefix(
+							strings.ToUpper(strings.TrimSpace(s)),
+							"SELECT") {
+							q, pos = s, a.Pos()
+						}
+					}
+				}
+			}
+		}
+		return true
+	})
+	return q, pos
+}
+
+// str 返回字符串字面值或包级字符串常量e的值。
+func (c *checker) str(e ast.Expr) (string, bool) {
+	if id, ok := e.(*ast.Ident); ok {
+		s, ok := c.consts[id.Name]
+		return s, ok
+	}
+	return strlit(e)
+}
+
+// typeOf 推断函数fn中表达式e所涉及的本包结构名，无法推断时返回""。
+func (c *checker) typeOf(fn *ast.FuncDecl, e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return c.typeOf(fn, e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return c.typeOf(fn, e.X)
+		}
+	case *ast.CompositeLit:
+		return c.known(typename(e.Type))
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && len(e.Args) > 0 &&
+			(id.Name == "new" || id.Name == "make") {
+			return c.known(typename(e.Args[0]))
+		}
+	case *ast.Ident:
+		return c.known(declType(fn, e))
+	}
+	return ""
+}
+
+// known 返回本包结构名n，n 不是本包结构时返回""。
+func (c *checker) known(n string) string {
+	if _, ok := c.structs[n]; ok {
+		return n
+	}
+	return ""
+}
+
+// declType 在函数fn的参数和函数体中查找变量id的声明，返回其元素类型名。
+func declType(fn *ast.FuncDecl, id *ast.Ident) string {
+	for _, f := range fn.Type.Params.List {
+		for _, n := range f.Names {
+			if n.Name == id.Name {
+				return typename(f.Type)
+			}
+		}
+	}
+	var t string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if name.Name != id.Name {
+					continue
+				}
+				if n.Type != nil {
+					t = typename(n.Type)
+				} else if i < len(n.Values) {
+					t = exprType(n.Values[i])
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, l := range n.Lhs {
+				if l, ok := l.(*ast.Ident); ok && l.Name == id.Name {
+					t = exprType(n.Rhs[i])
+				}
+			}
+		}
+		return t == ""
+	})
+	return t
+}
+
+// exprType 返回初始化表达式e的元素类型名，如&T{}、[]*T{}、make([]*T, n)。
+func exprType(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.UnaryExpr:
+		return exprType(e.X)
+	case *ast.CompositeLit:
+		return typename(e.Type)
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && len(e.Args) > 0 &&
+			(id.Name == "new" || id.Name == "make") {
+			return typename(e.Args[0])
+		}
+	}
+	return ""
+}
+
+// typename 返回类型表达式t去掉指针、切片后的类型名，不是本包类型时返回""。
+func typename(t ast.Expr) string {
+	switch t := t.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return typename(t.X)
+	case *ast.ArrayType:
+		return typename(t.Elt)
+	}
+	return ""
+}
+
+func isptr(t ast.Expr) bool {
+	_, ok := t.(*ast.StarExpr)
+	return ok
+}
+
+// strlit 返回字符串字面值e的值。
+func strlit(e ast.Expr) (string, bool) {
+	if b, ok := e.(*ast.BasicLit); ok && b.Kind == token.STRING {
+		s, err := strconv.Unquote(b.Value)
+		return s, err == nil
+	}
+	return "", false
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
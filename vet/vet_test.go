@@ -0,0 +1,39 @@
+package vet
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/laokz/sqlaux"
+)
+
+const keySrc = `package p
+
+type Row struct {
+	A int ` + "`db:\"name=x\"`" + `
+	B int ` + "`db:\"name=x\"`" + `
+}
+`
+
+func TestConfigKey(t *testing.T) {
+	old := sqlaux.GetConfig()
+	defer sqlaux.SetConfig(old)
+	c := old
+	c.Key = "name"
+	if err := sqlaux.SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", keySrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := Files(fset, []*ast.File{f})
+	if len(ds) != 1 ||
+		!strings.Contains(ds[0].Message, `duplicate column "x"`) {
+		t.Errorf("diagnostics %v", ds)
+	}
+}
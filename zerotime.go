@@ -0,0 +1,114 @@
+package sqlaux
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ZeroTimePolicy 为time.Time字段零值的处理策略。
+type ZeroTimePolicy int
+
+// time.Time零值的处理策略，写入指Buildstr等拼接值串，接收指Scan。
+const (
+	ZeroTimeKeep     ZeroTimePolicy = iota // 原样写入；接收NULL报错
+	ZeroTimeNull                           // 写入NULL；NULL接收为零值
+	ZeroTimeSkip                           // SET中略去，VALUES中写DEFAULT；同上
+	ZeroTimeError                          // 写入时报错；接收NULL报错
+	ZeroTimeSentinel                       // 写入ZeroTimeValue；其与NULL均接收为零值
+)
+
+// ZeroTime 为当前的time.Time零值处理策略，ZeroTimeValue 为ZeroTimeSentinel策
+// 略所使用的代替值，如time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)。
+//
+// Deprecated: 以SetConfig或WithNullPolicy选项设置Config.ZeroTime、
+// ZeroTimeValue；这两个变量只在首次使用sqlaux时读取一次。
+var (
+	ZeroTime      = ZeroTimeKeep
+	ZeroTimeValue time.Time
+)
+
+// timeT 为time.Time字段的等价类型，按ZeroTime策略接收NULL和代替值；除
+// time.Time外还接受文本形式的时间，MySQL的"0000-00-00"零日期接收为零值。
+type timeT time.Time
+
+var (
+	timeTType    = reflect.TypeOf(timeT{})
+	ptrTimeTType = reflect.PtrTo(timeTType)
+)
+
+// Scan 按全局配置的ZeroTime策略接收src。
+func (t *timeT) Scan(src interface{}) error {
+	return t.scan(src, conf())
+}
+
+// scan 按c的ZeroTime策略接收src。
+func (t *timeT) scan(src interface{}, c *Config) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		if zt := c.ZeroTime; zt == ZeroTimeKeep || zt == ZeroTimeError {
+			return fmt.Errorf("cannot scan NULL into time.Time")
+		}
+		*t = timeT{}
+		return nil
+	case time.Time:
+		if c.ZeroTime == ZeroTimeSentinel && v.Equal(c.ZeroTimeValue) {
+			v = time.Time{}
+		}
+		*t = timeT(v)
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("cannot scan %T into time.Time", src)
+	}
+	if strings.HasPrefix(s, "0000-00-00") {
+		*t = timeT{}
+		return nil
+	}
+	var tt time.Time
+	if err := convert(reflect.ValueOf(&tt).Elem(), s); err != nil {
+		return err
+	}
+	return t.scan(tt, c)
+}
+
+// timeR 为Scan时接收time.Time字段（t指向之）的变量，按c的ZeroTime策略。
+type timeR struct {
+	t *timeT
+	c *Config
+}
+
+func (r timeR) Scan(src interface{}) error { return r.t.scan(src, r.c) }
+
+// ptrTimeR 为Scan时接收*time.Time字段（p指向之）的变量：NULL 接收为nil，其
+// 它值按c的ZeroTime策略。
+type ptrTimeR struct {
+	p **timeT
+	c *Config
+}
+
+func (r ptrTimeR) Scan(src interface{}) error {
+	if src == nil {
+		*r.p = nil
+		return nil
+	}
+	t := new(timeT)
+	if err := t.scan(src, r.c); err != nil {
+		return err
+	}
+	*r.p = t
+	return nil
+}
+
+func (t timeT) Value() (driver.Value, error) { return time.Time(t), nil }
+
+// zerotime 报告映射项e所对应的字段（ptr指向之）是否为time.Time零值。
+func (e entryT) zerotime(ptr reflect.Value) bool {
+	return e.typ == timeTType && time.Time(ptr.Elem().Interface().(timeT)).IsZero()
+}
@@ -0,0 +1,97 @@
+package sqlaux
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+type ZtEvent struct {
+	ID   int
+	At   time.Time
+	Done *time.Time
+}
+
+func TestConfigScanNullPolicy(t *testing.T) {
+	sentinel := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"id", "at", "done"}
+	row := []driver.Value{int64(1), nil, sentinel}
+
+	rows := testRows(t, cols, row)
+	var d []*ZtEvent
+	if err := Scan(rows, &d); err == nil {
+		t.Error("global ZeroTimeKeep accepted NULL")
+	}
+	rows.Close()
+
+	c := With(WithNullPolicy(ZeroTimeSentinel, sentinel))
+	check := func(how string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", how, err)
+		}
+		if len(d) != 1 || !d[0].At.IsZero() || d[0].Done == nil ||
+			!d[0].Done.IsZero() {
+			t.Errorf("%s: got %+v", how, d)
+		}
+		d = nil
+	}
+	rows = testRows(t, cols, row)
+	check("Scan", c.Scan(rows, &d))
+	rows.Close()
+	rows = testRows(t, cols, row)
+	check("ScanContext", c.ScanContext(context.Background(), rows, &d))
+	rows.Close()
+	tresult("SELECT * FROM zt_event", cols, row)
+	check("QueryContext", c.QueryContext(context.Background(), tdb,
+		"SELECT * FROM zt_event", nil, &d))
+
+	// NULL into a pointer is always nil
+	rows = testRows(t, cols, []driver.Value{int64(2), sentinel, nil})
+	defer rows.Close()
+	if err := c.Scan(rows, &d); err != nil || d[0].Done != nil {
+		t.Errorf("got %+v %v", d, err)
+	}
+}
+
+func TestZeroTimeBuild(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	d := []*ZtEvent{{ID: 1, At: at}, {ID: 2}}
+	tests := []struct {
+		p    ZeroTimePolicy
+		want string
+	}{
+		{ZeroTimeKeep, `(2,"0001-01-01 00:00:00",NULL)`},
+		{ZeroTimeNull, `(2,NULL,NULL)`},
+		{ZeroTimeSkip, `(2,DEFAULT,NULL)`},
+		{ZeroTimeSentinel, `(2,"1970-01-01 00:00:00",NULL)`},
+	}
+	for _, tt := range tests {
+		c := With(WithNullPolicy(tt.p, time.Unix(0, 0).UTC()))
+		s, err := c.Buildstr(d)
+		want := `(id,at,done) VALUES (1,"2020-01-02 03:04:05",NULL),` +
+			tt.want
+		if err != nil || s != want {
+			t.Errorf("policy %d:\n got %s %v\nwant %s", tt.p, s, err, want)
+		}
+	}
+	c := With(WithNullPolicy(ZeroTimeSkip, time.Time{}))
+	if s, err := c.Buildstr(d[1]); err != nil || s != "SET id=2,done=NULL" {
+		t.Errorf("ZeroTimeSkip SET: %s %v", s, err)
+	}
+	c = With(WithNullPolicy(ZeroTimeError, time.Time{}))
+	if _, err := c.Buildstr(d); err == nil {
+		t.Error("ZeroTimeError accepted a zero time")
+	}
+
+	// NULL and the MySQL zero date are scanned as zero time
+	rows := testRows(t, []string{"id", "at"}, []driver.Value{int64(3), nil},
+		[]driver.Value{int64(4), "0000-00-00 00:00:00"})
+	defer rows.Close()
+	c = With(WithNullPolicy(ZeroTimeNull, time.Time{}))
+	if err := c.Scan(rows, &d); err != nil || len(d) != 2 ||
+		!d[0].At.IsZero() || !d[1].At.IsZero() {
+		t.Errorf("ZeroTimeNull Scan: %v", err)
+	}
+}